@@ -3,7 +3,7 @@ package conv
 import (
 	"math"
 
-	"gonum.org/v1/gonum/mat"
+	"DoodleGan/tensor"
 )
 
 type (
@@ -17,7 +17,7 @@ type ActivationsLambdas struct {
 }
 
 type ReLU struct {
-	SavedDataMat
+	SavedData
 	ActivationsLambdas
 
 	SavedGrads
@@ -25,7 +25,7 @@ type ReLU struct {
 
 type LeakyReLU struct {
 	alpha float64
-	SavedDataMat
+	SavedData
 	ActivationsLambdas
 
 	SavedGrads
@@ -33,21 +33,21 @@ type LeakyReLU struct {
 
 type ELU struct {
 	alpha float64
-	SavedDataMat
+	SavedData
 	ActivationsLambdas
 
 	SavedGrads
 }
 
 type Sigmoid struct {
-	SavedDataMat
+	SavedData
 	ActivationsLambdas
 
 	SavedGrads
 }
 
 type Tanh struct {
-	SavedDataMat
+	SavedData
 	ActivationsLambdas
 
 	SavedGrads
@@ -55,28 +55,27 @@ type Tanh struct {
 
 func ApplyOnInputMatDense(
 	act func(i, j int, v float64) float64,
-	source, dest *[]mat.Dense,
+	source, dest *tensor.Tensor,
 ) {
-	for i := range len(*source) {
-		(*dest)[i].Apply(
-			act,
-			&(*source)[i],
-		)
+	n, c, _, _ := source.Dims()
+	for sample := range n {
+		for channel := range c {
+			dest.Mat(sample, channel).Apply(act, source.Mat(sample, channel))
+		}
 	}
 }
 
 func BackwardApply(
 	actPrime func(i, j int, v float64) float64,
-	lastInput []mat.Dense,
-	inGrads *[]mat.Dense,
-) []mat.Dense {
-	result := make([]mat.Dense, len(lastInput))
-	for i := range len(lastInput) {
-		result[i].Apply(
-			actPrime,
-			&lastInput[i],
-		)
-		result[i].MulElem(&result[i], &(*inGrads)[i])
+	lastInput *tensor.Tensor,
+	inGrads *tensor.Tensor,
+) *tensor.Tensor {
+	n, c, h, w := lastInput.Dims()
+	result := tensor.New(n, c, h, w, nil)
+	ApplyOnInputMatDense(actPrime, lastInput, result)
+	resultData := result.RawData()
+	for i, g := range inGrads.RawData() {
+		resultData[i] *= g
 	}
 	return result
 }
@@ -99,21 +98,18 @@ func NewReLU() ReLU {
 	}
 }
 
-func (layer *ReLU) Forward(input *[]mat.Dense) *[]mat.Dense {
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, len(*input))
-	ApplyOnInputMatDense(layer.lambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *ReLU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	n, c, h, w := input.Dims()
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	ApplyOnInputMatDense(layer.lambda, input, layer.lastOutput)
+	return layer.lastOutput
 }
 
-func (layer *ReLU) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
+func (layer *ReLU) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
 	layer.lastOutGrads = BackwardApply(layer.lambdaPrime, layer.lastInput, inGrads)
-	return &layer.lastOutGrads
-}
-
-func (layer *ReLU) DeflatOutGrads() *[]mat.Dense {
-	return &layer.lastOutGrads
+	return layer.lastOutGrads
 }
 
 func NewLeakyReLU(alpha float64) LeakyReLU {
@@ -136,21 +132,18 @@ func NewLeakyReLU(alpha float64) LeakyReLU {
 	}
 }
 
-func (layer *LeakyReLU) Forward(input *[]mat.Dense) *[]mat.Dense {
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, len(*input))
-	ApplyOnInputMatDense(layer.lambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *LeakyReLU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	n, c, h, w := input.Dims()
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	ApplyOnInputMatDense(layer.lambda, input, layer.lastOutput)
+	return layer.lastOutput
 }
 
-func (layer *LeakyReLU) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
+func (layer *LeakyReLU) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
 	layer.lastOutGrads = BackwardApply(layer.lambdaPrime, layer.lastInput, inGrads)
-	return &layer.lastOutGrads
-}
-
-func (layer *LeakyReLU) DeflatOutGrads() *[]mat.Dense {
-	return &layer.lastOutGrads
+	return layer.lastOutGrads
 }
 
 func NewELU(alpha ...float64) ELU {
@@ -179,21 +172,18 @@ func NewELU(alpha ...float64) ELU {
 	}
 }
 
-func (layer *ELU) Forward(input *[]mat.Dense) *[]mat.Dense {
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, len(*input))
-	ApplyOnInputMatDense(layer.lambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *ELU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	n, c, h, w := input.Dims()
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	ApplyOnInputMatDense(layer.lambda, input, layer.lastOutput)
+	return layer.lastOutput
 }
 
-func (layer *ELU) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
+func (layer *ELU) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
 	layer.lastOutGrads = BackwardApply(layer.lambdaPrime, layer.lastInput, inGrads)
-	return &layer.lastOutGrads
-}
-
-func (layer *ELU) DeflatOutGrads() *[]mat.Dense {
-	return &layer.lastOutGrads
+	return layer.lastOutGrads
 }
 
 func NewSigmoid() Sigmoid {
@@ -211,21 +201,18 @@ func NewSigmoid() Sigmoid {
 	}
 }
 
-func (layer *Sigmoid) Forward(input *[]mat.Dense) *[]mat.Dense {
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, len(*input))
-	ApplyOnInputMatDense(layer.lambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *Sigmoid) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	n, c, h, w := input.Dims()
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	ApplyOnInputMatDense(layer.lambda, input, layer.lastOutput)
+	return layer.lastOutput
 }
 
-func (layer *Sigmoid) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
+func (layer *Sigmoid) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
 	layer.lastOutGrads = BackwardApply(layer.lambdaPrime, layer.lastInput, inGrads)
-	return &layer.lastOutGrads
-}
-
-func (layer *Sigmoid) DeflatOutGrads() *[]mat.Dense {
-	return &layer.lastOutGrads
+	return layer.lastOutGrads
 }
 
 func NewTanh() Tanh {
@@ -243,19 +230,16 @@ func NewTanh() Tanh {
 	}
 }
 
-func (layer *Tanh) Forward(input *[]mat.Dense) *[]mat.Dense {
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, len(*input))
-	ApplyOnInputMatDense(layer.lambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *Tanh) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	n, c, h, w := input.Dims()
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	ApplyOnInputMatDense(layer.lambda, input, layer.lastOutput)
+	return layer.lastOutput
 }
 
-func (layer *Tanh) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
+func (layer *Tanh) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
 	layer.lastOutGrads = BackwardApply(layer.lambdaPrime, layer.lastInput, inGrads)
-	return &layer.lastOutGrads
-}
-
-func (layer *Tanh) DeflatOutGrads() *[]mat.Dense {
-	return &layer.lastOutGrads
+	return layer.lastOutGrads
 }
@@ -9,6 +9,7 @@ import (
 
 	"DoodleGan/conv"
 	"DoodleGan/functools"
+	"DoodleGan/tensor"
 )
 
 func TestReLU(t *testing.T) {
@@ -17,12 +18,12 @@ func TestReLU(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{1, -1, -888, 0}),
 		*mat.NewDense(2, 2, []float64{-3, -4, -5, 1}),
 	}
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 0, 0, 0}),
 		*mat.NewDense(2, 2, []float64{0, 0, 0, 1}),
 	}
-	if !reflect.DeepEqual(output, &target) {
+	if !reflect.DeepEqual(&output, &target) {
 		t.Fatal()
 	}
 }
@@ -33,17 +34,17 @@ func TestReLU_Backward(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{1, -1, -888, 0}),
 		*mat.NewDense(2, 2, []float64{-3, -4, -5, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 1, 1, 1}),
 		*mat.NewDense(2, 2, []float64{1, 2, 3, 4}),
 	}
-	output := layer.Backward(&inGrads)
+	output := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 0, 0, 0}),
 		*mat.NewDense(2, 2, []float64{0, 0, 0, 4}),
 	}
-	if !reflect.DeepEqual(output, &target) {
+	if !reflect.DeepEqual(&output, &target) {
 		fmt.Println(target)
 		fmt.Println(output)
 		t.Fatal()
@@ -56,12 +57,12 @@ func TestLeakyReLU(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{1, -1, -888, 0}),
 		*mat.NewDense(2, 2, []float64{-3, -4, -5, 2}),
 	}
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, -1.0 * 0.1, -888.0 * 0.1, 0}),
 		*mat.NewDense(2, 2, []float64{-3.0 * 0.1, -4.0 * 0.1, -5.0 * 0.1, 2}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		t.Fatal()
 	}
 }
@@ -73,17 +74,17 @@ func TestLeakyReLU_Backward(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{1, -1, -888, 0}),
 		*mat.NewDense(2, 2, []float64{-3, -4, -5, 2}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 1, 1, 1}),
 		*mat.NewDense(2, 2, []float64{1, 2, 3, 4}),
 	}
-	output := layer.Backward(&inGrads)
+	output := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 0.1, 0.1, 0.1}),
 		*mat.NewDense(2, 2, []float64{0.1, 0.2, 0.3, 4}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		fmt.Println(output)
 		fmt.Println(target)
 		t.Fatal()
@@ -96,12 +97,12 @@ func TestELU(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{1, -1, 0, 10}),
 		*mat.NewDense(2, 2, []float64{-2, 0.1, -10, -0.1}),
 	}
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, -0.316060279, 0, 10}),
 		*mat.NewDense(2, 2, []float64{-0.432332358, 0.1, -0.4999773, -0.047581291}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		t.Fatal()
 	}
 }
@@ -113,17 +114,17 @@ func TestELU_Backward(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{1, -1, 0, 10}),
 		*mat.NewDense(2, 2, []float64{-3, -4, -5, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 1, 1, 1}),
 		*mat.NewDense(2, 2, []float64{1, 2, 3, 4}),
 	}
-	output := layer.Backward(&inGrads)
+	output := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 0.09196986, 0.25, 1}),
 		*mat.NewDense(2, 2, []float64{0.012446767 * 1, 0.00457891 * 2, 0.001684487 * 3, 1 * 4}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		fmt.Println(output)
 		fmt.Println(target)
 		t.Fatal()
@@ -136,12 +137,12 @@ func TestSigmoid(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{-2, -1, 1, 2}),
 		*mat.NewDense(2, 2, []float64{-2000, 2000, 0, 5.999}),
 	}
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{0.119202922, 0.268941421, 0.731058579, 0.880797078}),
 		*mat.NewDense(2, 2, []float64{0, 1, 0.5, 0.997524909}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		t.Fatal()
 	}
 }
@@ -152,17 +153,17 @@ func TestSigmoid_Backward(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{-2, -1, 1, 2}),
 		*mat.NewDense(2, 2, []float64{-2000, 2000, 0, 5.999}),
 	}
-	layer.Forward(&input1)
+	layer.Forward(tensor.FromMats(input1))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 1, 1, 1}),
 		*mat.NewDense(2, 2, []float64{1, 2, 3, 4}),
 	}
-	output := layer.Backward(&inGrads)
+	output := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{0.104993585, 0.196611933, 0.196611933, 0.104993585}),
 		*mat.NewDense(2, 2, []float64{0, 0, 0.75, 0.002468965 * 4}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		fmt.Println(output)
 		fmt.Println(target)
 		t.Fatal()
@@ -175,12 +176,12 @@ func TestTanh(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{-2, -1, 1, 2}),
 		*mat.NewDense(2, 2, []float64{-20, 2000, 0, 5.999}),
 	}
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{-0.96402758007, -0.76159415595, 0.76159415595, 0.96402758007}),
 		*mat.NewDense(2, 2, []float64{-1, 1, 0, 0.99998768705}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		t.Fatal()
 	}
 }
@@ -191,17 +192,17 @@ func TestTanh_Backward(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{-2, -1, 1, 2}),
 		*mat.NewDense(2, 2, []float64{-2000, 2000, 0, 5.999}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 1, 1, 1}),
 		*mat.NewDense(2, 2, []float64{1, 2, 3, 4}),
 	}
-	output := layer.Backward(&inGrads)
+	output := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{0.070650825, 0.419974342, 0.419974342, 0.070650825}),
 		*mat.NewDense(2, 2, []float64{0, 0, 3, 0.000024626 * 4}),
 	}
-	if !functools.IsEqualMatSlice(output, &target, 0.001) {
+	if !functools.IsEqualMatSlice(&output, &target, 0.001) {
 		fmt.Println(output)
 		fmt.Println(target)
 		t.Fatal()
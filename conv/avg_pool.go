@@ -4,6 +4,7 @@ import (
 	"gonum.org/v1/gonum/mat"
 
 	"DoodleGan/functools"
+	"DoodleGan/tensor"
 )
 
 type AvgPool struct {
@@ -55,23 +56,25 @@ func (layer *AvgPool) PrintFilter(precision int) {
 	functools.PrintMat(&layer.matPool, precision)
 }
 
-func (layer *AvgPool) Forward(input *[]mat.Dense) *[]mat.Dense {
-	inputFlatDim := layer.inputSize.height * layer.inputSize.width
-
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, len(*input))
-
-	for i := range len(*input) {
-		flatInput := *mat.NewVecDense(inputFlatDim, functools.FlattenMat(&(*input)[i]))
-		var pm mat.Dense
-		pm.Mul(&layer.matPool, &flatInput)
-		layer.lastOutput[i] = pm
+func (layer *AvgPool) Forward(input *tensor.Tensor) *tensor.Tensor {
+	n, c, _, _ := input.Dims()
+	checkInputDims("AvgPool forward", input, c, layer.inputSize)
+	layer.lastInput = input
+	layer.lastOutput = tensor.New(n, c, layer.outputSize.height, layer.outputSize.width, nil)
+	for sample := range n {
+		for i := range c {
+			flatInput := mat.NewVecDense(layer.inputSize.FlatDim(), input.Channel(sample, i))
+			pooled := mat.NewVecDense(
+				layer.outputSize.FlatDim(),
+				layer.lastOutput.Channel(sample, i),
+			)
+			pooled.MulVec(&layer.matPool, flatInput)
+		}
 	}
-	return &layer.lastOutput
+	return layer.lastOutput
 }
 
-func buildAvgGradMat(grads *mat.Dense, retSize, poolSize *MatSize) mat.Dense {
-	retData := make([]float64, retSize.FlatDim())
+func buildAvgGradMat(grads *mat.Dense, retSize, poolSize *MatSize, dest []float64) {
 	n, m := grads.Dims()
 	scaler := float64(1.0 / (float64(poolSize.FlatDim())))
 	for i := range n {
@@ -80,40 +83,27 @@ func buildAvgGradMat(grads *mat.Dense, retSize, poolSize *MatSize) mat.Dense {
 			for pi := range poolSize.height {
 				for pj := range poolSize.width {
 					offset := (i*poolSize.height+pi)*retSize.width + j*poolSize.width + pj
-					retData[offset] = cGrad
+					dest[offset] += cGrad
 				}
 			}
 		}
 	}
-	return *mat.NewDense(retSize.height, retSize.width, retData)
 }
 
-func (layer *AvgPool) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
-	layer.lastOutGrads = make([]mat.Dense, len(*inGrads))
-	for i, grad := range *inGrads {
-		layer.lastOutGrads[i] = *mat.NewDense(
-			layer.inputSize.height,
-			layer.inputSize.width,
-			nil,
-		)
-		avgPoolGrad := buildAvgGradMat(&grad, &layer.inputSize, &layer.poolSize)
-		layer.lastOutGrads[i].Add(
-			&layer.lastOutGrads[i],
-			&avgPoolGrad,
-		)
+func (layer *AvgPool) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	n, c, _, _ := inGrads.Dims()
+	checkInputDims("AvgPool backward", inGrads, c, layer.outputSize)
+	layer.lastInGrads = inGrads
+	layer.lastOutGrads = tensor.New(n, c, layer.inputSize.height, layer.inputSize.width, nil)
+	for sample := range n {
+		for i := range c {
+			buildAvgGradMat(
+				inGrads.Mat(sample, i),
+				&layer.inputSize,
+				&layer.poolSize,
+				layer.lastOutGrads.Channel(sample, i),
+			)
+		}
 	}
-	return &layer.lastOutGrads
-}
-
-func (layer *AvgPool) DeflatOutGrads() *[]mat.Dense {
-	return &layer.lastOutGrads
-}
-
-func (layer *AvgPool) DeflatOutput() *[]mat.Dense {
-	return GetDeflatOutput(&layer.ConvType)
-}
-
-func (layer *AvgPool) FlatOutput() *[]float64 {
-	return GetFlatOutput(&layer.ConvType)
+	return layer.lastOutGrads
 }
@@ -8,6 +8,7 @@ import (
 
 	"DoodleGan/conv"
 	"DoodleGan/functools"
+	"DoodleGan/tensor"
 )
 
 func TestPool_1(t *testing.T) {
@@ -18,14 +19,15 @@ func TestPool_1(t *testing.T) {
 		-3, 5, 1, 2,
 		-2, 1, 2, 8,
 	})}
-	layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input))
 	target := []float64{
 		11. / 4, 3. / 4,
 		1. / 4, 13. / 4,
 	}
-	if !functools.IsEqual(&target, layer.FlatOutput(), 0.01) {
+	result := output.RawData()
+	if !functools.IsEqual(&target, &result, 0.01) {
 		fmt.Println(target)
-		fmt.Println(*layer.FlatOutput())
+		fmt.Println(result)
 		t.Fatal()
 	}
 }
@@ -39,14 +41,15 @@ func TestPool_2(t *testing.T) {
 		-2, 1, 2, 8, 7,
 		2, 3, 5, 1, 0,
 	})}
-	layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input))
 	target := []float64{
 		11. / 4, 3. / 4,
 		1. / 4, 13. / 4,
 	}
-	if !functools.IsEqual(&target, layer.FlatOutput(), 0.01) {
+	result := output.RawData()
+	if !functools.IsEqual(&target, &result, 0.01) {
 		fmt.Println(target)
-		fmt.Println(*layer.FlatOutput())
+		fmt.Println(result)
 		t.Fatal()
 	}
 }
@@ -69,7 +72,7 @@ func TestPool_3(t *testing.T) {
 			0, -3, -3, -4, 5,
 		}),
 	}
-	layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input))
 	targetFlat := []float64{
 		18. / 9,
 		14. / 9,
@@ -78,14 +81,16 @@ func TestPool_3(t *testing.T) {
 		*mat.NewDense(1, 1, []float64{18. / 9}),
 		*mat.NewDense(1, 1, []float64{14. / 9}),
 	}
-	if !functools.IsEqual(&targetFlat, layer.FlatOutput(), 0.01) {
+	resultFlat := output.RawData()
+	resultDeflat := output.Mats(0)
+	if !functools.IsEqual(&targetFlat, &resultFlat, 0.01) {
 		fmt.Println(targetFlat)
-		fmt.Println(*layer.FlatOutput())
+		fmt.Println(resultFlat)
 		t.Fatal()
 	}
-	if !functools.IsEqualMatSlice(&targetDeflat, layer.DeflatOutput(), 0.01) {
+	if !functools.IsEqualMatSlice(&targetDeflat, &resultDeflat, 0.01) {
 		fmt.Println(targetDeflat)
-		fmt.Println(layer.DeflatOutput())
+		fmt.Println(resultDeflat)
 		t.Fatal()
 	}
 }
@@ -95,7 +100,7 @@ func TestPool_Backward_1(t *testing.T) {
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 2, 4, -1}),
 	}
-	result := layer.Backward(&inGrads)
+	result := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{
 			1.0 / 4.0, 1.0 / 4.0, 1.0 / 2.0, 1.0 / 2.0,
@@ -104,9 +109,9 @@ func TestPool_Backward_1(t *testing.T) {
 			1.0, 1.0, -1.0 / 4.0, -1.0 / 4.0,
 		}),
 	}
-	if !functools.IsEqualMatSlice(&target, result, 0.001) {
+	if !functools.IsEqualMatSlice(&target, &result, 0.001) {
 		functools.PrintMatSlice(&target, 2)
-		functools.PrintMatSlice(result, 2)
+		functools.PrintMatSlice(&result, 2)
 		t.Fail()
 	}
 }
@@ -116,7 +121,7 @@ func TestPool_Backward_2(t *testing.T) {
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{1, 2, 4, -1}),
 	}
-	result := layer.Backward(&inGrads)
+	result := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(5, 5, []float64{
 			1.0 / 4.0, 1.0 / 4.0, 1.0 / 2.0, 1.0 / 2.0, 0.0,
@@ -126,9 +131,9 @@ func TestPool_Backward_2(t *testing.T) {
 			0.0, 0.0, 0.0, 0.0, 0.0,
 		}),
 	}
-	if !functools.IsEqualMatSlice(&target, result, 0.001) {
+	if !functools.IsEqualMatSlice(&target, &result, 0.001) {
 		functools.PrintMatSlice(&target, 2)
-		functools.PrintMatSlice(result, 2)
+		functools.PrintMatSlice(&result, 2)
 		t.Fail()
 	}
 }
@@ -139,7 +144,7 @@ func TestPool_Backward_3(t *testing.T) {
 		*mat.NewDense(1, 1, []float64{3}),
 		*mat.NewDense(1, 1, []float64{18}),
 	}
-	result := layer.Backward(&inGrads)
+	result := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{
 			3.0 / 9.0, 3.0 / 9.0, 3.0 / 9.0, 0,
@@ -154,9 +159,9 @@ func TestPool_Backward_3(t *testing.T) {
 			0, 0, 0, 0,
 		}),
 	}
-	if !functools.IsEqualMatSlice(&target, result, 0.001) {
+	if !functools.IsEqualMatSlice(&target, &result, 0.001) {
 		functools.PrintMatSlice(&target, 2)
-		functools.PrintMatSlice(result, 2)
+		functools.PrintMatSlice(&result, 2)
 		t.Fail()
 	}
 }
@@ -0,0 +1,240 @@
+package conv
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"DoodleGan/tensor"
+)
+
+// Normalizes every channel over the batch and all its positions, then scales it by gamma
+// and shifts by beta. In inference mode running mean and variance are used instead.
+type BatchNorm2D struct {
+	numChannels int
+	momentum    float64 // running = momentum * running + (1 - momentum) * batch
+	eps         float64
+	training    bool
+
+	gamma       []float64
+	beta        []float64
+	runningMean []float64
+	runningVar  []float64
+
+	SavedData
+	SavedGrads
+	normalized *tensor.Tensor
+	invStd     []float64
+	gammaGrads *tensor.Tensor
+	betaGrads  *tensor.Tensor
+}
+
+func NewBatchNorm2D(numChannels int, momentum, eps float64) BatchNorm2D {
+	if numChannels < 1 {
+		mess := fmt.Sprintf(
+			"NewBatchNorm2D fail:\n\tNumber of channels must be positive, have: %d",
+			numChannels,
+		)
+		panic(mess)
+	}
+	if momentum < 0.0 || momentum >= 1.0 || eps <= 0.0 {
+		mess := fmt.Sprintf(
+			"NewBatchNorm2D fail:\n\tmomentum (%f) must be in range [0, 1) and eps (%f) positive",
+			momentum,
+			eps,
+		)
+		panic(mess)
+	}
+	gamma := make([]float64, numChannels)
+	runningVar := make([]float64, numChannels)
+	for c := range numChannels {
+		gamma[c] = 1.0
+		runningVar[c] = 1.0
+	}
+	return BatchNorm2D{
+		numChannels: numChannels,
+		momentum:    momentum,
+		eps:         eps,
+		training:    true,
+		gamma:       gamma,
+		beta:        make([]float64, numChannels),
+		runningMean: make([]float64, numChannels),
+		runningVar:  runningVar,
+		invStd:      make([]float64, numChannels),
+		gammaGrads:  tensor.New(1, 1, 1, numChannels, nil),
+		betaGrads:   tensor.New(1, 1, 1, numChannels, nil),
+	}
+}
+
+func (layer *BatchNorm2D) SetTraining(training bool) {
+	layer.training = training
+}
+
+func (layer *BatchNorm2D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	n, c, h, w := input.Dims()
+	if c != layer.numChannels {
+		mess := fmt.Sprintf(
+			"BatchNorm2D forward fail:\n\tInput channels (%d) don't match number of channels (%d)",
+			c,
+			layer.numChannels,
+		)
+		panic(mess)
+	}
+	if layer.training && n*h*w < 2 {
+		panic("BatchNorm2D forward fail:\n\tTraining mode needs at least 2 values per channel in a batch, variance of one value is 0")
+	}
+	layer.lastInput = input
+	mean, variance := layer.runningMean, layer.runningVar
+	if layer.training {
+		mean, variance = channelMeanVar(input)
+		for ch := range layer.numChannels {
+			layer.runningMean[ch] = layer.momentum*layer.runningMean[ch] + (1.0-layer.momentum)*mean[ch]
+			layer.runningVar[ch] = layer.momentum*layer.runningVar[ch] + (1.0-layer.momentum)*variance[ch]
+		}
+	}
+	for ch := range layer.numChannels {
+		layer.invStd[ch] = 1.0 / math.Sqrt(variance[ch]+layer.eps)
+	}
+
+	layer.normalized = tensor.New(n, c, h, w, nil)
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	for sample := range n {
+		for ch := range c {
+			normalized := layer.normalized.Channel(sample, ch)
+			output := layer.lastOutput.Channel(sample, ch)
+			for k, v := range input.Channel(sample, ch) {
+				normalized[k] = (v - mean[ch]) * layer.invStd[ch]
+				output[k] = layer.gamma[ch]*normalized[k] + layer.beta[ch]
+			}
+		}
+	}
+	return layer.lastOutput
+}
+
+// Gamma and beta gradients are summed over the batch and all positions
+func (layer *BatchNorm2D) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
+	n, c, h, w := inGrads.Dims()
+	gammaGrads := layer.gammaGrads.RawData()
+	betaGrads := layer.betaGrads.RawData()
+	clear(gammaGrads)
+	clear(betaGrads)
+	for sample := range n {
+		for ch := range c {
+			normalized := layer.normalized.Channel(sample, ch)
+			for k, g := range inGrads.Channel(sample, ch) {
+				gammaGrads[ch] += g * normalized[k]
+				betaGrads[ch] += g
+			}
+		}
+	}
+
+	layer.lastOutGrads = tensor.New(n, c, h, w, nil)
+	mFloat := float64(n * h * w)
+	for sample := range n {
+		for ch := range c {
+			normalized := layer.normalized.Channel(sample, ch)
+			outGrads := layer.lastOutGrads.Channel(sample, ch)
+			scale := layer.gamma[ch] * layer.invStd[ch]
+			for k, g := range inGrads.Channel(sample, ch) {
+				if layer.training {
+					outGrads[k] = scale / mFloat *
+						(mFloat*g - betaGrads[ch] - normalized[k]*gammaGrads[ch])
+				} else {
+					outGrads[k] = scale * g
+				}
+			}
+		}
+	}
+	return layer.lastOutGrads
+}
+
+func (layer *BatchNorm2D) GetWeightsGrads() *tensor.Tensor {
+	return layer.gammaGrads
+}
+
+func (layer *BatchNorm2D) GetBiasGrads() *tensor.Tensor {
+	return layer.betaGrads
+}
+
+func (layer *BatchNorm2D) Params() []tensor.Param {
+	return []tensor.Param{
+		{Name: "gamma", Value: tensor.New(1, 1, 1, layer.numChannels, layer.gamma), Grad: layer.gammaGrads},
+		{Name: "beta", Value: tensor.New(1, 1, 1, layer.numChannels, layer.beta), Grad: layer.betaGrads},
+	}
+}
+
+func (layer *BatchNorm2D) LoadGamma(gamma *[]float64) {
+	checkChannelsLength("LoadGamma", gamma, layer.numChannels)
+	layer.gamma = slices.Clone(*gamma)
+}
+
+func (layer *BatchNorm2D) LoadBeta(beta *[]float64) {
+	checkChannelsLength("LoadBeta", beta, layer.numChannels)
+	layer.beta = slices.Clone(*beta)
+}
+
+func (layer *BatchNorm2D) LoadRunningStats(mean, variance *[]float64) {
+	checkChannelsLength("LoadRunningStats", mean, layer.numChannels)
+	checkChannelsLength("LoadRunningStats", variance, layer.numChannels)
+	layer.runningMean = slices.Clone(*mean)
+	layer.runningVar = slices.Clone(*variance)
+}
+
+func (layer *BatchNorm2D) GetGamma() *[]float64 {
+	return &layer.gamma
+}
+
+func (layer *BatchNorm2D) GetBeta() *[]float64 {
+	return &layer.beta
+}
+
+func (layer *BatchNorm2D) GetRunningStats() (*[]float64, *[]float64) {
+	return &layer.runningMean, &layer.runningVar
+}
+
+func (layer *BatchNorm2D) NumChannels() int {
+	return layer.numChannels
+}
+
+// Biased mean and variance of every channel over the batch and all positions
+func channelMeanVar(input *tensor.Tensor) ([]float64, []float64) {
+	n, c, h, w := input.Dims()
+	mFloat := float64(n * h * w)
+	mean := make([]float64, c)
+	variance := make([]float64, c)
+	for sample := range n {
+		for ch := range c {
+			for _, v := range input.Channel(sample, ch) {
+				mean[ch] += v
+			}
+		}
+	}
+	for ch := range c {
+		mean[ch] /= mFloat
+	}
+	for sample := range n {
+		for ch := range c {
+			for _, v := range input.Channel(sample, ch) {
+				d := v - mean[ch]
+				variance[ch] += d * d
+			}
+		}
+	}
+	for ch := range c {
+		variance[ch] /= mFloat
+	}
+	return mean, variance
+}
+
+func checkChannelsLength(funcName string, source *[]float64, numChannels int) {
+	if len(*source) != numChannels {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tSource length (%d) doesn't match number of channels (%d)",
+			funcName,
+			len(*source),
+			numChannels,
+		)
+		panic(mess)
+	}
+}
@@ -0,0 +1,139 @@
+package conv_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
+)
+
+func TestBatchNorm2D_Forward(t *testing.T) {
+	layer := conv.NewBatchNorm2D(2, 0.9, 1e-12)
+	gamma := []float64{2, 1}
+	beta := []float64{0, 1}
+	layer.LoadGamma(&gamma)
+	layer.LoadBeta(&beta)
+	// Channel 0 has mean 2 and variance 1, channel 1 mean 0 and variance 4
+	input := tensor.New(2, 2, 1, 2, []float64{
+		1, 3,
+		-2, 2,
+
+		1, 3,
+		2, -2,
+	})
+	output := layer.Forward(input).RawData()
+	target := []float64{
+		-2, 2,
+		0, 2,
+
+		-2, 2,
+		2, 0,
+	}
+	if !functools.IsEqual(&target, &output, 1e-9) {
+		fmt.Println(output)
+		t.Fail()
+	}
+
+	mean, variance := layer.GetRunningStats()
+	targetMean := []float64{0.2, 0}
+	targetVar := []float64{1, 1.3}
+	if !functools.IsEqual(&targetMean, mean, 1e-9) || !functools.IsEqual(&targetVar, variance, 1e-9) {
+		fmt.Println(*mean, *variance)
+		t.Fail()
+	}
+}
+
+// Backward compared with numerical gradient of sum(weights * output)
+func TestBatchNorm2D_Backward(t *testing.T) {
+	gamma := []float64{0.5, -2}
+	beta := []float64{1, 0.5}
+	newLayer := func() conv.BatchNorm2D {
+		layer := conv.NewBatchNorm2D(2, 0.9, 1e-5)
+		layer.LoadGamma(&gamma)
+		layer.LoadBeta(&beta)
+		return layer
+	}
+	inputData := []float64{
+		0.5, -1, 2, 0.3,
+		-1.5, 1.2, 0.7, 0.1,
+
+		1, 2, -0.5, 0,
+		0.4, -0.8, 1.1, 2.5,
+	}
+	weights := []float64{
+		1, -2, 0.5, 1,
+		-1, 3, 0.2, 0,
+
+		2, 1, -1, 0.5,
+		1, 1, -3, 0.7,
+	}
+	lossAt := func(data []float64) float64 {
+		layer := newLayer()
+		output := layer.Forward(tensor.New(2, 2, 2, 2, data)).RawData()
+		retVal := 0.0
+		for i := range output {
+			retVal += weights[i] * output[i]
+		}
+		return retVal
+	}
+
+	layer := newLayer()
+	layer.Forward(tensor.New(2, 2, 2, 2, inputData))
+	outGrads := layer.Backward(tensor.New(2, 2, 2, 2, weights)).RawData()
+
+	h := 1e-6
+	numGrads := make([]float64, len(inputData))
+	for i := range inputData {
+		plus := append([]float64{}, inputData...)
+		minus := append([]float64{}, inputData...)
+		plus[i] += h
+		minus[i] -= h
+		numGrads[i] = (lossAt(plus) - lossAt(minus)) / (2 * h)
+	}
+	if !functools.IsEqual(&numGrads, &outGrads, 1e-5) {
+		fmt.Println(numGrads)
+		fmt.Println(outGrads)
+		t.Fail()
+	}
+
+	betaGrads := layer.GetBiasGrads().RawData()
+	targetBetaGrads := []float64{3, 1.9}
+	if !functools.IsEqual(&targetBetaGrads, &betaGrads, 1e-9) {
+		fmt.Println(betaGrads)
+		t.Fail()
+	}
+}
+
+// Training updates only the layer copies of loaded values
+func TestBatchNorm2D_Load_Copies(t *testing.T) {
+	layer := conv.NewBatchNorm2D(1, 0.9, 1e-12)
+	gamma := []float64{2}
+	beta := []float64{0.5}
+	mean := []float64{0}
+	variance := []float64{1}
+	layer.LoadGamma(&gamma)
+	layer.LoadBeta(&beta)
+	layer.LoadRunningStats(&mean, &variance)
+	layer.Forward(tensor.New(1, 1, 2, 2, []float64{1, 2, 3, 6}))
+	(*layer.GetGamma())[0] = 5
+	(*layer.GetBeta())[0] = 5
+
+	loaded := []float64{gamma[0], beta[0], mean[0], variance[0]}
+	target := []float64{2, 0.5, 0, 1}
+	if !functools.IsEqual(&target, &loaded, 1e-12) {
+		fmt.Println(loaded)
+		t.Fail()
+	}
+}
+
+func TestBatchNorm2D_Train_single_value_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := conv.NewBatchNorm2D(1, 0.9, 1e-12)
+	layer.Forward(tensor.New(1, 1, 1, 1, []float64{1}))
+}
@@ -0,0 +1,214 @@
+package conv
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"DoodleGan/functools"
+	"DoodleGan/regularizers"
+)
+
+// Serializable description of a conv layer: type, hyperparameters and trained values
+type LayerConfig struct {
+	Type            string    `json:"type"`
+	KernelSize      [2]int    `json:"kernel_size,omitempty"`
+	PoolSize        [2]int    `json:"pool_size,omitempty"`
+	NumberOfFilters int       `json:"number_of_filters,omitempty"`
+	InputSize       [2]int    `json:"input_size,omitempty"`
+	InputChannels   int       `json:"input_channels,omitempty"`
+	Stride          [2]int    `json:"stride,omitempty"`
+	Padding         [4]int    `json:"padding,omitempty"` // N, E, S, W
+	OutputPadding   [2]int    `json:"output_padding,omitempty"`
+	OutputSize      [2]int    `json:"output_size,omitempty"`
+	OutputChannels  int       `json:"output_channels,omitempty"`
+	Alpha           float64   `json:"alpha,omitempty"`
+	Rate            float64   `json:"rate,omitempty"`
+	Momentum        float64   `json:"momentum,omitempty"`
+	Eps             float64   `json:"eps,omitempty"`
+	Filters         []float64 `json:"filters,omitempty"`
+	Bias            []float64 `json:"bias,omitempty"`
+	Gamma           []float64 `json:"gamma,omitempty"`
+	Beta            []float64 `json:"beta,omitempty"`
+	RunningMean     []float64 `json:"running_mean,omitempty"`
+	RunningVar      []float64 `json:"running_var,omitempty"`
+
+	Regularizers []regularizers.Config `json:"regularizers,omitempty"`
+}
+
+func GetLayerConfig(layer ConvLayer) (LayerConfig, error) {
+	switch l := layer.(type) {
+	case *Conv2D:
+		regularizerConfigs, err := regularizers.GetConfigs(l.weightRegularizers)
+		if err != nil {
+			return LayerConfig{}, err
+		}
+		filters := make([]float64, 0, l.NumChannels()*l.kernelSize.FlatDim())
+		for i := range l.filters {
+			filters = append(filters, functools.FlattenMat(&l.filters[i])...)
+		}
+		return LayerConfig{
+			Type:            "Conv2D",
+			KernelSize:      [2]int{l.kernelSize.height, l.kernelSize.width},
+			NumberOfFilters: l.numberOfFilters,
+			InputSize:       [2]int{l.inputSize.height, l.inputSize.width},
+			InputChannels:   l.inputChannels,
+			Stride:          [2]int{l.stride.vertical, l.stride.horizontal},
+			Padding:         [4]int{l.padding.up, l.padding.right, l.padding.down, l.padding.left},
+			Filters:         filters,
+			Bias:            slices.Clone(l.bias),
+			Regularizers:    regularizerConfigs,
+		}, nil
+	case *Conv2DTranspose:
+		filters := make([]float64, 0, l.NumChannels()*l.kernelSize.FlatDim())
+		for i := range l.filters {
+			filters = append(filters, functools.FlattenMat(&l.filters[i])...)
+		}
+		return LayerConfig{
+			Type:            "Conv2DTranspose",
+			KernelSize:      [2]int{l.kernelSize.height, l.kernelSize.width},
+			NumberOfFilters: l.numberOfFilters,
+			InputSize:       [2]int{l.inputSize.height, l.inputSize.width},
+			InputChannels:   l.inputChannels,
+			Stride:          [2]int{l.stride.vertical, l.stride.horizontal},
+			Padding:         [4]int{l.padding.up, l.padding.right, l.padding.down, l.padding.left},
+			OutputPadding:   [2]int{l.outputPadding.height, l.outputPadding.width},
+			Filters:         filters,
+			Bias:            slices.Clone(l.bias),
+		}, nil
+	case *MaxPool:
+		return LayerConfig{
+			Type:          "MaxPool",
+			PoolSize:      [2]int{l.poolSize.height, l.poolSize.width},
+			InputSize:     [2]int{l.inputSize.height, l.inputSize.width},
+			InputChannels: l.numChannels,
+			Stride:        [2]int{l.stride.horizontal, l.stride.vertical}, // order used by NewMaxPool
+		}, nil
+	case *AvgPool:
+		return LayerConfig{
+			Type:      "AvgPool",
+			PoolSize:  [2]int{l.poolSize.height, l.poolSize.width},
+			InputSize: [2]int{l.inputSize.height, l.inputSize.width},
+			Stride:    [2]int{l.stride.horizontal, l.stride.vertical}, // order used by NewAvgPool
+		}, nil
+	case *BatchNorm2D:
+		return LayerConfig{
+			Type:          "BatchNorm2D",
+			InputChannels: l.numChannels,
+			Momentum:      l.momentum,
+			Eps:           l.eps,
+			Gamma:         slices.Clone(l.gamma),
+			Beta:          slices.Clone(l.beta),
+			RunningMean:   slices.Clone(l.runningMean),
+			RunningVar:    slices.Clone(l.runningVar),
+		}, nil
+	case *Dropout2D:
+		return LayerConfig{Type: "Dropout2D", Rate: l.rate}, nil
+	case *Flatten:
+		return LayerConfig{Type: "Flatten"}, nil
+	case *Reshape:
+		return LayerConfig{
+			Type:           "Reshape",
+			OutputSize:     [2]int{l.outputSize.height, l.outputSize.width},
+			OutputChannels: l.outputChannels,
+		}, nil
+	case *ReLU:
+		return LayerConfig{Type: "ReLU"}, nil
+	case *LeakyReLU:
+		return LayerConfig{Type: "LeakyReLU", Alpha: l.alpha}, nil
+	case *ELU:
+		return LayerConfig{Type: "ELU", Alpha: l.alpha}, nil
+	case *Sigmoid:
+		return LayerConfig{Type: "Sigmoid"}, nil
+	case *Tanh:
+		return LayerConfig{Type: "Tanh"}, nil
+	}
+	return LayerConfig{}, fmt.Errorf("GetLayerConfig fail: unsupported conv layer type %T", layer)
+}
+
+// rng is used by dropout layers
+func NewLayerFromConfig(config *LayerConfig, rng *rand.Rand) (ConvLayer, error) {
+	switch config.Type {
+	case "Conv2D":
+		weightRegularizers, err := regularizers.NewFromConfigs(config.Regularizers)
+		if err != nil {
+			return nil, err
+		}
+		layer := NewConv2D(
+			config.KernelSize,
+			config.NumberOfFilters,
+			config.InputSize,
+			config.InputChannels,
+			config.Stride,
+			config.Padding,
+			weightRegularizers...,
+		)
+		if len(config.Filters) > 0 {
+			layer.LoadFilter(&config.Filters)
+		}
+		if len(config.Bias) > 0 {
+			layer.LoadBias(&config.Bias)
+		}
+		return &layer, nil
+	case "Conv2DTranspose":
+		layer := NewConv2DTranspose(
+			config.KernelSize,
+			config.NumberOfFilters,
+			config.InputSize,
+			config.InputChannels,
+			config.Stride,
+			config.Padding,
+			config.OutputPadding,
+		)
+		if len(config.Filters) > 0 {
+			layer.LoadFilter(&config.Filters)
+		}
+		if len(config.Bias) > 0 {
+			layer.LoadBias(&config.Bias)
+		}
+		return &layer, nil
+	case "MaxPool":
+		layer := NewMaxPool(config.PoolSize, config.InputSize, config.Stride, config.InputChannels)
+		return &layer, nil
+	case "AvgPool":
+		layer := NewAvgPool(config.PoolSize, config.InputSize, config.Stride)
+		return &layer, nil
+	case "Flatten":
+		layer := NewFlatten()
+		return &layer, nil
+	case "BatchNorm2D":
+		layer := NewBatchNorm2D(config.InputChannels, config.Momentum, config.Eps)
+		if len(config.Gamma) > 0 {
+			layer.LoadGamma(&config.Gamma)
+		}
+		if len(config.Beta) > 0 {
+			layer.LoadBeta(&config.Beta)
+		}
+		if len(config.RunningMean) > 0 && len(config.RunningVar) > 0 {
+			layer.LoadRunningStats(&config.RunningMean, &config.RunningVar)
+		}
+		return &layer, nil
+	case "Dropout2D":
+		layer := NewDropout2D(config.Rate, rng)
+		return &layer, nil
+	case "Reshape":
+		layer := NewReshape(config.OutputSize, config.OutputChannels)
+		return &layer, nil
+	case "ReLU":
+		layer := NewReLU()
+		return &layer, nil
+	case "LeakyReLU":
+		layer := NewLeakyReLU(config.Alpha)
+		return &layer, nil
+	case "ELU":
+		layer := NewELU(config.Alpha)
+		return &layer, nil
+	case "Sigmoid":
+		layer := NewSigmoid()
+		return &layer, nil
+	case "Tanh":
+		layer := NewTanh()
+		return &layer, nil
+	}
+	return nil, fmt.Errorf("NewLayerFromConfig fail: unknown conv layer type %q", config.Type)
+}
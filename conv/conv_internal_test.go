@@ -8,6 +8,68 @@ import (
 	"gonum.org/v1/gonum/mat"
 )
 
+// Dense Toeplitz convolution Conv2D used before im2col,
+// kept as a reference for tests and benchmarks
+
+func getPaddedInputSize(inputSize MatSize, padding Padding) MatSize {
+	return MatSize{
+		inputSize.height + padding.up + padding.down,
+		inputSize.width + padding.left + padding.right,
+	}
+}
+
+func prepareFilterToConv(
+	source *mat.Dense,
+	paddedFlatInputDim int,
+	paddedSize, outputSize, kernelSize MatSize,
+	stride Stride,
+) mat.Dense {
+	convValues := make(
+		[]float64,
+		outputSize.FlatDim()*paddedFlatInputDim,
+	)
+	rowOffset := 0
+	for range outputSize.height {
+		for j := range outputSize.width {
+			for ki := range kernelSize.height {
+				for kj := range kernelSize.width {
+					c := rowOffset + j*stride.horizontal + kj + ki*paddedSize.width
+					convValues[c] = source.At(ki, kj)
+				}
+			}
+			rowOffset += paddedSize.height * paddedSize.width
+		}
+		rowOffset += paddedSize.width * stride.vertical
+	}
+	return *mat.NewDense(outputSize.FlatDim(), paddedFlatInputDim, convValues)
+}
+
+func preparedFlatInput(
+	input *mat.Dense,
+	inputSize MatSize,
+	padding Padding,
+) *mat.VecDense {
+	paddedSize := getPaddedInputSize(inputSize, padding)
+	inputFlatDim := paddedSize.FlatDim()
+	result := make([]float64, inputFlatDim)
+	currentOffset := padding.up * getPaddedInputSize(inputSize, padding).width
+	for i := range inputSize.height {
+		currentOffset += padding.left
+		for j := range inputSize.width {
+			result[currentOffset] = input.At(i, j)
+			currentOffset += 1
+		}
+		currentOffset += padding.right
+	}
+	return mat.NewVecDense(inputFlatDim, result)
+}
+
+func convolve(flatInput *mat.VecDense, kernel *mat.Dense) mat.VecDense {
+	var cm mat.VecDense
+	cm.MulVec(kernel, flatInput)
+	return cm
+}
+
 func TestPreparePaddingInput_1(t *testing.T) {
 	prepared := preparedFlatInput(
 		mat.NewDense(2, 2, []float64{
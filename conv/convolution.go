@@ -8,6 +8,9 @@ import (
 	"gonum.org/v1/gonum/mat"
 
 	"DoodleGan/functools"
+	"DoodleGan/initializers"
+	"DoodleGan/regularizers"
+	"DoodleGan/tensor"
 )
 
 /*
@@ -28,24 +31,18 @@ type Conv2D struct {
 	padding         Padding
 	stride          Stride
 
-	filters []mat.Dense
-	bias    []float64
+	filters    []mat.Dense // views of filterData
+	filterData []float64
+	bias       []float64
 
-	SavedGrads
-	filterGrads []mat.Dense
-	biasGrads   []float64
+	weightRegularizers []regularizers.Regularizer
 
-	convCache
-}
+	SavedGrads
+	filterGrads *tensor.Tensor // numberOfFilters x inputChannels x kernel
+	biasGrads   *tensor.Tensor
 
-type convCache struct {
-	paddedInputSize       MatSize
-	gradSize              MatSize
-	dilatedGradSize       MatSize
-	interweavedGradSize   MatSize
-	paddedDilatedGradSize MatSize
-	skippedRows           int
-	skippedCols           int
+	colShape colShape // input unrolled by im2col
+	workers  int
 }
 
 func NewConv2D(
@@ -55,6 +52,7 @@ func NewConv2D(
 	inputChannels int,
 	stride [2]int,
 	padding [4]int, // N, E, S, W
+	weightRegularizers ...regularizers.Regularizer, // penalize filters only
 ) Conv2D {
 	if kernelSize[0] > inputSize[0]+padding[0]+padding[2] ||
 		kernelSize[1] > inputSize[1]+padding[1]+padding[3] {
@@ -91,28 +89,6 @@ func NewConv2D(
 		horizontal: stride[1],
 	}
 
-	skippedRows_ := skippedRowsColsPad(
-		inputSize[0],
-		kernelSize[0],
-		stride[0],
-		padding[0]+padding[2],
-	)
-	skippedCols_ := skippedRowsColsPad(
-		inputSize[1],
-		kernelSize[1],
-		stride[1],
-		padding[1]+padding[3],
-	)
-	dilatedGradSize_ := *dilatedSize(&outputSize_, &stride_)
-	paddedDilatedGradSize_ := MatSize{
-		height: dilatedGradSize_.height + 2*(kernelSize[0]-1) + skippedRows_,
-		width:  dilatedGradSize_.width + 2*(kernelSize[1]-1) + skippedCols_,
-	}
-
-	interweavedGradSize_ := MatSize{
-		height: dilatedGradSize_.height + skippedRows_,
-		width:  dilatedGradSize_.width + skippedCols_,
-	}
 	return Conv2D{
 		ConvType: ConvType{
 			inputSize:  inputSize_,
@@ -124,14 +100,18 @@ func NewConv2D(
 		padding:         padding_,
 		stride:          stride_,
 		bias:            make([]float64, numberOfFilters),
-		convCache: convCache{
-			paddedInputSize:       getPaddedInputSize(inputSize_, padding_),
-			gradSize:              outputSize_,
-			dilatedGradSize:       dilatedGradSize_,
-			interweavedGradSize:   interweavedGradSize_,
-			paddedDilatedGradSize: paddedDilatedGradSize_,
-			skippedRows:           skippedRows_,
-			skippedCols:           skippedCols_,
+		filterGrads:     tensor.New(numberOfFilters, inputChannels, kernelSize[0], kernelSize[1], nil),
+		biasGrads:       tensor.New(1, 1, 1, numberOfFilters, nil),
+		workers:         1,
+
+		weightRegularizers: weightRegularizers,
+		colShape: colShape{
+			channels:   inputChannels,
+			inputSize:  inputSize_,
+			kernelSize: MatSize{kernelSize[0], kernelSize[1]},
+			outputSize: outputSize_,
+			padding:    padding_,
+			stride:     stride_,
 		},
 	}
 }
@@ -157,30 +137,29 @@ func (layer *Conv2D) LoadBias(biases *[]float64) {
 		)
 		panic(mess)
 	}
-	layer.bias = *biases
+	layer.bias = slices.Clone(*biases)
 }
 
 func (layer *Conv2D) InitFilterRandom(minRange, maxRange float64) {
 	if maxRange < minRange {
 		panic("Filter random initialization fail:\n\tminRange can't be greater than maxRange")
 	}
-	numChannels := layer.NumChannels()
-	numPixelsKernel := layer.kernelSize.FlatDim()
-	newFilter := make([]mat.Dense, numChannels)
-	matValues := make([]float64, numPixelsKernel)
-
-	for i := range numChannels {
-		for j := range numPixelsKernel {
-			ranVal := rand.Float64()*(maxRange-minRange) + minRange
-			matValues[j] = ranVal
-		}
-		layer.filters[i] = *mat.NewDense(
-			layer.kernelSize.height,
-			layer.kernelSize.width,
-			slices.Clone(matValues),
-		)
+	filterData := make([]float64, layer.NumChannels()*layer.kernelSize.FlatDim())
+	for i := range filterData {
+		filterData[i] = rand.Float64()*(maxRange-minRange) + minRange
 	}
-	layer.filters = newFilter
+	layer.filterData = filterData
+	layer.filters = filterViews(filterData, layer.kernelSize)
+}
+
+func (layer *Conv2D) InitWeights(initializer initializers.Initializer) {
+	filter := initializer.Initialize(initializers.ConvShape(
+		layer.numberOfFilters,
+		layer.inputChannels,
+		layer.kernelSize.height,
+		layer.kernelSize.width,
+	))
+	layer.LoadFilter(&filter)
 }
 
 func (layer *Conv2D) LoadFilter(source *[]float64) {
@@ -197,115 +176,158 @@ func (layer *Conv2D) LoadFilter(source *[]float64) {
 		)
 		panic(mess)
 	}
-	layer.filters = make([]mat.Dense, numChannels)
-	for i := range numChannels {
-		matValues := (*source)[i*numPixelsKernel : i*numPixelsKernel+numPixelsKernel]
-		layer.filters[i] = *mat.NewDense(
-			layer.kernelSize.height,
-			layer.kernelSize.width,
-			matValues,
-		)
-	}
-}
-
-func (layer *Conv2D) ArrayToConv2DInput(source []float64) []mat.Dense {
-	result := make([]mat.Dense, layer.inputChannels)
-	numPixelsInput := layer.inputSize.FlatDim()
-	s := 0
-	for i := range layer.inputChannels {
-		result[i] = *mat.NewDense(
-			layer.inputSize.height,
-			layer.inputSize.width,
-			source[s:s+numPixelsInput],
-		)
-		s += numPixelsInput
-	}
-	return result
+	layer.filterData = slices.Clone(*source)
+	layer.filters = filterViews(layer.filterData, layer.kernelSize)
+}
+
+// Single sample tensor sharing data with source
+func (layer *Conv2D) ArrayToConv2DInput(source []float64) *tensor.Tensor {
+	return tensor.New(
+		1,
+		layer.inputChannels,
+		layer.inputSize.height,
+		layer.inputSize.width,
+		source,
+	)
 }
 
-func (layer *Conv2D) Forward(input *[]mat.Dense) *[]mat.Dense {
-	layer.lastInput = *input
-	layer.lastOutput = make([]mat.Dense, layer.numberOfFilters)
-	for f := range layer.numberOfFilters {
-		currentConvolved := *mat.NewDense(layer.outputSize.FlatDim(), 1, nil)
-		for i := range layer.inputChannels {
-			kernelMat := prepareFilterToConv(
-				&layer.filters[f*layer.inputChannels+i],
-				layer.paddedInputSize.FlatDim(),
-				layer.paddedInputSize,
-				layer.outputSize,
-				layer.kernelSize,
-				layer.stride,
-			)
-			cm := convolve(
-				preparedFlatInput(&(*input)[i], layer.inputSize, layer.padding),
-				&kernelMat,
-			)
-			currentConvolved.Add(&currentConvolved, &cm)
+// Number of goroutines sharing forward and backward work, values below 1 use GOMAXPROCS.
+// Batch samples are spread first, filters when the batch is smaller than workers.
+func (layer *Conv2D) SetWorkers(workers int) {
+	layer.workers = workersOrDefault(workers)
+}
+
+// Saved data and grads are set before workers start, workers write only disjoint samples
+// or filter rows, and batch sums are reduced in sample order afterwards
+func (layer *Conv2D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkInputDims("Conv2D forward", input, layer.inputChannels, layer.inputSize)
+	checkFiltersSet("Conv2D forward", layer.filterData)
+	layer.lastInput = input
+	layer.lastOutput = tensor.New(
+		input.Len(),
+		layer.numberOfFilters,
+		layer.outputSize.height,
+		layer.outputSize.width,
+		nil,
+	)
+	weights := layer.filterMat()
+	sampleWorkers, filterWorkers := layer.splitWorkers(input.Len())
+	parallelChunks(sampleWorkers, input.Len(), func(start, end int) {
+		cols := mat.NewDense(layer.colShape.rows(), layer.colShape.cols(), nil)
+		for n := start; n < end; n++ {
+			im2col(input.Sample(n), &layer.colShape, cols.RawMatrix().Data)
+			output := mat.NewDense(layer.numberOfFilters, layer.colShape.cols(), layer.lastOutput.Sample(n))
+			parallelChunks(filterWorkers, layer.numberOfFilters, func(fStart, fEnd int) {
+				convolved := output.Slice(fStart, fEnd, 0, layer.colShape.cols()).(*mat.Dense)
+				convolved.Mul(weights.Slice(fStart, fEnd, 0, layer.colShape.rows()), cols)
+				for f := fStart; f < fEnd; f++ {
+					channel := layer.lastOutput.Channel(n, f)
+					for k := range channel {
+						channel[k] += layer.bias[f]
+					}
+				}
+			})
 		}
-		addBias(&currentConvolved, layer.bias[f])
-		layer.lastOutput[f] = currentConvolved
+	})
+	return layer.lastOutput
+}
+
+// Filter and bias gradients are summed over the batch
+func (layer *Conv2D) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	checkInputDims("Conv2D backward", inGrads, layer.numberOfFilters, layer.outputSize)
+	layer.lastInGrads = inGrads
+	n, c, h, w := layer.lastInput.Dims()
+	layer.lastOutGrads = tensor.New(n, c, h, w, nil)
+
+	weights := layer.filterMat()
+	kernelGrads := make([]mat.Dense, n)
+	sampleWorkers, filterWorkers := layer.splitWorkers(n)
+	parallelChunks(sampleWorkers, n, func(start, end int) {
+		cols := mat.NewDense(layer.colShape.rows(), layer.colShape.cols(), nil)
+		for sample := start; sample < end; sample++ {
+			grads := layer.sampleGrads(inGrads, sample)
+			kernelGrads[sample] = layer.calcKernelGrads(sample, grads, cols, filterWorkers)
+			layer.calcOutGrads(sample, grads, weights, filterWorkers)
+		}
+	})
+	layer.sumBatchGrads(inGrads, kernelGrads)
+	for _, regularizer := range layer.weightRegularizers {
+		regularizer.AddGrads(layer.filterData, layer.filterGrads.RawData())
 	}
-	return &layer.lastOutput
+	return layer.lastOutGrads
 }
 
-func (layer *Conv2D) DeflatOutput() *[]mat.Dense {
-	return Deflat(layer.lastOutput, layer.outputSize, layer.numberOfFilters)
+func (layer *Conv2D) Penalty() float64 {
+	penalty := 0.0
+	for _, regularizer := range layer.weightRegularizers {
+		penalty += regularizer.Penalty(layer.filterData)
+	}
+	return penalty
 }
 
-func (layer *Conv2D) FlatOutput() *[]float64 {
-	return GetFlatOutput(&layer.ConvType)
+func (layer *Conv2D) splitWorkers(batchSize int) (int, int) {
+	if batchSize >= layer.workers {
+		return layer.workers, 1
+	}
+	return batchSize, layer.workers / batchSize
 }
 
-func (layer *Conv2D) Backward(inGrads *[]mat.Dense) *[]mat.Dense {
-	layer.lastInGrads = *inGrads
-	layer.calcKernelBiasGrads(inGrads)
-	layer.calcOutGrads(inGrads)
-	return &layer.lastOutGrads
+// Grads of one sample as numberOfFilters x output matrix
+func (layer *Conv2D) sampleGrads(inGrads *tensor.Tensor, sample int) *mat.Dense {
+	return mat.NewDense(layer.numberOfFilters, layer.colShape.cols(), inGrads.Sample(sample))
 }
 
-func (layer *Conv2D) BackwardFromFlat(inGrads *[]float64) *[]mat.Dense {
-	inGradsMat := make([]mat.Dense, layer.numberOfFilters)
-	pixelsInKernel := layer.kernelSize.FlatDim()
-	for i := range layer.numberOfFilters {
-		inGradsMat[i] = *mat.NewDense(
-			layer.gradSize.height,
-			layer.gradSize.width,
-			(*inGrads)[i*pixelsInKernel:(i+1)*pixelsInKernel],
+// Filters as numberOfFilters x (inputChannels * kernel) matrix, rows match im2col columns
+func (layer *Conv2D) filterMat() *mat.Dense {
+	kernelLen := layer.kernelSize.FlatDim()
+	data := make([]float64, layer.NumChannels()*kernelLen)
+	for k := range layer.filters {
+		kernel := mat.NewDense(
+			layer.kernelSize.height,
+			layer.kernelSize.width,
+			data[k*kernelLen:(k+1)*kernelLen],
 		)
+		kernel.Copy(&layer.filters[k])
 	}
-	return layer.Backward(&inGradsMat)
+	return mat.NewDense(layer.numberOfFilters, layer.inputChannels*kernelLen, data)
 }
 
-func (layer *Conv2D) BackwardFromVec(inGrads *mat.VecDense) *[]mat.Dense {
-	inGradsData := inGrads.RawVector().Data
-	return layer.BackwardFromFlat(&inGradsData)
-}
-
-func (layer *Conv2D) DeflatOutGrads() *[]mat.Dense {
-	return Deflat(layer.lastOutGrads, layer.inputSize, layer.inputChannels)
+// Views of filter gradients ordered the same as filters
+func (layer *Conv2D) GetFilterGrads() *[]mat.Dense {
+	retVal := make([]mat.Dense, 0, layer.NumChannels())
+	for f := range layer.numberOfFilters {
+		retVal = append(retVal, layer.filterGrads.Mats(f)...)
+	}
+	return &retVal
 }
 
-func (layer *Conv2D) GetFilterGrads() *[]mat.Dense {
-	return &layer.filterGrads
+func (layer *Conv2D) GetWeightsGrads() *tensor.Tensor {
+	return layer.filterGrads
 }
 
-func (layer *Conv2D) GetBiasGrads() *[]float64 {
-	return &layer.biasGrads
+func (layer *Conv2D) GetBiasGrads() *tensor.Tensor {
+	return layer.biasGrads
 }
 
-func (layer *Conv2D) ApplyGrads(
-	learningRate *float64,
-	dWeightsGrads *[]mat.Dense,
-	dBiasGrad *[]float64,
-) {
-	for b := range layer.numberOfFilters {
-		layer.bias[b] -= *learningRate * (*dBiasGrad)[b]
-	}
-	for f := range layer.NumChannels() {
-		var scaledGrads mat.Dense
-		scaledGrads.Scale(*learningRate, &(*dWeightsGrads)[f])
-		layer.filters[f].Sub(&layer.filters[f], &scaledGrads)
+func (layer *Conv2D) Params() []tensor.Param {
+	checkFiltersSet("Conv2D params", layer.filterData)
+	return []tensor.Param{
+		{
+			Name: "filters",
+			Value: tensor.New(
+				layer.numberOfFilters,
+				layer.inputChannels,
+				layer.kernelSize.height,
+				layer.kernelSize.width,
+				layer.filterData,
+			),
+			Grad: layer.filterGrads,
+		},
+		{
+			Name:  "bias",
+			Value: tensor.New(1, 1, 1, layer.numberOfFilters, layer.bias),
+			Grad:  layer.biasGrads,
+		},
 	}
 }
 
@@ -325,232 +347,40 @@ func (layer *Conv2D) NumFilters() int {
 	return layer.numberOfFilters
 }
 
-func getPaddedInputSize(inputSize MatSize, padding Padding) MatSize {
-	return MatSize{
-		inputSize.height + padding.up + padding.down,
-		inputSize.width + padding.left + padding.right,
-	}
-}
-
-func prepareFilterToConv(
-	source *mat.Dense,
-	paddedFlatInputDim int,
-	paddedSize, outputSize, kernelSize MatSize,
-	stride Stride,
-) mat.Dense {
-	convValues := make(
-		[]float64,
-		outputSize.FlatDim()*paddedFlatInputDim,
+func (layer *Conv2D) calcKernelGrads(sample int, grads, cols *mat.Dense, workers int) mat.Dense {
+	im2col(layer.lastInput.Sample(sample), &layer.colShape, cols.RawMatrix().Data)
+	kernelGrads := mat.NewDense(layer.numberOfFilters, layer.colShape.rows(), nil)
+	parallelChunks(workers, layer.numberOfFilters, func(fStart, fEnd int) {
+		filterRows := kernelGrads.Slice(fStart, fEnd, 0, layer.colShape.rows()).(*mat.Dense)
+		filterRows.Mul(grads.Slice(fStart, fEnd, 0, layer.colShape.cols()), cols.T())
+	})
+	return *kernelGrads
+}
+
+// Sums per sample grads in sample order, so results don't depend on number of workers
+func (layer *Conv2D) sumBatchGrads(inGrads *tensor.Tensor, kernelGrads []mat.Dense) {
+	layer.filterGrads.Zero()
+	layer.biasGrads.Zero()
+	filterGrads := mat.NewDense(
+		layer.numberOfFilters,
+		layer.colShape.rows(),
+		layer.filterGrads.RawData(),
 	)
-	rowOffset := 0
-	for range outputSize.height {
-		for j := range outputSize.width {
-			for ki := range kernelSize.height {
-				for kj := range kernelSize.width {
-					c := rowOffset + j*stride.horizontal + kj + ki*paddedSize.width
-					convValues[c] = source.At(ki, kj)
-				}
-			}
-			rowOffset += paddedSize.height * paddedSize.width
-		}
-		rowOffset += paddedSize.width * stride.vertical
-	}
-	return *mat.NewDense(outputSize.FlatDim(), paddedFlatInputDim, convValues)
-}
-
-func preparedFlatInput(
-	input *mat.Dense,
-	inputSize MatSize,
-	padding Padding,
-) *mat.VecDense {
-	paddedSize := getPaddedInputSize(inputSize, padding)
-	inputFlatDim := paddedSize.FlatDim()
-	result := make([]float64, inputFlatDim)
-	currentOffset := padding.up * getPaddedInputSize(inputSize, padding).width
-	for i := range inputSize.height {
-		currentOffset += padding.left
-		for j := range inputSize.width {
-			result[currentOffset] = input.At(i, j)
-			currentOffset += 1
-		}
-		currentOffset += padding.right
-	}
-	return mat.NewVecDense(inputFlatDim, result)
-}
-
-func convolve(flatInput *mat.VecDense, kernel *mat.Dense) mat.VecDense {
-	var cm mat.VecDense
-	cm.MulVec(kernel, flatInput)
-	return cm
-}
-
-func addBias(dest *mat.Dense, b float64) {
-	dest.Apply(
-		func(i, j int, v float64) float64 {
-			return v + b
-		},
-		dest,
-	)
-}
-
-func rotateMatHalfPi(m mat.Dense) mat.Dense {
-	rows, cols := m.Dims()
-	rotatedRows := mat.NewDense(rows, cols, nil)
-	rotatedRowsCols := mat.NewDense(rows, cols, nil)
-	for i := range rows {
-		rotatedRows.SetRow(rows-i-1, m.RawRowView(i))
-	}
-	for j := range cols {
-		rawCol := mat.NewVecDense(rows, nil)
-		rawCol.CopyVec(rotatedRows.ColView(j))
-		rotatedRowsCols.SetCol(cols-j-1, rawCol.RawVector().Data)
-	}
-	return *rotatedRowsCols
-}
-
-func addPadding(source *mat.Dense, padding Padding) *mat.Dense {
-	n, m := source.Dims()
-	paddedSize := getPaddedInputSize(MatSize{height: n, width: m}, padding)
-	retData := make([]float64, paddedSize.FlatDim())
-	offset := paddedSize.width * padding.up
-	for i := range n {
-		offset += padding.left
-		for j := range m {
-			retData[offset] = source.At(i, j)
-			offset++
-		}
-		offset += padding.right
-	}
-	return mat.NewDense(paddedSize.height, paddedSize.width, retData)
-}
-
-func (layer *Conv2D) calcKernelBiasGrads(inGrads *[]mat.Dense) {
-	layer.filterGrads = make([]mat.Dense, layer.NumChannels())
-	layer.biasGrads = make([]float64, layer.numberOfFilters)
-
-	for f := range layer.numberOfFilters {
-		dilatedGrad := dilate(&(*inGrads)[f], &layer.dilatedGradSize, &layer.stride)
-		paddedDilatedGrad := addPadding(dilatedGrad, Padding{
-			up:    0,
-			right: layer.skippedCols,
-			down:  layer.skippedRows,
-			left:  0,
-		})
-		kernelGrad := prepareFilterToConv(
-			paddedDilatedGrad,
-			layer.paddedInputSize.FlatDim(),
-			layer.paddedInputSize,
-			layer.kernelSize,
-			layer.interweavedGradSize,
-			Stride{
-				vertical:   1,
-				horizontal: 1,
-			},
-		)
-		for i := range layer.inputChannels {
-			flatInput := preparedFlatInput(
-				&layer.lastInput[i],
-				layer.inputSize,
-				layer.padding,
-			)
-			convolvedKernelGrad := convolve(flatInput, &kernelGrad)
-			layer.filterGrads[f*layer.inputChannels+i] = *mat.NewDense(
-				layer.kernelSize.height,
-				layer.kernelSize.width,
-				convolvedKernelGrad.RawVector().Data,
-			)
+	biasGrads := layer.biasGrads.RawData()
+	for sample := range kernelGrads {
+		filterGrads.Add(filterGrads, &kernelGrads[sample])
+		grads := layer.sampleGrads(inGrads, sample)
+		for f := range layer.numberOfFilters {
+			biasGrads[f] += mat.Sum(grads.RowView(f))
 		}
-		layer.biasGrads[f] = mat.Sum(&(*inGrads)[f])
-	}
-}
-
-func dilatedSize(sourceSize *MatSize, stride *Stride) *MatSize {
-	return &MatSize{
-		height: stride.vertical*(sourceSize.height-1) + 1,
-		width:  stride.horizontal*(sourceSize.width-1) + 1,
 	}
 }
 
-func dilate(source *mat.Dense, dilatedSize *MatSize, stride *Stride) *mat.Dense {
-	n, m := source.Dims()
-	result := make([]float64, dilatedSize.FlatDim())
-	pos := 0
-	for i := range n {
-		for j := range m {
-			result[pos] = source.At(i, j)
-			pos += stride.horizontal
-		}
-		if stride.vertical > 1 {
-			pos -= 1
-		}
-		pos += dilatedSize.width * (stride.vertical - 1)
-	}
-	return mat.NewDense(dilatedSize.height, dilatedSize.width, result)
-}
-
-func cropMat(source mat.Dense, cropPadding Padding) mat.Dense {
-	n, m := source.Dims()
-	retHeight := n - cropPadding.up - cropPadding.down
-	retWidth := m - cropPadding.left - cropPadding.right
-	retVal := mat.NewDense(retHeight, retWidth, nil)
-	retVal.Add(retVal, source.Slice(
-		cropPadding.up,
-		cropPadding.up+retHeight,
-		cropPadding.left,
-		cropPadding.left+retWidth,
-	))
-	return *retVal
-}
-
-func skippedRowsColsPad(inputSize, kernelSize, stride, sumPad int) int {
-	retVal := inputSize + sumPad
-	for retVal > kernelSize {
-		retVal -= stride
-	}
-	if retVal == kernelSize {
-		return 0
-	}
-	return retVal
-}
-
-func (layer *Conv2D) calcOutGrads(inGrads *[]mat.Dense) {
-	layer.lastOutGrads = make([]mat.Dense, layer.inputChannels)
-	for i := range layer.inputChannels {
-		layer.lastOutGrads[i] = *mat.NewDense(layer.inputSize.height, layer.inputSize.width, nil)
-	}
-
-	for f := range layer.numberOfFilters {
-		dilated := dilate(&(*inGrads)[f], &layer.dilatedGradSize, &layer.stride)
-		paddedGradsFlat := preparedFlatInput(
-			dilated,
-			layer.dilatedGradSize,
-			Padding{
-				up:    layer.kernelSize.height - 1,
-				right: layer.kernelSize.width - 1 + layer.skippedCols,
-				down:  layer.kernelSize.height - 1 + layer.skippedRows,
-				left:  layer.kernelSize.width - 1,
-			},
-		)
-		for i := range layer.inputChannels {
-			rotatedKernel := rotateMatHalfPi(layer.filters[f*layer.inputChannels+i])
-			rotatedKernelPrep := prepareFilterToConv(
-				&rotatedKernel,
-				paddedGradsFlat.Len(),
-				layer.paddedDilatedGradSize,
-				layer.paddedInputSize,
-				layer.kernelSize,
-				Stride{horizontal: 1, vertical: 1},
-			)
-			outGradsConvolved := convolve(paddedGradsFlat, &rotatedKernelPrep)
-			cropped := cropMat(
-				*mat.NewDense(
-					layer.paddedInputSize.height,
-					layer.paddedInputSize.width,
-					outGradsConvolved.RawVector().Data,
-				),
-				layer.padding,
-			)
-			layer.lastOutGrads[i].Add(&layer.lastOutGrads[i], &cropped)
-		}
-	}
+func (layer *Conv2D) calcOutGrads(sample int, grads, weights *mat.Dense, workers int) {
+	colGrads := mat.NewDense(layer.colShape.rows(), layer.colShape.cols(), nil)
+	parallelChunks(workers, layer.colShape.rows(), func(rStart, rEnd int) {
+		colRows := colGrads.Slice(rStart, rEnd, 0, layer.colShape.cols()).(*mat.Dense)
+		colRows.Mul(weights.Slice(0, layer.numberOfFilters, rStart, rEnd).T(), grads)
+	})
+	col2im(colGrads.RawMatrix().Data, &layer.colShape, layer.lastOutGrads.Sample(sample))
 }
@@ -9,6 +9,8 @@ import (
 
 	"DoodleGan/conv"
 	"DoodleGan/functools"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
 )
 
 func TestConv2D_Backward_1(t *testing.T) {
@@ -22,16 +24,15 @@ func TestConv2D_Backward_1(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{1, 2, 1, 0, 3, -2, 3, 4, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{3, 2, 1, -2.5}),
 	}
 
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{3, 8, 4, -5, -3, -4, -2, 5.5, -1.25}),
@@ -47,13 +48,13 @@ func TestConv2D_Backward_1(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
 		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
 		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
@@ -74,15 +75,14 @@ func TestConv2D_Backward_2(t *testing.T) {
 		*mat.NewDense(3, 3, []float64{1, 2, 1, 0, 3, -2, 3, 4, 1}),
 		*mat.NewDense(3, 3, []float64{2, 3, 2, 1, -2, 1, 2, 4, -1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{3, 2, 1, -2.5}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{3, 8, 4, -5, -3, -4, -2, 5.5, -1.25}),
@@ -100,13 +100,13 @@ func TestConv2D_Backward_2(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
 		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
@@ -127,16 +127,15 @@ func TestConv2D_Backward_3(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{1, 2, 1, 0, 3, -2, 3, 4, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{3, 2, 1, -2.5}),
 		*mat.NewDense(2, 2, []float64{2, 5, -1, 2}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{9, 25, 9, -4, 6, -17, -4, 12.5, -7.25}),
@@ -153,13 +152,13 @@ func TestConv2D_Backward_3(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
 		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
 		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
@@ -186,17 +185,16 @@ func TestConv2D_Backward_4(t *testing.T) {
 		*mat.NewDense(3, 3, []float64{1, 2, 1, 0, 3, -2, 3, 4, 1}),
 		*mat.NewDense(3, 3, []float64{3, 2, -2, 1, -1, 2, 5, -2, 0}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{3, 2, 1, -2.5}),
 		*mat.NewDense(2, 2, []float64{0.5, 1, 2, 0.5}),
 		*mat.NewDense(2, 2, []float64{2, 5, -1, 2}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()    // 2 Channels 3x3
-	filterGrads := layer.GetFilterGrads() // 6 Channels 2x2
-	biasGrads := layer.GetBiasGrads()     // 3 Channels 1x1 Flat
+	filterGrads := layer.GetFilterGrads()       // 6 Channels 2x2
+	biasGrads := layer.GetBiasGrads().RawData() // 3 Channels 1x1 Flat
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{3, 10, 12, 3.5, -14.5, -9, 5, 0, -4.75}),
@@ -218,15 +216,15 @@ func TestConv2D_Backward_4(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -241,15 +239,14 @@ func TestConv2D_Backward_5(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{1, 2, 1, 0, 3, -2, 3, 4, 1, 4, -1, 2, 5, 2, -2, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(1, 1, []float64{0.1}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	var targetOutGradsTemp mat.Dense
 	targetOutGradsTemp.Scale(
@@ -268,15 +265,15 @@ func TestConv2D_Backward_5(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -289,15 +286,14 @@ func TestConv2D_Backward_6(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{3, -1, 2, 1, -3, 1, 2, 2, 0.5, -0.5, 1, 2, 4, 2, 1, -4}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{0.5, 0, 1, -2}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{1, -0.5, 0, 0, 1, 1, 0, 0, 2, -1, -4, 2, 2, 2, -4, -4}),
@@ -313,15 +309,15 @@ func TestConv2D_Backward_6(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -336,15 +332,14 @@ func TestConv2D_Backward_7(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{3, -1, 8, -1, -2, 4, 5, -2, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(1, 1, []float64{0.5}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{1, 0.5, 0, 0.5, 0, 0, 0, 0, 0}),
@@ -360,15 +355,15 @@ func TestConv2D_Backward_7(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -383,15 +378,14 @@ func TestConv2D_Backward_8(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{-1, 1, 0.5, 2}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2, 0.5, -1, -0.5}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{6, -4, -2, 1}),
@@ -407,15 +401,15 @@ func TestConv2D_Backward_8(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -430,15 +424,14 @@ func TestConv2D_Backward_9(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{2, 1, -1, 3, 2, 4, -2, 1, 3}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2, -1, 1, 0.5}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{4, -2, -2, 2, -1, 1, 2, -1, 1}),
@@ -454,15 +447,15 @@ func TestConv2D_Backward_9(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -477,15 +470,14 @@ func TestConv2D_Backward_10(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(4, 3, []float64{3, -1, 3, -1, 2, 2, -0.5, 4, -1, 2, -3, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{-1, 0, -2, 2, 2, 1, -3, 2, 0.5}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(4, 3, []float64{0, 0, -2, 6, 8, 3, 2, 4, 1, 6, 8, 1.5}),
@@ -501,15 +493,15 @@ func TestConv2D_Backward_10(t *testing.T) {
 		fmt.Println(targetFilterGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		fmt.Println(*outGrads)
+		fmt.Println(outGrads)
 		fmt.Println(targetOutGrads)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
@@ -530,17 +522,16 @@ func TestConv2D_Backward_11(t *testing.T) {
 		*mat.NewDense(3, 3, []float64{1, -1, 2, 2, -2, 0, -1, 3, -1}),
 		*mat.NewDense(3, 3, []float64{2, 3, 0, 1, 1, 2, -3, -1, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{0.5, 0.75, 1, 0}),
 		*mat.NewDense(2, 2, []float64{2, 1, 0, 2}),
 		*mat.NewDense(2, 2, []float64{-3, 2, 1, 1}),
 	}
-	layer.Backward(&inGrads)
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 
-	outGrads := layer.DeflatOutGrads()
 	filterGrads := layer.GetFilterGrads()
-	biasGrads := layer.GetBiasGrads()
+	biasGrads := layer.GetBiasGrads().RawData()
 
 	targetOutGrads := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{-5.5, -7.5, 0.25, 1, 0, -2, 0, 3, -1}),
@@ -562,21 +553,21 @@ func TestConv2D_Backward_11(t *testing.T) {
 		functools.PrintMatSlice(&targetFilterGrads, 2)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*outGrads, targetOutGrads) {
+	if !reflect.DeepEqual(outGrads, targetOutGrads) {
 		fmt.Println("== OUT GRADS ==")
-		functools.PrintMatSlice(outGrads, 2)
+		functools.PrintMatSlice(&outGrads, 2)
 		functools.PrintMatSlice(&targetOutGrads, 2)
 		t.Fail()
 	}
-	if !reflect.DeepEqual(*biasGrads, targetBiasGrads) {
+	if !reflect.DeepEqual(biasGrads, targetBiasGrads) {
 		fmt.Println("== BIAS GRADS ==")
-		fmt.Println(*biasGrads)
+		fmt.Println(biasGrads)
 		fmt.Println(targetBiasGrads)
 		t.Fail()
 	}
 }
 
-func TestConv2D_ApplyGrads_1(t *testing.T) {
+func TestConv2D_Params_Step_1(t *testing.T) {
 	layer := conv.NewConv2D([2]int{2, 2}, 3, [2]int{3, 3}, 2, [2]int{2, 2}, [4]int{1, 1, 0, 0})
 	filter := []float64{
 		1, -1, -1, 1,
@@ -591,16 +582,16 @@ func TestConv2D_ApplyGrads_1(t *testing.T) {
 		*mat.NewDense(3, 3, []float64{1, -1, 2, 2, -2, 0, -1, 3, -1}),
 		*mat.NewDense(3, 3, []float64{2, 3, 0, 1, 1, 2, -3, -1, 1}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{0.5, 0.75, 1, 0}),
 		*mat.NewDense(2, 2, []float64{2, 1, 0, 2}),
 		*mat.NewDense(2, 2, []float64{-3, 2, 1, 1}),
 	}
-	layer.Backward(&inGrads)
+	layer.Backward(tensor.FromMats(inGrads))
 
-	learningRate := 0.1
-	layer.ApplyGrads(&learningRate, layer.GetFilterGrads(), layer.GetBiasGrads())
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	optimizer.Step(layer.Params())
 	layerFilter := layer.GetFilter()
 	layerBias := layer.GetBias()
 	targetLayerFilter := []mat.Dense{
@@ -0,0 +1,139 @@
+package conv
+
+import (
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
+)
+
+// QuickDraw bitmaps are 28 x 28 grayscale
+func newQuickDrawConv2D(numberOfFilters int) Conv2D {
+	layer := NewConv2D([2]int{3, 3}, numberOfFilters, [2]int{28, 28}, 1, [2]int{1, 1}, [4]int{1, 1, 1, 1})
+	filter := make([]float64, layer.NumChannels()*9)
+	for i := range filter {
+		filter[i] = rand.Float64()*2.0 - 1.0
+	}
+	layer.LoadFilter(&filter)
+	return layer
+}
+
+func randomTensor(n, c, h, w int) *tensor.Tensor {
+	retVal := tensor.New(n, c, h, w, nil)
+	for i := range retVal.RawData() {
+		retVal.RawData()[i] = rand.Float64()*2.0 - 1.0
+	}
+	return retVal
+}
+
+// Forward pass with one dense Toeplitz matrix per kernel, as Conv2D used to do it
+func toeplitzForward(layer *Conv2D, input *tensor.Tensor) *tensor.Tensor {
+	paddedInputSize := getPaddedInputSize(layer.inputSize, layer.padding)
+	output := tensor.New(
+		input.Len(),
+		layer.numberOfFilters,
+		layer.outputSize.height,
+		layer.outputSize.width,
+		nil,
+	)
+	kernelMats := make([]mat.Dense, layer.NumChannels())
+	for k := range kernelMats {
+		kernelMats[k] = prepareFilterToConv(
+			&layer.filters[k],
+			paddedInputSize.FlatDim(),
+			paddedInputSize,
+			layer.outputSize,
+			layer.kernelSize,
+			layer.stride,
+		)
+	}
+	for n := range input.Len() {
+		for i := range layer.inputChannels {
+			flatInput := preparedFlatInput(input.Mat(n, i), layer.inputSize, layer.padding)
+			for f := range layer.numberOfFilters {
+				cm := convolve(flatInput, &kernelMats[f*layer.inputChannels+i])
+				convolved := output.Channel(n, f)
+				for k, v := range cm.RawVector().Data {
+					convolved[k] += v
+				}
+			}
+		}
+		for f := range layer.numberOfFilters {
+			convolved := output.Channel(n, f)
+			for k := range convolved {
+				convolved[k] += layer.bias[f]
+			}
+		}
+	}
+	return output
+}
+
+func TestConv2D_Im2col_Matches_Toeplitz(t *testing.T) {
+	layer := NewConv2D([2]int{3, 2}, 3, [2]int{7, 6}, 2, [2]int{2, 1}, [4]int{1, 0, 2, 1})
+	filter := make([]float64, layer.NumChannels()*6)
+	for i := range filter {
+		filter[i] = rand.Float64()*2.0 - 1.0
+	}
+	layer.LoadFilter(&filter)
+	bias := []float64{0.5, -1, 2}
+	layer.LoadBias(&bias)
+	input := randomTensor(2, 2, 7, 6)
+
+	target := toeplitzForward(&layer, input).RawData()
+	result := layer.Forward(input).RawData()
+	if !functools.IsEqual(&target, &result, 1e-9) {
+		t.Fail()
+	}
+}
+
+func BenchmarkConv2D_Forward_Im2col(b *testing.B) {
+	layer := newQuickDrawConv2D(8)
+	input := randomTensor(1, 1, 28, 28)
+	b.ResetTimer()
+	for range b.N {
+		layer.Forward(input)
+	}
+}
+
+func BenchmarkConv2D_Forward_Toeplitz(b *testing.B) {
+	layer := newQuickDrawConv2D(8)
+	input := randomTensor(1, 1, 28, 28)
+	b.ResetTimer()
+	for range b.N {
+		toeplitzForward(&layer, input)
+	}
+}
+
+func BenchmarkConv2D_Backward_Im2col(b *testing.B) {
+	layer := newQuickDrawConv2D(8)
+	input := randomTensor(1, 1, 28, 28)
+	grads := randomTensor(1, 8, 28, 28)
+	layer.Forward(input)
+	b.ResetTimer()
+	for range b.N {
+		layer.Backward(grads)
+	}
+}
+
+func benchmarkConv2DBatch(b *testing.B, workers int) {
+	layer := newQuickDrawConv2D(8)
+	layer.SetWorkers(workers)
+	input := randomTensor(32, 1, 28, 28)
+	grads := randomTensor(32, 8, 28, 28)
+	b.ResetTimer()
+	for range b.N {
+		layer.Forward(input)
+		layer.Backward(grads)
+	}
+}
+
+func BenchmarkConv2D_Batch_Sequential(b *testing.B) {
+	benchmarkConv2DBatch(b, 1)
+}
+
+func BenchmarkConv2D_Batch_Parallel(b *testing.B) {
+	benchmarkConv2DBatch(b, 0)
+}
@@ -2,6 +2,7 @@ package conv_test
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
 	"testing"
 
@@ -9,6 +10,9 @@ import (
 
 	"DoodleGan/conv"
 	"DoodleGan/functools"
+	"DoodleGan/initializers"
+	"DoodleGan/regularizers"
+	"DoodleGan/tensor"
 )
 
 // Single input
@@ -18,8 +22,8 @@ func TestConv2D_1(t *testing.T) {
 	filter := []float64{0, -1, 0, -1, 5, -1, 0, -1, 0}
 	layer.LoadFilter(&filter)
 	input := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
-	layer.Forward(&[]mat.Dense{*mat.NewDense(4, 4, input)})
-	if !reflect.DeepEqual(*layer.FlatOutput(), []float64{6, 7, 10, 11}) {
+	output := layer.Forward(tensor.FromMats([]mat.Dense{*mat.NewDense(4, 4, input)}))
+	if !reflect.DeepEqual(output.RawData(), []float64{6, 7, 10, 11}) {
 		t.Fatal()
 	}
 }
@@ -33,12 +37,12 @@ func TestConv2D_2(t *testing.T) {
 		1, 2, 3, 4, 5, 6,
 	}
 	layer.LoadFilter(&filter)
-	layer.Forward(&[]mat.Dense{
+	output := layer.Forward(tensor.FromMats([]mat.Dense{
 		*mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}),
 		*mat.NewDense(2, 3, []float64{3, 3, 3, 3, 3, 3}),
-	})
+	}))
 
-	if !reflect.DeepEqual(*layer.FlatOutput(), []float64{105}) {
+	if !reflect.DeepEqual(output.RawData(), []float64{105}) {
 		t.Fatal()
 	}
 }
@@ -56,10 +60,10 @@ func TestConv2D_3(t *testing.T) {
 		2, 2,
 	}
 	layer.LoadFilter(&filter)
-	layer.Forward(&[]mat.Dense{*mat.NewDense(3, 3, []float64{1, 2, 1, 2, 3, 2, 1, 2, 1})})
+	output := layer.Forward(tensor.FromMats([]mat.Dense{*mat.NewDense(3, 3, []float64{1, 2, 1, 2, 3, 2, 1, 2, 1})}))
 
 	if !reflect.DeepEqual(
-		*layer.FlatOutput(),
+		output.RawData(),
 		[]float64{8, 8, 8, 8, -8, -8, -8, -8, 16, 16, 16, 16},
 	) {
 		t.Fatal()
@@ -95,9 +99,9 @@ func TestConv2D_4(t *testing.T) {
 		0, 5, 0, -1,
 	}
 	propperInput := layer.ArrayToConv2DInput(input)
-	layer.Forward(&propperInput)
+	output := layer.Forward(propperInput)
 	target := []float64{4, 19, 4}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		t.Fatal()
 	}
 }
@@ -118,13 +122,13 @@ func TestConv2D_Stride_1(t *testing.T) {
 		2, 5, 4, 7, 0, 8,
 	}
 	propperInput := layer.ArrayToConv2DInput(input)
-	layer.Forward(&propperInput)
+	output := layer.Forward(propperInput)
 	target := []float64{
 		3, 1, 0,
 		1, 7, 4,
 		6, 12, 10,
 	}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		t.Fatal()
 	}
 }
@@ -144,14 +148,14 @@ func TestConv2D_Stride_2(t *testing.T) {
 		-2, 1, -3, 3, 1,
 	}
 	propperInput := layer.ArrayToConv2DInput(input)
-	layer.Forward(&propperInput)
+	output := layer.Forward(propperInput)
 	target := []float64{
 		-16, 10,
 		6, 0,
 	}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		fmt.Println(target)
-		fmt.Println(*layer.FlatOutput())
+		fmt.Println(output.RawData())
 		t.Fatal()
 	}
 }
@@ -169,15 +173,15 @@ func TestConv2D_Padding_1(t *testing.T) {
 		-3, 4, -2,
 	}
 	propperInput := layer.ArrayToConv2DInput(input)
-	layer.Forward(&propperInput)
+	output := layer.Forward(propperInput)
 	target := []float64{
 		-6, 12, -15,
 		-16, 6, 7,
 		33, -23, 8,
 	}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		fmt.Println(target)
-		fmt.Println(*layer.FlatOutput())
+		fmt.Println(output.RawData())
 		t.Fatal()
 	}
 }
@@ -194,7 +198,7 @@ func TestConv2D_Padding_2(t *testing.T) {
 		0, 3, 1,
 		3, 2, 1,
 	})
-	layer.Forward(&input)
+	output := layer.Forward(input)
 	target := []float64{
 		0, 0, 0, 0, 0,
 		1, 2, 3, 6, 0,
@@ -202,9 +206,9 @@ func TestConv2D_Padding_2(t *testing.T) {
 		3, 5, 7, 3, 0,
 		-3, 1, 1, 1, 0,
 	}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		fmt.Println(target)
-		fmt.Println(*layer.FlatOutput())
+		fmt.Println(output.RawData())
 		t.Fatal()
 	}
 }
@@ -244,7 +248,8 @@ func TestConv2D_All_1(t *testing.T) {
 		-2, 1, 0,
 		2, 1, 3,
 	})
-	layer.Forward(&input)
+	output := layer.Forward(input)
+	outputMats := output.Mats(0)
 	target := []float64{
 		1, 3, 4, -7,
 		-6, 18, 3, 3,
@@ -252,9 +257,9 @@ func TestConv2D_All_1(t *testing.T) {
 		-11, -1, -6, -12,
 		-10, -6, 9, -6,
 	}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		fmt.Println(target)
-		functools.PrintMatSlice(layer.DeflatOutput(), 0)
+		functools.PrintMatSlice(&outputMats, 0)
 		t.Fatal()
 	}
 }
@@ -298,7 +303,8 @@ func TestConv2D_All_2(t *testing.T) {
 		-2, 1, 0,
 		2, 1, 3,
 	})
-	layer.Forward(&input)
+	output := layer.Forward(input)
+	outputMats := output.Mats(0)
 	target := []float64{
 		2, 4, 5, -6,
 		-5, 19, 4, 4,
@@ -306,9 +312,139 @@ func TestConv2D_All_2(t *testing.T) {
 		-13, -3, -8, -14,
 		-12, -8, 7, -8,
 	}
-	if !reflect.DeepEqual(target, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(target, output.RawData()) {
 		fmt.Println(target)
-		functools.PrintMatSlice(layer.DeflatOutput(), 0)
+		functools.PrintMatSlice(&outputMats, 0)
 		t.Fatal()
 	}
 }
+
+// Workers split batch samples and filters, results must not depend on their number
+func TestConv2D_Workers(t *testing.T) {
+	newLayer := func(workers int) conv.Conv2D {
+		layer := conv.NewConv2D([2]int{3, 3}, 4, [2]int{6, 5}, 2, [2]int{2, 1}, [4]int{1, 1, 1, 1})
+		filter := make([]float64, layer.NumChannels()*9)
+		for i := range filter {
+			filter[i] = float64(i%7) - 3.0
+		}
+		layer.LoadFilter(&filter)
+		bias := []float64{1, -1, 0.5, 2}
+		layer.LoadBias(&bias)
+		layer.SetWorkers(workers)
+		return layer
+	}
+	for _, batchSize := range []int{1, 3, 8} {
+		input := tensor.New(batchSize, 2, 6, 5, nil)
+		for i := range input.RawData() {
+			input.RawData()[i] = float64(i%11)*0.5 - 2.0
+		}
+		sequential := newLayer(1)
+		parallel := newLayer(4)
+		targetOutput := sequential.Forward(input)
+		resultOutput := parallel.Forward(input)
+		if !reflect.DeepEqual(targetOutput.RawData(), resultOutput.RawData()) {
+			fmt.Println("== OUTPUT ==", batchSize)
+			t.Fail()
+		}
+
+		grads := targetOutput.Map(func(v float64) float64 { return v * 0.1 })
+		targetOutGrads := sequential.Backward(grads)
+		resultOutGrads := parallel.Backward(grads)
+		if !reflect.DeepEqual(targetOutGrads.RawData(), resultOutGrads.RawData()) {
+			fmt.Println("== OUT GRADS ==", batchSize)
+			t.Fail()
+		}
+		if !reflect.DeepEqual(
+			sequential.GetWeightsGrads().RawData(),
+			parallel.GetWeightsGrads().RawData(),
+		) {
+			fmt.Println("== FILTER GRADS ==", batchSize)
+			t.Fail()
+		}
+		if !reflect.DeepEqual(
+			sequential.GetBiasGrads().RawData(),
+			parallel.GetBiasGrads().RawData(),
+		) {
+			fmt.Println("== BIAS GRADS ==", batchSize)
+			t.Fail()
+		}
+	}
+}
+
+func TestConv2D_InitFilterRandom(t *testing.T) {
+	layer := conv.NewConv2D([2]int{2, 2}, 2, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	layer.InitFilterRandom(-1, 1)
+	output := layer.Forward(tensor.New(1, 1, 3, 3, nil))
+	if n, c, h, w := output.Dims(); n != 1 || c != 2 || h != 2 || w != 2 {
+		fmt.Println(output.Dims())
+		t.Fail()
+	}
+}
+
+func TestConv2D_InitWeights(t *testing.T) {
+	layer := conv.NewConv2D([2]int{2, 2}, 2, [2]int{2, 2}, 3, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	initializer := initializers.NewHeNormal(rand.New(rand.NewSource(1)))
+	layer.InitWeights(&initializer)
+
+	// Ones input sums every filter row
+	input := tensor.New(1, 3, 2, 2, nil)
+	for i := range input.RawData() {
+		input.RawData()[i] = 1.0
+	}
+	output := layer.Forward(input).RawData()
+	targetInitializer := initializers.NewHeNormal(rand.New(rand.NewSource(1)))
+	filter := targetInitializer.Initialize(initializers.ConvShape(2, 3, 2, 2))
+	target := make([]float64, 2)
+	for i, v := range filter {
+		target[i/12] += v
+	}
+	if !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(target)
+		fmt.Println(output)
+		t.Fail()
+	}
+}
+
+func TestConv2D_Regularizers(t *testing.T) {
+	l2 := regularizers.NewL2(0.25)
+	layer := conv.NewConv2D([2]int{2, 2}, 1, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0}, &l2)
+	plainLayer := conv.NewConv2D([2]int{2, 2}, 1, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	filter := []float64{1, -2, 0, 4}
+	layer.LoadFilter(&filter)
+	plainLayer.LoadFilter(&filter)
+
+	input := tensor.New(1, 1, 3, 3, []float64{1, 2, 0, -1, 3, 1, 2, 0, -2})
+	inGrads := tensor.New(1, 1, 2, 2, []float64{1, -1, 0.5, 2})
+	layer.Forward(input)
+	layer.Backward(inGrads)
+	plainLayer.Forward(input)
+	plainLayer.Backward(inGrads)
+
+	// Plain grads with 2 * 0.25 * w
+	target := plainLayer.GetWeightsGrads().RawData()
+	for i, w := range filter {
+		target[i] += 0.5 * w
+	}
+	result := layer.GetWeightsGrads().RawData()
+	targetPenalty := 0.25 * 21
+	resultPenalty := layer.Penalty()
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(target)
+		fmt.Println(result)
+		t.Fail()
+	}
+	if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+		fmt.Println(resultPenalty)
+		t.Fail()
+	}
+}
+
+func TestConv2D_Params_Uninitialized_Filters(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := conv.NewConv2D([2]int{2, 2}, 1, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	layer.Params()
+}
@@ -0,0 +1,318 @@
+package conv
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/initializers"
+	"DoodleGan/tensor"
+)
+
+/*
+
+   Transposed convolution is the same operation as Conv2D backward pass to its input,
+   every input pixel scatters a kernel sized patch to the output with col2im.
+
+   https://arxiv.org/abs/1603.07285
+
+*/
+
+type Conv2DTranspose struct {
+	ConvType
+	kernelSize      MatSize
+	numberOfFilters int
+	inputChannels   int
+	padding         Padding
+	stride          Stride
+	outputPadding   MatSize
+
+	filters    []mat.Dense // views of filterData
+	filterData []float64
+	bias       []float64
+
+	SavedGrads
+	filterGrads *tensor.Tensor // numberOfFilters x inputChannels x kernel
+	biasGrads   *tensor.Tensor
+
+	colShape colShape // output unrolled by im2col
+}
+
+func NewConv2DTranspose(
+	kernelSize [2]int,
+	numberOfFilters int,
+	inputSize [2]int,
+	inputChannels int,
+	stride [2]int,
+	padding [4]int, // N, E, S, W
+	outputPadding [2]int,
+) Conv2DTranspose {
+	if numberOfFilters < 1 || inputChannels < 1 {
+		mess := fmt.Sprintf(
+			"NewConv2DTranspose fail:\n\tNumber of filters (%d) and number of channels (%d) must be positive",
+			numberOfFilters,
+			inputChannels,
+		)
+		panic(mess)
+	}
+	if outputPadding[0] < 0 || outputPadding[1] < 0 ||
+		outputPadding[0] >= stride[0] || outputPadding[1] >= stride[1] {
+		mess := fmt.Sprintf(
+			"NewConv2DTranspose fail:\n\tOutput padding (%d x %d) must be non negative and smaller than stride (%d x %d)",
+			outputPadding[0], outputPadding[1],
+			stride[0], stride[1],
+		)
+		panic(mess)
+	}
+	stride_ := Stride{
+		vertical:   stride[0],
+		horizontal: stride[1],
+	}
+	padding_ := Padding{
+		up:    padding[0],
+		right: padding[1],
+		down:  padding[2],
+		left:  padding[3],
+	}
+	inputSize_ := MatSize{inputSize[0], inputSize[1]}
+	fullOutputSize_ := MatSize{
+		height: (inputSize[0]-1)*stride[0] + kernelSize[0] + outputPadding[0],
+		width:  (inputSize[1]-1)*stride[1] + kernelSize[1] + outputPadding[1],
+	}
+	outputSize_ := MatSize{
+		height: fullOutputSize_.height - padding[0] - padding[2],
+		width:  fullOutputSize_.width - padding[1] - padding[3],
+	}
+	if outputSize_.height < 1 || outputSize_.width < 1 {
+		mess := fmt.Sprintf(
+			"NewConv2DTranspose fail:\n\tPadding is too big for output size (%d x %d)",
+			fullOutputSize_.height,
+			fullOutputSize_.width,
+		)
+		panic(mess)
+	}
+	return Conv2DTranspose{
+		ConvType: ConvType{
+			inputSize:  inputSize_,
+			outputSize: outputSize_,
+		},
+		kernelSize:      MatSize{kernelSize[0], kernelSize[1]},
+		numberOfFilters: numberOfFilters,
+		inputChannels:   inputChannels,
+		padding:         padding_,
+		stride:          stride_,
+		outputPadding:   MatSize{outputPadding[0], outputPadding[1]},
+		bias:            make([]float64, numberOfFilters),
+		filterGrads:     tensor.New(numberOfFilters, inputChannels, kernelSize[0], kernelSize[1], nil),
+		biasGrads:       tensor.New(1, 1, 1, numberOfFilters, nil),
+		colShape: colShape{
+			channels:   numberOfFilters,
+			inputSize:  outputSize_,
+			kernelSize: MatSize{kernelSize[0], kernelSize[1]},
+			outputSize: inputSize_,
+			padding:    padding_,
+			stride:     stride_,
+		},
+	}
+}
+
+func (layer *Conv2DTranspose) GetKernelSize() (int, int) {
+	return layer.kernelSize.height, layer.kernelSize.width
+}
+
+func (layer *Conv2DTranspose) GetOutputSize() (int, int) {
+	return layer.outputSize.height, layer.outputSize.width
+}
+
+func (layer *Conv2DTranspose) PrintFilter(precision int) {
+	functools.PrintMatSlice(&layer.filters, precision)
+}
+
+func (layer *Conv2DTranspose) LoadBias(biases *[]float64) {
+	if len(*biases) != layer.numberOfFilters {
+		mess := fmt.Sprintf(
+			"Bias load fail:\n\tdimention of bias to load (%d) doesn't match number filters (%d)",
+			len(*biases),
+			layer.numberOfFilters,
+		)
+		panic(mess)
+	}
+	layer.bias = slices.Clone(*biases)
+}
+
+func (layer *Conv2DTranspose) InitFilterRandom(minRange, maxRange float64) {
+	if maxRange < minRange {
+		panic("Filter random initialization fail:\n\tminRange can't be greater than maxRange")
+	}
+	filterData := make([]float64, layer.NumChannels()*layer.kernelSize.FlatDim())
+	for i := range filterData {
+		filterData[i] = rand.Float64()*(maxRange-minRange) + minRange
+	}
+	layer.filterData = filterData
+	layer.filters = filterViews(filterData, layer.kernelSize)
+}
+
+// Fans are computed as for Conv2D with the same kernels
+func (layer *Conv2DTranspose) InitWeights(initializer initializers.Initializer) {
+	filter := initializer.Initialize(initializers.ConvShape(
+		layer.numberOfFilters,
+		layer.inputChannels,
+		layer.kernelSize.height,
+		layer.kernelSize.width,
+	))
+	layer.LoadFilter(&filter)
+}
+
+// Kernels are ordered by output filter, then by input channel, same as in Conv2D
+func (layer *Conv2DTranspose) LoadFilter(source *[]float64) {
+	numChannels := layer.NumChannels()
+	numPixelsKernel := layer.kernelSize.FlatDim()
+	if len(*source) != numChannels*numPixelsKernel {
+		mess := fmt.Sprintf(
+			"Load filter fail:\n\tSource length and dimentions doesn't match: %d * %d * %d * %d != %d",
+			layer.numberOfFilters,
+			layer.inputChannels,
+			layer.kernelSize.height,
+			layer.kernelSize.width,
+			len(*source),
+		)
+		panic(mess)
+	}
+	layer.filterData = slices.Clone(*source)
+	layer.filters = filterViews(layer.filterData, layer.kernelSize)
+}
+
+// Output padding rows and columns get only bias, as no patch reaches them
+func (layer *Conv2DTranspose) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkInputDims("Conv2DTranspose forward", input, layer.inputChannels, layer.inputSize)
+	checkFiltersSet("Conv2DTranspose forward", layer.filterData)
+	layer.lastInput = input
+	layer.lastOutput = tensor.New(
+		input.Len(),
+		layer.numberOfFilters,
+		layer.outputSize.height,
+		layer.outputSize.width,
+		nil,
+	)
+	kernelLen := layer.kernelSize.FlatDim()
+	cols := mat.NewDense(layer.colShape.rows(), layer.colShape.cols(), nil)
+	for n := range input.Len() {
+		sample := layer.sampleMat(input, n)
+		for f := range layer.numberOfFilters {
+			patches := cols.Slice(f*kernelLen, (f+1)*kernelLen, 0, layer.colShape.cols()).(*mat.Dense)
+			patches.Mul(layer.filterMat(f).T(), sample)
+		}
+		col2im(cols.RawMatrix().Data, &layer.colShape, layer.lastOutput.Sample(n))
+		for f := range layer.numberOfFilters {
+			channel := layer.lastOutput.Channel(n, f)
+			for k := range channel {
+				channel[k] += layer.bias[f]
+			}
+		}
+	}
+	return layer.lastOutput
+}
+
+// Filter and bias gradients are summed over the batch
+func (layer *Conv2DTranspose) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	checkInputDims("Conv2DTranspose backward", inGrads, layer.numberOfFilters, layer.outputSize)
+	layer.lastInGrads = inGrads
+	layer.filterGrads.Zero()
+	layer.biasGrads.Zero()
+	n, c, h, w := layer.lastInput.Dims()
+	layer.lastOutGrads = tensor.New(n, c, h, w, nil)
+
+	kernelLen := layer.kernelSize.FlatDim()
+	biasGrads := layer.biasGrads.RawData()
+	cols := mat.NewDense(layer.colShape.rows(), layer.colShape.cols(), nil)
+	var kernelGrads, outGrad mat.Dense
+	for sample := range n {
+		im2col(inGrads.Sample(sample), &layer.colShape, cols.RawMatrix().Data)
+		input := layer.sampleMat(layer.lastInput, sample)
+		outGrads := layer.sampleMat(layer.lastOutGrads, sample)
+		for f := range layer.numberOfFilters {
+			patches := cols.Slice(f*kernelLen, (f+1)*kernelLen, 0, layer.colShape.cols())
+			kernelGrads.Mul(input, patches.T())
+			filterGrads := mat.NewDense(layer.inputChannels, kernelLen, layer.filterGrads.Sample(f))
+			filterGrads.Add(filterGrads, &kernelGrads)
+
+			outGrad.Mul(layer.filterMat(f), patches)
+			outGrads.Add(outGrads, &outGrad)
+			biasGrads[f] += mat.Sum(inGrads.Mat(sample, f))
+		}
+	}
+	return layer.lastOutGrads
+}
+
+// Kernels of one filter as inputChannels x kernel matrix sharing data with filters
+func (layer *Conv2DTranspose) filterMat(filter int) *mat.Dense {
+	size := layer.inputChannels * layer.kernelSize.FlatDim()
+	return mat.NewDense(
+		layer.inputChannels,
+		layer.kernelSize.FlatDim(),
+		layer.filterData[filter*size:(filter+1)*size],
+	)
+}
+
+// One sample as inputChannels x input matrix sharing data with source
+func (layer *Conv2DTranspose) sampleMat(source *tensor.Tensor, sample int) *mat.Dense {
+	return mat.NewDense(layer.inputChannels, layer.inputSize.FlatDim(), source.Sample(sample))
+}
+
+// Views of filter gradients ordered the same as filters
+func (layer *Conv2DTranspose) GetFilterGrads() *[]mat.Dense {
+	retVal := make([]mat.Dense, 0, layer.NumChannels())
+	for f := range layer.numberOfFilters {
+		retVal = append(retVal, layer.filterGrads.Mats(f)...)
+	}
+	return &retVal
+}
+
+func (layer *Conv2DTranspose) GetWeightsGrads() *tensor.Tensor {
+	return layer.filterGrads
+}
+
+func (layer *Conv2DTranspose) GetBiasGrads() *tensor.Tensor {
+	return layer.biasGrads
+}
+
+func (layer *Conv2DTranspose) Params() []tensor.Param {
+	checkFiltersSet("Conv2DTranspose params", layer.filterData)
+	return []tensor.Param{
+		{
+			Name: "filters",
+			Value: tensor.New(
+				layer.numberOfFilters,
+				layer.inputChannels,
+				layer.kernelSize.height,
+				layer.kernelSize.width,
+				layer.filterData,
+			),
+			Grad: layer.filterGrads,
+		},
+		{
+			Name:  "bias",
+			Value: tensor.New(1, 1, 1, layer.numberOfFilters, layer.bias),
+			Grad:  layer.biasGrads,
+		},
+	}
+}
+
+func (layer *Conv2DTranspose) GetFilter() *[]mat.Dense {
+	return &layer.filters
+}
+
+func (layer *Conv2DTranspose) GetBias() *[]float64 {
+	return &layer.bias
+}
+
+func (layer *Conv2DTranspose) NumChannels() int {
+	return layer.numberOfFilters * layer.inputChannels
+}
+
+func (layer *Conv2DTranspose) NumFilters() int {
+	return layer.numberOfFilters
+}
@@ -0,0 +1,230 @@
+package conv_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
+)
+
+func TestConv2DTranspose_1(t *testing.T) {
+	layer := conv.NewConv2DTranspose(
+		[2]int{2, 2}, 1, [2]int{2, 2}, 1, [2]int{2, 2}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	filter := []float64{1, 0, 0, 1}
+	layer.LoadFilter(&filter)
+	output := layer.Forward(tensor.FromMats([]mat.Dense{*mat.NewDense(2, 2, []float64{1, 2, 3, 4})})).Mats(0)
+	target := []mat.Dense{
+		*mat.NewDense(4, 4, []float64{
+			1, 0, 2, 0,
+			0, 1, 0, 2,
+			3, 0, 4, 0,
+			0, 3, 0, 4,
+		}),
+	}
+	if !functools.IsEqualMatSlice(&target, &output, 0.001) {
+		functools.PrintMatSlice(&output, 1)
+		t.Fail()
+	}
+}
+
+func TestConv2DTranspose_2(t *testing.T) {
+	layer := conv.NewConv2DTranspose(
+		[2]int{2, 2}, 1, [2]int{2, 2}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	filter := []float64{1, 0, 0, 1}
+	layer.LoadFilter(&filter)
+	output := layer.Forward(tensor.FromMats([]mat.Dense{*mat.NewDense(2, 2, []float64{1, 2, 3, 4})})).Mats(0)
+	target := []mat.Dense{
+		*mat.NewDense(3, 3, []float64{
+			1, 2, 0,
+			3, 5, 2,
+			0, 3, 4,
+		}),
+	}
+	if !functools.IsEqualMatSlice(&target, &output, 0.001) {
+		functools.PrintMatSlice(&output, 1)
+		t.Fail()
+	}
+}
+
+// Transposed convolution forward equals Conv2D backward to its input
+func TestConv2DTranspose_Conv2D_Adjoint(t *testing.T) {
+	filter := []float64{1, -2, 0, 3, 1, -1, 2, 0, 1}
+	convLayer := conv.NewConv2D([2]int{3, 3}, 1, [2]int{5, 5}, 1, [2]int{2, 2}, [4]int{0, 0, 0, 0})
+	convLayer.LoadFilter(&filter)
+	input := []mat.Dense{*mat.NewDense(5, 5, nil)}
+	convLayer.Forward(tensor.FromMats(input))
+	grads := []mat.Dense{*mat.NewDense(2, 2, []float64{1, -1, 2, 0.5})}
+	target := convLayer.Backward(tensor.FromMats(grads)).Mats(0)
+
+	layer := conv.NewConv2DTranspose(
+		[2]int{3, 3}, 1, [2]int{2, 2}, 1, [2]int{2, 2}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	layer.LoadFilter(&filter)
+	output := layer.Forward(tensor.FromMats(grads)).Mats(0)
+	if !functools.IsEqualMatSlice(&target, &output, 0.001) {
+		functools.PrintMatSlice(&target, 2)
+		functools.PrintMatSlice(&output, 2)
+		t.Fail()
+	}
+}
+
+// Multi input
+// Multi filter
+// Stride, padding and output padding
+func TestConv2DTranspose_Backward_1(t *testing.T) {
+	layer := conv.NewConv2DTranspose(
+		[2]int{2, 3}, 2, [2]int{2, 2}, 2, [2]int{2, 1}, [4]int{1, 0, 0, 1}, [2]int{1, 0},
+	)
+	filter := []float64{
+		1, 0, -1, 2, 1, 0,
+		0, 1, 1, -1, 0, 2,
+
+		1, 1, 1, 0, -1, 0,
+		2, 0, 1, 1, -2, 1,
+	}
+	bias := []float64{0.5, -1}
+	layer.LoadFilter(&filter)
+	layer.LoadBias(&bias)
+	input := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, 2, 3, -1}),
+		*mat.NewDense(2, 2, []float64{0, 1, -2, 2}),
+	}
+	output := layer.Forward(tensor.FromMats(input)).Mats(0)
+	targetOutput := []mat.Dense{
+		*mat.NewDense(4, 3, []float64{
+			4.5, 2.5, 2.5,
+			-2.5, -2.5, 3.5,
+			-0.5, -4.5, 4.5,
+			0.5, 0.5, 0.5,
+		}),
+		*mat.NewDense(4, 3, []float64{
+			-1, -5, 0,
+			5, -1, 0,
+			2, -6, 1,
+			-1, -1, -1,
+		}),
+	}
+	if !functools.IsEqualMatSlice(&targetOutput, &output, 0.001) {
+		fmt.Println("== OUTPUT ==")
+		functools.PrintMatSlice(&output, 2)
+		t.Fail()
+	}
+
+	inGrads := []mat.Dense{
+		*mat.NewDense(4, 3, []float64{
+			-1, 2, -2,
+			0, -2, 1,
+			1, 1, 1,
+			-1, -2, 1,
+		}),
+		*mat.NewDense(4, 3, []float64{
+			-2, 1, 1,
+			2, -2, 1,
+			0, -1, 2,
+			-2, 0, -2,
+		}),
+	}
+	outGrads := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
+	targetOutGrads := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, -1, 3, 4}),
+		*mat.NewDense(2, 2, []float64{9, -6, -3, 9}),
+	}
+	targetFilterGrads := []mat.Dense{
+		*mat.NewDense(2, 3, []float64{0, 2, -7, -3, 5, 0}),
+		*mat.NewDense(2, 3, []float64{0, -4, 6, 1, 2, -2}),
+		*mat.NewDense(2, 3, []float64{-2, 8, -7, -4, 1, -2}),
+		*mat.NewDense(2, 3, []float64{4, -8, 6, -2, -1, 7}),
+	}
+	targetBiasGrads := []float64{-1, -2}
+	if !functools.IsEqualMatSlice(&targetOutGrads, &outGrads, 0.001) {
+		fmt.Println("== OUT GRADS ==")
+		functools.PrintMatSlice(&outGrads, 2)
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilterGrads, layer.GetFilterGrads(), 0.001) {
+		fmt.Println("== FILTER GRADS ==")
+		functools.PrintMatSlice(layer.GetFilterGrads(), 2)
+		t.Fail()
+	}
+	biasGrads := layer.GetBiasGrads().RawData()
+	if !functools.IsEqual(&targetBiasGrads, &biasGrads, 0.001) {
+		fmt.Println("== BIAS GRADS ==")
+		fmt.Println(biasGrads)
+		t.Fail()
+	}
+}
+
+func TestConv2DTranspose_Forward_Uninitialized_Filters(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := conv.NewConv2DTranspose(
+		[2]int{2, 2}, 1, [2]int{2, 2}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	layer.Forward(tensor.New(1, 1, 2, 2, nil))
+}
+
+func TestConv2D_Forward_Uninitialized_Filters(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := conv.NewConv2D([2]int{2, 2}, 1, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	layer.Forward(tensor.New(1, 1, 3, 3, nil))
+}
+
+func TestConv2D_LoadFilter_Copies(t *testing.T) {
+	filter := []float64{1, 0, 0, 1}
+	layer := conv.NewConv2D([2]int{2, 2}, 1, [2]int{2, 2}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	transposed := conv.NewConv2DTranspose(
+		[2]int{2, 2}, 1, [2]int{1, 1}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	layer.LoadFilter(&filter)
+	transposed.LoadFilter(&filter)
+	filter[0] = 5
+
+	output := layer.Forward(tensor.New(1, 1, 2, 2, []float64{1, 2, 3, 4})).RawData()
+	if target := []float64{5}; !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(output)
+		t.Fail()
+	}
+	output = transposed.Forward(tensor.New(1, 1, 1, 1, []float64{1})).RawData()
+	if target := []float64{1, 0, 0, 1}; !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(output)
+		t.Fail()
+	}
+}
+
+func TestConv2D_LoadBias_Copies(t *testing.T) {
+	filter := []float64{1, 0, 0, 1}
+	bias := []float64{0.5}
+	layer := conv.NewConv2D([2]int{2, 2}, 1, [2]int{2, 2}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	transposed := conv.NewConv2DTranspose(
+		[2]int{2, 2}, 1, [2]int{1, 1}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	layer.LoadFilter(&filter)
+	layer.LoadBias(&bias)
+	transposed.LoadFilter(&filter)
+	transposed.LoadBias(&bias)
+	bias[0] = 5
+
+	output := layer.Forward(tensor.New(1, 1, 2, 2, []float64{1, 2, 3, 4})).RawData()
+	if target := []float64{5.5}; !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(output)
+		t.Fail()
+	}
+	output = transposed.Forward(tensor.New(1, 1, 1, 1, []float64{1})).RawData()
+	if target := []float64{1.5, 0.5, 0.5, 1.5}; !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(output)
+		t.Fail()
+	}
+}
@@ -0,0 +1,85 @@
+package conv
+
+import (
+	"fmt"
+	"math/rand"
+
+	"DoodleGan/tensor"
+)
+
+// Spatial dropout, zeroes whole channels of a sample with probability rate while training
+// and scales kept channels by 1 / (1 - rate), so inference passes input unchanged
+type Dropout2D struct {
+	rate     float64
+	rng      *rand.Rand
+	training bool
+
+	mask []float64 // 0 or 1 / (1 - rate) for every channel of every sample
+}
+
+func NewDropout2D(rate float64, rng *rand.Rand) Dropout2D {
+	if rate < 0.0 || rate >= 1.0 {
+		panic(fmt.Sprintf("NewDropout2D fail:\n\trate (%f) must be in range [0, 1)", rate))
+	}
+	if rng == nil {
+		panic("NewDropout2D fail:\n\tRandom number generator can't be nil")
+	}
+	return Dropout2D{
+		rate:     rate,
+		rng:      rng,
+		training: true,
+	}
+}
+
+func (layer *Dropout2D) SetRand(rng *rand.Rand) {
+	if rng == nil {
+		panic("SetRand fail:\n\tRandom number generator can't be nil")
+	}
+	layer.rng = rng
+}
+
+func (layer *Dropout2D) SetTraining(training bool) {
+	layer.training = training
+}
+
+func (layer *Dropout2D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	if !layer.training {
+		layer.mask = nil
+		return input
+	}
+	n, c, _, _ := input.Dims()
+	scale := 1.0 / (1.0 - layer.rate)
+	layer.mask = make([]float64, n*c)
+	for i := range layer.mask {
+		if layer.rng.Float64() >= layer.rate {
+			layer.mask[i] = scale
+		}
+	}
+	return layer.applyMask(input)
+}
+
+func (layer *Dropout2D) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	if layer.mask == nil {
+		return inGrads
+	}
+	return layer.applyMask(inGrads)
+}
+
+func (layer *Dropout2D) GetRate() float64 {
+	return layer.rate
+}
+
+func (layer *Dropout2D) applyMask(source *tensor.Tensor) *tensor.Tensor {
+	n, c, _, _ := source.Dims()
+	retVal := source.Clone()
+	for sample := range n {
+		for ch := range c {
+			m := layer.mask[sample*c+ch]
+			channel := retVal.Channel(sample, ch)
+			for k := range channel {
+				channel[k] *= m
+			}
+		}
+	}
+	return retVal
+}
@@ -0,0 +1,59 @@
+package conv_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
+)
+
+// Every channel is either dropped as a whole or kept and scaled
+func TestDropout2D_Forward_Backward(t *testing.T) {
+	layer := conv.NewDropout2D(0.5, rand.New(rand.NewSource(42)))
+	input := tensor.New(4, 8, 3, 3, nil)
+	for i := range input.RawData() {
+		input.RawData()[i] = float64(i + 1)
+	}
+	output := layer.Forward(input)
+	outGrads := layer.Backward(input)
+	dropped := 0
+	for n := range 4 {
+		for c := range 8 {
+			inChannel := input.Channel(n, c)
+			channel := output.Channel(n, c)
+			gradsChannel := outGrads.Channel(n, c)
+			if channel[0] == 0.0 {
+				dropped++
+			}
+			for k, v := range channel {
+				if v != 0.0 && v != 2.0*inChannel[k] || (v == 0.0) != (channel[0] == 0.0) {
+					fmt.Println(n, c, channel)
+					t.Fail()
+				}
+				if gradsChannel[k] != v {
+					fmt.Println(n, c, gradsChannel)
+					t.Fail()
+				}
+			}
+		}
+	}
+	if dropped == 0 || dropped == 32 {
+		fmt.Println(dropped)
+		t.Fail()
+	}
+}
+
+func TestDropout2D_Eval(t *testing.T) {
+	layer := conv.NewDropout2D(0.9, rand.New(rand.NewSource(1)))
+	layer.SetTraining(false)
+	input := tensor.New(1, 2, 1, 2, []float64{1, -2, 3, 4})
+	output := layer.Forward(input).RawData()
+	target := []float64{1, -2, 3, 4}
+	if !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(output)
+		t.Fail()
+	}
+}
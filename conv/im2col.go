@@ -0,0 +1,77 @@
+package conv
+
+// Shape of unrolled input, every column holds one kernel sized patch
+// of all channels, ordered channel, kernel row, kernel column.
+// Columns follow output positions row by row.
+type colShape struct {
+	channels   int
+	inputSize  MatSize
+	kernelSize MatSize
+	outputSize MatSize
+	padding    Padding
+	stride     Stride
+}
+
+func (s *colShape) rows() int {
+	return s.channels * s.kernelSize.FlatDim()
+}
+
+func (s *colShape) cols() int {
+	return s.outputSize.FlatDim()
+}
+
+// Fills dest (rows x cols) with patches of sample (C x H x W),
+// padded positions are written as zeros
+func im2col(sample []float64, shape *colShape, dest []float64) {
+	nCols := shape.cols()
+	row := 0
+	for c := range shape.channels {
+		channel := sample[c*shape.inputSize.FlatDim() : (c+1)*shape.inputSize.FlatDim()]
+		for ki := range shape.kernelSize.height {
+			for kj := range shape.kernelSize.width {
+				destRow := dest[row*nCols : (row+1)*nCols]
+				col := 0
+				for oi := range shape.outputSize.height {
+					i := oi*shape.stride.vertical + ki - shape.padding.up
+					for oj := range shape.outputSize.width {
+						j := oj*shape.stride.horizontal + kj - shape.padding.left
+						if i < 0 || i >= shape.inputSize.height || j < 0 || j >= shape.inputSize.width {
+							destRow[col] = 0.0
+						} else {
+							destRow[col] = channel[i*shape.inputSize.width+j]
+						}
+						col++
+					}
+				}
+				row++
+			}
+		}
+	}
+}
+
+// Adds every patch of source (rows x cols) back to its place in dest (C x H x W),
+// values that fall on padding are dropped
+func col2im(source []float64, shape *colShape, dest []float64) {
+	nCols := shape.cols()
+	row := 0
+	for c := range shape.channels {
+		channel := dest[c*shape.inputSize.FlatDim() : (c+1)*shape.inputSize.FlatDim()]
+		for ki := range shape.kernelSize.height {
+			for kj := range shape.kernelSize.width {
+				sourceRow := source[row*nCols : (row+1)*nCols]
+				col := 0
+				for oi := range shape.outputSize.height {
+					i := oi*shape.stride.vertical + ki - shape.padding.up
+					for oj := range shape.outputSize.width {
+						j := oj*shape.stride.horizontal + kj - shape.padding.left
+						if i >= 0 && i < shape.inputSize.height && j >= 0 && j < shape.inputSize.width {
+							channel[i*shape.inputSize.width+j] += sourceRow[col]
+						}
+						col++
+					}
+				}
+				row++
+			}
+		}
+	}
+}
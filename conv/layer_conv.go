@@ -1,30 +1,54 @@
 package conv
 
-import "gonum.org/v1/gonum/mat"
+import (
+	"fmt"
+	"math/rand"
 
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/tensor"
+)
+
+// Conv layers process a whole batch at once, samples are C x H x W feature maps
 type ConvLayer interface {
-	Forward(*[]mat.Dense) *[]mat.Dense
-	Backward(inGrads *[]mat.Dense) *[]mat.Dense
+	Forward(input *tensor.Tensor) *tensor.Tensor
+	Backward(inGrads *tensor.Tensor) *tensor.Tensor
 }
 
+// Gradients are summed over all samples of the last backward pass.
+// Param names are unique within a layer.
 type ConvLayerTrainable interface {
 	ConvLayer
 
-	ApplyGrads(learningRate *float64, dWeightsGrads *[]mat.Dense, dBiasGrad *[]float64)
-	DeflatOutGrads() *[]mat.Dense
-	GetBiasGrads() *[]float64
-	GetFilterGrads() *[]mat.Dense
+	Params() []tensor.Param
+}
+
+// Penalty is added to the loss, its gradient is part of grads after backward
+type ConvLayerRegularized interface {
+	ConvLayer
+
+	Penalty() float64
+}
+
+// Conv layers behaving differently in training and inference, like batch normalization
+type ConvLayerTrainMode interface {
+	SetTraining(training bool)
+}
+
+// Conv layers drawing random numbers while training, like dropout
+type ConvLayerRandom interface {
+	SetRand(rng *rand.Rand)
 }
 
 type ConvType struct {
-	SavedDataMat
+	SavedData
 	inputSize  MatSize
 	outputSize MatSize
 }
 
-type SavedDataMat struct {
-	lastInput  []mat.Dense
-	lastOutput []mat.Dense
+type SavedData struct {
+	lastInput  *tensor.Tensor
+	lastOutput *tensor.Tensor
 }
 
 type MatSize struct {
@@ -65,36 +89,32 @@ func NewPadding(up, right, down, left int) Padding {
 }
 
 type SavedGrads struct {
-	lastInGrads  []mat.Dense
-	lastOutGrads []mat.Dense
-}
-
-func GetFlatOutput(layer *ConvType) *[]float64 {
-	channelSize := layer.outputSize.height * layer.outputSize.width
-	result := make([]float64, channelSize*len(layer.lastOutput))
-	for i := range layer.lastOutput {
-		for j := range channelSize {
-			result[i*channelSize+j] = layer.lastOutput[i].RawMatrix().Data[j]
-		}
+	lastInGrads  *tensor.Tensor
+	lastOutGrads *tensor.Tensor
+}
+
+// Panics when samples don't have given number of channels of given size
+func checkInputDims(layerName string, input *tensor.Tensor, channels int, size MatSize) {
+	_, c, h, w := input.Dims()
+	if c != channels || h != size.height || w != size.width {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tInput sample (%d x %d x %d) doesn't match expected (%d x %d x %d)",
+			layerName,
+			c, h, w,
+			channels, size.height, size.width,
+		)
+		panic(mess)
 	}
-	return &result
 }
 
-func Deflat(source []mat.Dense, size MatSize, numFilter int) *[]mat.Dense {
-	result := make([]mat.Dense, numFilter)
-	for i := range numFilter {
-		reshaped := mat.NewDense(
-			size.height,
-			size.width,
-			source[i].RawMatrix().Data,
+func checkFiltersSet(layerName string, filterData []float64) {
+	if filterData == nil {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tFilters aren't initialized, use InitFilterRandom, InitWeights or LoadFilter",
+			layerName,
 		)
-		result[i] = *reshaped
+		panic(mess)
 	}
-	return &result
-}
-
-func GetDeflatOutput(layer *ConvType) *[]mat.Dense {
-	return Deflat(layer.lastOutput, layer.outputSize, len(layer.lastOutput))
 }
 
 func (size *MatSize) FlatDim() int {
@@ -108,3 +128,17 @@ func (size *MatSize) Height() int {
 func (size *MatSize) Width() int {
 	return size.width
 }
+
+// Kernels sharing data, kernel i is data[i*kh*kw : (i+1)*kh*kw]
+func filterViews(data []float64, kernelSize MatSize) []mat.Dense {
+	numPixelsKernel := kernelSize.FlatDim()
+	filters := make([]mat.Dense, len(data)/numPixelsKernel)
+	for i := range filters {
+		filters[i] = *mat.NewDense(
+			kernelSize.height,
+			kernelSize.width,
+			data[i*numPixelsKernel:(i+1)*numPixelsKernel],
+		)
+	}
+	return filters
+}
@@ -9,6 +9,7 @@ import (
 
 	"DoodleGan/conv"
 	"DoodleGan/functools"
+	"DoodleGan/tensor"
 )
 
 func TestMaxPool_1(t *testing.T) {
@@ -21,12 +22,12 @@ func TestMaxPool_1(t *testing.T) {
 			7, 6, 1, -9,
 		}),
 	}
-	layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input))
 	targetFlat := []float64{
 		4, 5, 7, 2,
 	}
-	if !reflect.DeepEqual(targetFlat, *layer.FlatOutput()) {
-		fmt.Println(*layer.FlatOutput())
+	if !reflect.DeepEqual(targetFlat, output.RawData()) {
+		fmt.Println(output.RawData())
 		t.Fatal()
 	}
 }
@@ -47,7 +48,8 @@ func TestMaxPool_2(t *testing.T) {
 			-4, 0, 2, 3,
 		}),
 	}
-	layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input))
+	outputMats := output.Mats(0)
 	targetFlat := []float64{
 		4, 5, 7, 2, 8, 5, 0, 3,
 	}
@@ -59,12 +61,12 @@ func TestMaxPool_2(t *testing.T) {
 			8, 5, 0, 3,
 		}),
 	}
-	if !reflect.DeepEqual(targetFlat, *layer.FlatOutput()) {
-		fmt.Println(*layer.FlatOutput())
+	if !reflect.DeepEqual(targetFlat, output.RawData()) {
+		fmt.Println(output.RawData())
 		t.Fatal()
 	}
-	if !functools.IsEqualMatSlice(&targetDeflat, layer.DeflatOutput(), 0.001) {
-		fmt.Println(*layer.DeflatOutput())
+	if !functools.IsEqualMatSlice(&targetDeflat, &outputMats, 0.001) {
+		fmt.Println(outputMats)
 		t.Fatal()
 	}
 }
@@ -78,11 +80,11 @@ func TestMaxPool_3(t *testing.T) {
 			4, 4, 4,
 		}),
 	}
-	layer.Forward(&input)
+	output := layer.Forward(tensor.FromMats(input))
 	targetFlat := []float64{
 		3,
 	}
-	if !reflect.DeepEqual(targetFlat, *layer.FlatOutput()) {
+	if !reflect.DeepEqual(targetFlat, output.RawData()) {
 		t.Fatal()
 	}
 }
@@ -103,12 +105,12 @@ func TestMaxPool_Backward_1(t *testing.T) {
 			-3, -4, 2, 2,
 		}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{4, 2, -2, 3}),
 		*mat.NewDense(2, 2, []float64{3, 4, 1, -5}),
 	}
-	result := layer.Backward(&inGrads)
+	result := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{
 			0, 0, 0, 0,
@@ -123,9 +125,9 @@ func TestMaxPool_Backward_1(t *testing.T) {
 			0, 0, 0, 0,
 		}),
 	}
-	if !functools.IsEqualMatSlice(&target, result, 0.001) {
+	if !functools.IsEqualMatSlice(&target, &result, 0.001) {
 		functools.PrintMatSlice(&target, 1)
-		functools.PrintMatSlice(result, 1)
+		functools.PrintMatSlice(&result, 1)
 		t.Fail()
 	}
 }
@@ -146,12 +148,12 @@ func TestMaxPool_Backward_2(t *testing.T) {
 			3, 0, 2, 1,
 		}),
 	}
-	layer.Forward(&input)
+	layer.Forward(tensor.FromMats(input))
 	inGrads := []mat.Dense{
 		*mat.NewDense(1, 1, []float64{4}),
 		*mat.NewDense(1, 1, []float64{-1}),
 	}
-	result := layer.Backward(&inGrads)
+	result := layer.Backward(tensor.FromMats(inGrads)).Mats(0)
 	target := []mat.Dense{
 		*mat.NewDense(4, 4, []float64{
 			0, 0, 4, 0,
@@ -166,9 +168,9 @@ func TestMaxPool_Backward_2(t *testing.T) {
 			0, 0, 0, 0,
 		}),
 	}
-	if !functools.IsEqualMatSlice(&target, result, 0.001) {
+	if !functools.IsEqualMatSlice(&target, &result, 0.001) {
 		functools.PrintMatSlice(&target, 1)
-		functools.PrintMatSlice(result, 1)
+		functools.PrintMatSlice(&result, 1)
 		t.Fail()
 	}
 }
@@ -0,0 +1,34 @@
+package conv
+
+import (
+	"runtime"
+	"sync"
+)
+
+func workersOrDefault(workers int) int {
+	if workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+// Splits [0, n) into at most workers contiguous chunks, every chunk runs in its own goroutine.
+// Chunks depend only on workers and n, so callers writing disjoint memory get the same
+// result on every run.
+func parallelChunks(workers, n int, fn func(start, end int)) {
+	if workers <= 1 || n <= 1 {
+		fn(0, n)
+		return
+	}
+	workers = min(workers, n)
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += chunk {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, min(start+chunk, n))
+	}
+	wg.Wait()
+}
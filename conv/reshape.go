@@ -0,0 +1,78 @@
+package conv
+
+import (
+	"fmt"
+
+	"DoodleGan/tensor"
+)
+
+// Bridge from conv feature maps to dense vectors, channels are concatenated row by row
+type Flatten struct {
+	inputDims [3]int // C, H, W of samples seen in the last forward pass
+}
+
+// Bridge from dense vectors to conv feature maps
+type Reshape struct {
+	outputSize     MatSize
+	outputChannels int
+
+	inputDims [3]int // C, H, W of samples seen in the last forward pass
+}
+
+func NewFlatten() Flatten {
+	return Flatten{}
+}
+
+func (layer *Flatten) Forward(input *tensor.Tensor) *tensor.Tensor {
+	_, c, h, w := input.Dims()
+	layer.inputDims = [3]int{c, h, w}
+	return input.Reshape(1, 1, input.SampleLen())
+}
+
+func (layer *Flatten) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return inGrads.Reshape(layer.inputDims[0], layer.inputDims[1], layer.inputDims[2])
+}
+
+func NewReshape(outputSize [2]int, outputChannels int) Reshape {
+	if outputSize[0] < 1 || outputSize[1] < 1 || outputChannels < 1 {
+		mess := fmt.Sprintf(
+			"NewReshape fail:\n\tOutput size (%d x %d x %d) must be positive",
+			outputChannels,
+			outputSize[0],
+			outputSize[1],
+		)
+		panic(mess)
+	}
+	return Reshape{
+		outputSize:     MatSize{outputSize[0], outputSize[1]},
+		outputChannels: outputChannels,
+	}
+}
+
+func (layer *Reshape) Forward(input *tensor.Tensor) *tensor.Tensor {
+	if input.SampleLen() != layer.outputChannels*layer.outputSize.FlatDim() {
+		mess := fmt.Sprintf(
+			"Reshape fail:\n\tSample length (%d) doesn't match %d channels of size %d x %d",
+			input.SampleLen(),
+			layer.outputChannels,
+			layer.outputSize.height,
+			layer.outputSize.width,
+		)
+		panic(mess)
+	}
+	_, c, h, w := input.Dims()
+	layer.inputDims = [3]int{c, h, w}
+	return input.Reshape(layer.outputChannels, layer.outputSize.height, layer.outputSize.width)
+}
+
+func (layer *Reshape) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return inGrads.Reshape(layer.inputDims[0], layer.inputDims[1], layer.inputDims[2])
+}
+
+func (layer *Reshape) GetOutputSize() (int, int) {
+	return layer.outputSize.height, layer.outputSize.width
+}
+
+func (layer *Reshape) NumChannels() int {
+	return layer.outputChannels
+}
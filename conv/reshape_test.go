@@ -0,0 +1,68 @@
+package conv_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
+)
+
+func TestFlatten_1(t *testing.T) {
+	layer := conv.NewFlatten()
+	input := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, 2, 3, 4}),
+		*mat.NewDense(2, 2, []float64{5, 6, 7, 8}),
+	}
+	output := layer.Forward(tensor.FromMats(input))
+	target := mat.NewVecDense(8, []float64{1, 2, 3, 4, 5, 6, 7, 8})
+	if !functools.IsEqualVec(target, output.Vec(0), 1e-12) {
+		fmt.Println(output.RawData())
+		t.Fail()
+	}
+
+	grads := layer.Backward(
+		tensor.FromVec(mat.NewVecDense(8, []float64{8, 7, 6, 5, 4, 3, 2, 1})),
+	).Mats(0)
+	targetGrads := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{8, 7, 6, 5}),
+		*mat.NewDense(2, 2, []float64{4, 3, 2, 1}),
+	}
+	if !functools.IsEqualMatSlice(&targetGrads, &grads, 1e-12) {
+		functools.PrintMatSlice(&grads, 1)
+		t.Fail()
+	}
+}
+
+func TestReshape_1(t *testing.T) {
+	layer := conv.NewReshape([2]int{2, 3}, 2)
+	input := mat.NewVecDense(12, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	output := layer.Forward(tensor.FromVec(input)).Mats(0)
+	target := []mat.Dense{
+		*mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}),
+		*mat.NewDense(2, 3, []float64{7, 8, 9, 10, 11, 12}),
+	}
+	if !functools.IsEqualMatSlice(&target, &output, 1e-12) {
+		functools.PrintMatSlice(&output, 1)
+		t.Fail()
+	}
+
+	grads := layer.Backward(tensor.FromMats(target))
+	if !functools.IsEqualVec(input, grads.Vec(0), 1e-12) {
+		fmt.Println(grads.RawData())
+		t.Fail()
+	}
+}
+
+func TestReshape_Length_Mismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := conv.NewReshape([2]int{2, 2}, 2)
+	layer.Forward(tensor.New(1, 1, 1, 6, nil))
+}
@@ -114,6 +114,20 @@ func ArgToSliceLabel(n, idx int) []float64 {
 	return retVal
 }
 
+// Softmax with max subtracted from every input before exponentiation
+func StableSoftmax(input *mat.VecDense) mat.VecDense {
+	maxVal := mat.Max(input)
+	retVal := *mat.NewVecDense(input.Len(), nil)
+	expSums := 0.0
+	for i := range input.Len() {
+		exp := math.Exp(input.AtVec(i) - maxVal)
+		expSums += exp
+		retVal.SetVec(i, exp)
+	}
+	retVal.ScaleVec(1./expSums, &retVal)
+	return retVal
+}
+
 func VecToMatSlice(source *mat.VecDense, height, width int) []mat.Dense {
 	channelPixels := height * width
 	numChannels := len(source.RawVector().Data) / channelPixels
@@ -0,0 +1,51 @@
+package initializers
+
+import "fmt"
+
+// Weights of a layer seen as a Rows x Cols matrix, rows are output units
+type Shape struct {
+	Rows   int
+	Cols   int
+	FanIn  int
+	FanOut int
+}
+
+type Initializer interface {
+	// Returns Rows * Cols values in row major order
+	Initialize(shape Shape) []float64
+}
+
+// Dense weights are nNeurons x nInputs
+func DenseShape(nInputs, nNeurons int) Shape {
+	return Shape{
+		Rows:   nNeurons,
+		Cols:   nInputs,
+		FanIn:  nInputs,
+		FanOut: nNeurons,
+	}
+}
+
+// Kernels ordered by filter, then by input channel, every filter is one row
+func ConvShape(numberOfFilters, inputChannels, kernelHeight, kernelWidth int) Shape {
+	receptiveField := kernelHeight * kernelWidth
+	return Shape{
+		Rows:   numberOfFilters,
+		Cols:   inputChannels * receptiveField,
+		FanIn:  inputChannels * receptiveField,
+		FanOut: numberOfFilters * receptiveField,
+	}
+}
+
+func checkValidShape(shape *Shape, funcName string) {
+	if shape.Rows < 1 || shape.Cols < 1 || shape.FanIn < 1 || shape.FanOut < 1 {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tShape dimentions must be positive, have: %d x %d, fan in: %d, fan out: %d",
+			funcName,
+			shape.Rows,
+			shape.Cols,
+			shape.FanIn,
+			shape.FanOut,
+		)
+		panic(mess)
+	}
+}
@@ -0,0 +1,59 @@
+package initializers
+
+import (
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Rows (or columns, whichever are fewer) of the weight matrix are orthonormal, scaled by gain
+//
+// https://arxiv.org/abs/1312.6120
+type Orthogonal struct {
+	gain float64
+	rng  *rand.Rand
+}
+
+func NewOrthogonal(gain float64, rng *rand.Rand) Orthogonal {
+	if rng == nil {
+		panic("NewOrthogonal fail:\n\tRandom number generator can't be nil")
+	}
+	return Orthogonal{
+		gain: gain,
+		rng:  rng,
+	}
+}
+
+func (o *Orthogonal) Initialize(shape Shape) []float64 {
+	checkValidShape(&shape, "Orthogonal initialize")
+	rows, cols := shape.Rows, shape.Cols
+	if rows < cols {
+		rows, cols = cols, rows
+	}
+	normal := make([]float64, rows*cols)
+	for i := range normal {
+		normal[i] = o.rng.NormFloat64()
+	}
+	var qr mat.QR
+	qr.Factorize(mat.NewDense(rows, cols, normal))
+	var q, r mat.Dense
+	qr.QTo(&q)
+	qr.RTo(&r)
+
+	// Sign of R diagonal makes Q uniformly distributed
+	retVal := mat.NewDense(rows, cols, nil)
+	for j := range cols {
+		sign := o.gain
+		if r.At(j, j) < 0.0 {
+			sign = -o.gain
+		}
+		for i := range rows {
+			retVal.Set(i, j, q.At(i, j)*sign)
+		}
+	}
+	if shape.Rows < shape.Cols {
+		transposed := mat.DenseCopyOf(retVal.T())
+		return transposed.RawMatrix().Data
+	}
+	return retVal.RawMatrix().Data
+}
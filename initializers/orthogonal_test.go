@@ -0,0 +1,45 @@
+package initializers_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/initializers"
+)
+
+func TestOrthogonal(t *testing.T) {
+	testCases := []struct {
+		rows int
+		cols int
+	}{
+		{3, 5},
+		{5, 3},
+		{4, 4},
+	}
+	for _, tc := range testCases {
+		gain := 2.0
+		initializer := initializers.NewOrthogonal(gain, rand.New(rand.NewSource(3)))
+		shape := initializers.Shape{Rows: tc.rows, Cols: tc.cols, FanIn: tc.cols, FanOut: tc.rows}
+		weights := mat.NewDense(tc.rows, tc.cols, initializer.Initialize(shape))
+
+		// Fewer vectors are orthogonal with squared norm gain^2
+		var gram mat.Dense
+		if tc.rows <= tc.cols {
+			gram.Mul(weights, weights.T())
+		} else {
+			gram.Mul(weights.T(), weights)
+		}
+		target := mat.NewDiagDense(min(tc.rows, tc.cols), nil)
+		for i := range min(tc.rows, tc.cols) {
+			target.SetDiag(i, gain*gain)
+		}
+		if !mat.EqualApprox(&gram, target, 1e-9) {
+			fmt.Println(tc.rows, tc.cols)
+			fmt.Println(mat.Formatted(&gram))
+			t.Fail()
+		}
+	}
+}
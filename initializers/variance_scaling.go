@@ -0,0 +1,89 @@
+package initializers
+
+import (
+	"math"
+	"math/rand"
+)
+
+type fanMode int
+
+const (
+	fanIn fanMode = iota
+	fanAvg
+)
+
+// Draws weights with variance scale / fan, either from a normal distribution
+// or a uniform one in [-limit, limit] with the same variance
+type VarianceScaling struct {
+	scale   float64
+	mode    fanMode
+	uniform bool
+	rng     *rand.Rand
+}
+
+// Variance 2 / (fanIn + fanOut)
+func NewGlorotUniform(rng *rand.Rand) VarianceScaling {
+	return newVarianceScaling(1.0, fanAvg, true, rng, "NewGlorotUniform")
+}
+
+func NewGlorotNormal(rng *rand.Rand) VarianceScaling {
+	return newVarianceScaling(1.0, fanAvg, false, rng, "NewGlorotNormal")
+}
+
+// Variance 2 / fanIn, suited for ReLU activations
+func NewHeUniform(rng *rand.Rand) VarianceScaling {
+	return newVarianceScaling(2.0, fanIn, true, rng, "NewHeUniform")
+}
+
+func NewHeNormal(rng *rand.Rand) VarianceScaling {
+	return newVarianceScaling(2.0, fanIn, false, rng, "NewHeNormal")
+}
+
+// Variance 1 / fanIn
+func NewLeCunUniform(rng *rand.Rand) VarianceScaling {
+	return newVarianceScaling(1.0, fanIn, true, rng, "NewLeCunUniform")
+}
+
+func NewLeCunNormal(rng *rand.Rand) VarianceScaling {
+	return newVarianceScaling(1.0, fanIn, false, rng, "NewLeCunNormal")
+}
+
+func newVarianceScaling(
+	scale float64,
+	mode fanMode,
+	uniform bool,
+	rng *rand.Rand,
+	funcName string,
+) VarianceScaling {
+	if rng == nil {
+		panic(funcName + " fail:\n\tRandom number generator can't be nil")
+	}
+	return VarianceScaling{
+		scale:   scale,
+		mode:    mode,
+		uniform: uniform,
+		rng:     rng,
+	}
+}
+
+func (vs *VarianceScaling) Initialize(shape Shape) []float64 {
+	checkValidShape(&shape, "VarianceScaling initialize")
+	fan := float64(shape.FanIn)
+	if vs.mode == fanAvg {
+		fan = float64(shape.FanIn+shape.FanOut) / 2.0
+	}
+	variance := vs.scale / fan
+	retVal := make([]float64, shape.Rows*shape.Cols)
+	if vs.uniform {
+		limit := math.Sqrt(3.0 * variance)
+		for i := range retVal {
+			retVal[i] = (vs.rng.Float64()*2.0 - 1.0) * limit
+		}
+	} else {
+		stdDev := math.Sqrt(variance)
+		for i := range retVal {
+			retVal[i] = vs.rng.NormFloat64() * stdDev
+		}
+	}
+	return retVal
+}
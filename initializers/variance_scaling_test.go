@@ -0,0 +1,89 @@
+package initializers_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/initializers"
+)
+
+func sampleVariance(values []float64) float64 {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	retVal := 0.0
+	for _, v := range values {
+		retVal += (v - mean) * (v - mean)
+	}
+	return retVal / float64(len(values))
+}
+
+func TestShapes(t *testing.T) {
+	dense := initializers.DenseShape(3, 5)
+	targetDense := initializers.Shape{Rows: 5, Cols: 3, FanIn: 3, FanOut: 5}
+	if dense != targetDense {
+		fmt.Println(dense)
+		t.Fail()
+	}
+	conv := initializers.ConvShape(4, 2, 3, 3)
+	targetConv := initializers.Shape{Rows: 4, Cols: 18, FanIn: 18, FanOut: 36}
+	if conv != targetConv {
+		fmt.Println(conv)
+		t.Fail()
+	}
+}
+
+func TestVarianceScaling_Variance(t *testing.T) {
+	shape := initializers.ConvShape(50, 20, 3, 3) // fan in 180, fan out 450
+	testCases := []struct {
+		name        string
+		initializer initializers.VarianceScaling
+		variance    float64
+		limit       float64
+	}{
+		{"GlorotUniform", initializers.NewGlorotUniform(rand.New(rand.NewSource(1))), 2.0 / 630.0, math.Sqrt(6.0 / 630.0)},
+		{"GlorotNormal", initializers.NewGlorotNormal(rand.New(rand.NewSource(1))), 2.0 / 630.0, math.Inf(1)},
+		{"HeUniform", initializers.NewHeUniform(rand.New(rand.NewSource(1))), 2.0 / 180.0, math.Sqrt(6.0 / 180.0)},
+		{"HeNormal", initializers.NewHeNormal(rand.New(rand.NewSource(1))), 2.0 / 180.0, math.Inf(1)},
+		{"LeCunUniform", initializers.NewLeCunUniform(rand.New(rand.NewSource(1))), 1.0 / 180.0, math.Sqrt(3.0 / 180.0)},
+		{"LeCunNormal", initializers.NewLeCunNormal(rand.New(rand.NewSource(1))), 1.0 / 180.0, math.Inf(1)},
+	}
+	for _, tc := range testCases {
+		values := tc.initializer.Initialize(shape)
+		if len(values) != 50*180 {
+			fmt.Println(tc.name, len(values))
+			t.Fail()
+			continue
+		}
+		for _, v := range values {
+			if math.Abs(v) > tc.limit {
+				fmt.Println(tc.name, v)
+				t.Fail()
+				break
+			}
+		}
+		variance := sampleVariance(values)
+		if math.Abs(variance-tc.variance) > 0.05*tc.variance {
+			fmt.Println(tc.name, variance, tc.variance)
+			t.Fail()
+		}
+	}
+}
+
+func TestVarianceScaling_Seed(t *testing.T) {
+	shape := initializers.DenseShape(4, 3)
+	first := initializers.NewHeNormal(rand.New(rand.NewSource(5)))
+	second := initializers.NewHeNormal(rand.New(rand.NewSource(5)))
+	firstValues := first.Initialize(shape)
+	secondValues := second.Initialize(shape)
+	if !functools.IsEqual(&firstValues, &secondValues, 0) {
+		fmt.Println(firstValues)
+		fmt.Println(secondValues)
+		t.Fail()
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"math"
 
 	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
 )
 
 type (
@@ -17,68 +20,86 @@ type VActivationsLambdas struct {
 }
 
 type Softmax struct {
-	SavedDataVec
+	SavedData
 }
 
 type VReLU struct {
-	SavedDataVec
+	SavedData
 	VActivationsLambdas
 }
 
 type VLeakyReLU struct {
 	alpha float64
-	SavedDataVec
+	SavedData
 	VActivationsLambdas
 }
 
 type VELU struct {
 	alpha float64
-	SavedDataVec
+	SavedData
 	VActivationsLambdas
 }
 
 type VSigmoid struct {
-	SavedDataVec
+	SavedData
 	VActivationsLambdas
 }
 
 type VTanh struct {
-	SavedDataVec
+	SavedData
 	VActivationsLambdas
 }
 
 func ApplyOnInputVec(
 	act func(v float64) float64,
-	input, dest *mat.VecDense,
-) {
-	for i := range input.Len() {
-		dest.RawVector().Data[i] = act(input.AtVec(i))
-	}
+	input *tensor.Tensor,
+) *tensor.Tensor {
+	return input.Map(act)
 }
 
 func BackwardApply(
 	actPrime func(v float64) float64,
-	lastInput, inGrads *mat.VecDense,
-) mat.VecDense {
-	result := mat.NewVecDense(inGrads.Len(), nil)
-	ApplyOnInputVec(actPrime, lastInput, result)
-	result.MulElemVec(result, inGrads.SliceVec(0, inGrads.Len()))
-	return *result
+	lastInput, inGrads *tensor.Tensor,
+) *tensor.Tensor {
+	result := ApplyOnInputVec(actPrime, lastInput)
+	resultData := result.RawData()
+	for i, g := range inGrads.RawData() {
+		resultData[i] *= g
+	}
+	return result
 }
 
 func NewSoftmax() Softmax {
 	return Softmax{}
 }
 
-func (layer *Softmax) Forward(input *mat.VecDense) *mat.VecDense {
-	expSums := 0.0
-	for i := range input.Len() {
-		exp := math.Exp(input.AtVec(i))
-		expSums += exp
-		input.RawVector().Data[i] = exp
+// Softmax is taken over every sample separately
+func (layer *Softmax) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	n, c, h, w := input.Dims()
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	for i := range n {
+		softmax := functools.StableSoftmax(input.Vec(i))
+		copy(layer.lastOutput.Sample(i), softmax.RawVector().Data)
+	}
+	return layer.lastOutput
+}
+
+// Jacobian-vector product: s * (g - s . g)
+func (layer *Softmax) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	n, c, h, w := inGrads.Dims()
+	retVal := tensor.New(n, c, h, w, nil)
+	for sample := range n {
+		softmax := layer.lastOutput.Vec(sample)
+		grads := inGrads.Vec(sample)
+		dot := mat.Dot(softmax, grads)
+		retGrads := retVal.Sample(sample)
+		for i := range grads.Len() {
+			s := softmax.AtVec(i)
+			retGrads[i] = s * (grads.AtVec(i) - dot)
+		}
 	}
-	layer.lastOutput.ScaleVec(1./expSums, input)
-	return &layer.lastOutput
+	return retVal
 }
 
 func NewVReLU() VReLU {
@@ -99,16 +120,14 @@ func NewVReLU() VReLU {
 	}
 }
 
-func (layer *VReLU) Forward(input *mat.VecDense) *mat.VecDense {
-	layer.lastInput = *input
-	layer.lastOutput = *mat.NewVecDense(input.Len(), nil)
-	ApplyOnInputVec(layer.Vlambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *VReLU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	layer.lastOutput = ApplyOnInputVec(layer.Vlambda, input)
+	return layer.lastOutput
 }
 
-func (layer *VReLU) Backward(inGrads *mat.VecDense) *mat.VecDense {
-	retVal := BackwardApply(layer.VlambdaPrime, &layer.lastInput, inGrads)
-	return &retVal
+func (layer *VReLU) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return BackwardApply(layer.VlambdaPrime, layer.lastInput, inGrads)
 }
 
 func NewVLeakyReLU(alpha float64) VLeakyReLU {
@@ -131,16 +150,14 @@ func NewVLeakyReLU(alpha float64) VLeakyReLU {
 	}
 }
 
-func (layer *VLeakyReLU) Forward(input *mat.VecDense) *mat.VecDense {
-	layer.lastInput = *input
-	layer.lastOutput = *mat.NewVecDense(input.Len(), nil)
-	ApplyOnInputVec(layer.Vlambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *VLeakyReLU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	layer.lastOutput = ApplyOnInputVec(layer.Vlambda, input)
+	return layer.lastOutput
 }
 
-func (layer *VLeakyReLU) Backward(inGrads *mat.VecDense) *mat.VecDense {
-	retVal := BackwardApply(layer.VlambdaPrime, &layer.lastInput, inGrads)
-	return &retVal
+func (layer *VLeakyReLU) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return BackwardApply(layer.VlambdaPrime, layer.lastInput, inGrads)
 }
 
 func NewVELU(alpha ...float64) VELU {
@@ -169,16 +186,14 @@ func NewVELU(alpha ...float64) VELU {
 	}
 }
 
-func (layer *VELU) Forward(input *mat.VecDense) *mat.VecDense {
-	layer.lastInput = *input
-	layer.lastOutput = *mat.NewVecDense(input.Len(), nil)
-	ApplyOnInputVec(layer.Vlambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *VELU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	layer.lastOutput = ApplyOnInputVec(layer.Vlambda, input)
+	return layer.lastOutput
 }
 
-func (layer *VELU) Backward(inGrads *mat.VecDense) *mat.VecDense {
-	retVal := BackwardApply(layer.VlambdaPrime, &layer.lastInput, inGrads)
-	return &retVal
+func (layer *VELU) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return BackwardApply(layer.VlambdaPrime, layer.lastInput, inGrads)
 }
 
 func NewVSigmoid() VSigmoid {
@@ -196,16 +211,14 @@ func NewVSigmoid() VSigmoid {
 	}
 }
 
-func (layer *VSigmoid) Forward(input *mat.VecDense) *mat.VecDense {
-	layer.lastInput = *input
-	layer.lastOutput = *mat.NewVecDense(input.Len(), nil)
-	ApplyOnInputVec(layer.Vlambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *VSigmoid) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	layer.lastOutput = ApplyOnInputVec(layer.Vlambda, input)
+	return layer.lastOutput
 }
 
-func (layer *VSigmoid) Backward(inGrads *mat.VecDense) *mat.VecDense {
-	retVal := BackwardApply(layer.VlambdaPrime, &layer.lastInput, inGrads)
-	return &retVal
+func (layer *VSigmoid) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return BackwardApply(layer.VlambdaPrime, layer.lastInput, inGrads)
 }
 
 func NewVTanh() VTanh {
@@ -223,14 +236,12 @@ func NewVTanh() VTanh {
 	}
 }
 
-func (layer *VTanh) Forward(input *mat.VecDense) *mat.VecDense {
-	layer.lastInput = *input
-	layer.lastOutput = *mat.NewVecDense(input.Len(), nil)
-	ApplyOnInputVec(layer.Vlambda, input, &layer.lastOutput)
-	return &layer.lastOutput
+func (layer *VTanh) Forward(input *tensor.Tensor) *tensor.Tensor {
+	layer.lastInput = input
+	layer.lastOutput = ApplyOnInputVec(layer.Vlambda, input)
+	return layer.lastOutput
 }
 
-func (layer *VTanh) Backward(inGrads *mat.VecDense) *mat.VecDense {
-	retVal := BackwardApply(layer.VlambdaPrime, &layer.lastInput, inGrads)
-	return &retVal
+func (layer *VTanh) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	return BackwardApply(layer.VlambdaPrime, layer.lastInput, inGrads)
 }
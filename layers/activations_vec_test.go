@@ -9,13 +9,14 @@ import (
 
 	"DoodleGan/functools"
 	"DoodleGan/layers"
+	"DoodleGan/tensor"
 )
 
 func TestSoftmax(t *testing.T) {
 	layer := layers.NewSoftmax()
-	outputVec := layer.Forward(mat.NewVecDense(4, []float64{
+	outputVec := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{
 		1.1, 2.2, 0.2, -1.7,
-	}))
+	}))).Vec(0)
 	output := outputVec.RawVector().Data
 	target := []float64{
 		0.224, 0.672, 0.091, 0.013,
@@ -25,14 +26,64 @@ func TestSoftmax(t *testing.T) {
 	}
 }
 
+func TestSoftmax_Stable(t *testing.T) {
+	layer := layers.NewSoftmax()
+	input := mat.NewVecDense(3, []float64{1000, 1001, 1002})
+	outputVec := layer.Forward(tensor.FromVec(input)).Vec(0)
+	output := outputVec.RawVector().Data
+	target := []float64{0.09003, 0.24473, 0.66524}
+	if !functools.IsEqual(&target, &output, 0.0001) {
+		fmt.Println(output)
+		t.Fail()
+	}
+	targetInput := mat.NewVecDense(3, []float64{1000, 1001, 1002})
+	if !reflect.DeepEqual(input, targetInput) {
+		fmt.Println(input)
+		t.Fail()
+	}
+}
+
+// Every sample of a batch is normalized separately
+func TestSoftmax_Batch(t *testing.T) {
+	layer := layers.NewSoftmax()
+	output := layer.Forward(tensor.New(2, 1, 1, 4, []float64{
+		1.1, 2.2, 0.2, -1.7,
+		0, 0, 0, 0,
+	}))
+	result := output.RawData()
+	target := []float64{
+		0.224, 0.672, 0.091, 0.013,
+		0.25, 0.25, 0.25, 0.25,
+	}
+	if !functools.IsEqual(&target, &result, 0.001) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestSoftmax_Backward(t *testing.T) {
+	layer := layers.NewSoftmax()
+	layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{
+		1.1, 2.2, 0.2, -1.7,
+	})))
+	resultVec := layer.Backward(tensor.FromVec(mat.NewVecDense(4, []float64{0.5, -1, 0.2, 0}))).Vec(0)
+	result := resultVec.RawVector().Data
+	target := []float64{0.23299, -0.30781, 0.06745, 0.00737}
+	if !functools.IsEqual(&target, &result, 0.0001) {
+		fmt.Println(target)
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
 func TestVReLU(t *testing.T) {
 	layer := layers.NewVReLU()
-	output1 := layer.Forward(mat.NewVecDense(4, []float64{1, -1, -888, 0}))
+	output1 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{1, -1, -888, 0}))).Vec(0)
 	target1 := mat.NewVecDense(4, []float64{1, 0, 0, 0})
 	if !reflect.DeepEqual(output1, target1) {
 		t.Fail()
 	}
-	output2 := layer.Forward(mat.NewVecDense(4, []float64{-3, -4, -5, 1}))
+	output2 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-3, -4, -5, 1}))).Vec(0)
 	target2 := mat.NewVecDense(4, []float64{0, 0, 0, 1})
 	if !reflect.DeepEqual(output2, target2) {
 		t.Fail()
@@ -44,11 +95,11 @@ func TestVReLU_Backward(t *testing.T) {
 	input1 := *mat.NewVecDense(4, []float64{
 		1, -1, -888, 3,
 	})
-	layer.Forward(&input1)
+	layer.Forward(tensor.FromVec(&input1))
 	inGrads1 := *mat.NewVecDense(4, []float64{
 		1, 1, 1, 1,
 	})
-	output1 := layer.Backward(&inGrads1)
+	output1 := layer.Backward(tensor.FromVec(&inGrads1)).Vec(0)
 	target1 := []float64{
 		1, 0, 0, 1,
 	}
@@ -59,11 +110,11 @@ func TestVReLU_Backward(t *testing.T) {
 	}
 
 	input2 := *mat.NewVecDense(4, []float64{-3, -4, -5, 1})
-	layer.Forward(&input2)
+	layer.Forward(tensor.FromVec(&input2))
 	inGrads2 := *mat.NewVecDense(4, []float64{
 		1, 2, 3, 4,
 	})
-	output2 := layer.Backward(&inGrads2)
+	output2 := layer.Backward(tensor.FromVec(&inGrads2)).Vec(0)
 	target2 := []float64{
 		0, 0, 0, 4,
 	}
@@ -76,12 +127,12 @@ func TestVReLU_Backward(t *testing.T) {
 
 func TestVLeakyReLU(t *testing.T) {
 	layer := layers.NewVLeakyReLU(0.1)
-	output1 := layer.Forward(mat.NewVecDense(4, []float64{1, -1, -888, 0}))
+	output1 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{1, -1, -888, 0}))).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{1, -1.0 * 0.1, -888.0 * 0.1, 0})
 	if !functools.IsEqualVec(output1, &target1, 0.001) {
 		t.Fail()
 	}
-	output2 := layer.Forward(mat.NewVecDense(4, []float64{-3, -4, -5, 2}))
+	output2 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-3, -4, -5, 2}))).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{-3.0 * 0.1, -4.0 * 0.1, -5.0 * 0.1, 2})
 	if !functools.IsEqualVec(output2, &target2, 0.001) {
 		t.Fail()
@@ -93,11 +144,11 @@ func TestVLeakyReLU_Backward(t *testing.T) {
 	input1 := *mat.NewVecDense(4, []float64{
 		1, -1, -888, 3,
 	})
-	layer.Forward(&input1)
+	layer.Forward(tensor.FromVec(&input1))
 	inGrads1 := *mat.NewVecDense(4, []float64{
 		1, 1, 1, 1,
 	})
-	output1 := layer.Backward(&inGrads1)
+	output1 := layer.Backward(tensor.FromVec(&inGrads1)).Vec(0)
 	target1 := []float64{
 		1, 0.25, 0.25, 1,
 	}
@@ -107,11 +158,11 @@ func TestVLeakyReLU_Backward(t *testing.T) {
 		t.Fail()
 	}
 	input2 := *mat.NewVecDense(4, []float64{-3, -4, -5, 1})
-	layer.Forward(&input2)
+	layer.Forward(tensor.FromVec(&input2))
 	inGrads2 := *mat.NewVecDense(4, []float64{
 		1, 2, 3, 4,
 	})
-	output2 := layer.Backward(&inGrads2)
+	output2 := layer.Backward(tensor.FromVec(&inGrads2)).Vec(0)
 	target2 := []float64{
 		0.25, 0.50, 0.75, 4,
 	}
@@ -124,12 +175,12 @@ func TestVLeakyReLU_Backward(t *testing.T) {
 
 func TestVELU(t *testing.T) {
 	layer := layers.NewVELU(0.5)
-	output1 := layer.Forward(mat.NewVecDense(4, []float64{1, -1, 0, 10}))
+	output1 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{1, -1, 0, 10}))).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{1, -0.316060279, 0, 10})
 	if !functools.IsEqualVec(output1, &target1, 0.001) {
 		t.Fail()
 	}
-	output2 := layer.Forward(mat.NewVecDense(4, []float64{-2, 0.1, -10, -0.1}))
+	output2 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-2, 0.1, -10, -0.1}))).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{-0.432332358, 0.1, -0.4999773, -0.047581291})
 	if !functools.IsEqualVec(output2, &target2, 0.001) {
 		t.Fail()
@@ -141,11 +192,11 @@ func TestVELU_Backward(t *testing.T) {
 	input1 := *mat.NewVecDense(4, []float64{
 		1, -1, 0, 10,
 	})
-	layer.Forward(&input1)
+	layer.Forward(tensor.FromVec(&input1))
 	inGrads1 := *mat.NewVecDense(4, []float64{
 		1, 1, 1, 1,
 	})
-	output1 := layer.Backward(&inGrads1)
+	output1 := layer.Backward(tensor.FromVec(&inGrads1)).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{
 		1, 0.09196986, 0.25, 1,
 	})
@@ -155,11 +206,11 @@ func TestVELU_Backward(t *testing.T) {
 		t.Fail()
 	}
 	input2 := *mat.NewVecDense(4, []float64{-3, -4, -5, 1})
-	layer.Forward(&input2)
+	layer.Forward(tensor.FromVec(&input2))
 	inGrads2 := *mat.NewVecDense(4, []float64{
 		1, 2, 3, 4,
 	})
-	output2 := layer.Backward(&inGrads2)
+	output2 := layer.Backward(tensor.FromVec(&inGrads2)).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{
 		0.012446767 * 1, 0.00457891 * 2, 0.001684487 * 3, 1 * 4,
 	})
@@ -172,12 +223,12 @@ func TestVELU_Backward(t *testing.T) {
 
 func TestVSigmoid(t *testing.T) {
 	layer := layers.NewVSigmoid()
-	output1 := layer.Forward(mat.NewVecDense(4, []float64{-2, -1, 1, 2}))
+	output1 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-2, -1, 1, 2}))).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{0.119202922, 0.268941421, 0.731058579, 0.880797078})
 	if !functools.IsEqualVec(output1, &target1, 0.001) {
 		t.Fail()
 	}
-	output2 := layer.Forward(mat.NewVecDense(4, []float64{-2000, 2000, 0, 5.999}))
+	output2 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-2000, 2000, 0, 5.999}))).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{0, 1, 0.5, 0.997524909})
 	if !functools.IsEqualVec(output2, &target2, 0.001) {
 		t.Fail()
@@ -189,11 +240,11 @@ func TestVSigmoid_Backward(t *testing.T) {
 	input1 := *mat.NewVecDense(4, []float64{
 		-2, -1, 1, 2,
 	})
-	layer.Forward(&input1)
+	layer.Forward(tensor.FromVec(&input1))
 	inGrads1 := *mat.NewVecDense(4, []float64{
 		1, 1, 1, 1,
 	})
-	output1 := layer.Backward(&inGrads1)
+	output1 := layer.Backward(tensor.FromVec(&inGrads1)).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{
 		0.104993585, 0.196611933, 0.196611933, 0.104993585,
 	})
@@ -205,11 +256,11 @@ func TestVSigmoid_Backward(t *testing.T) {
 	input2 := *mat.NewVecDense(4, []float64{
 		-2000, 2000, 0, 5.999,
 	})
-	layer.Forward(&input2)
+	layer.Forward(tensor.FromVec(&input2))
 	inGrads2 := *mat.NewVecDense(4, []float64{
 		1, 2, 3, 4,
 	})
-	output2 := layer.Backward(&inGrads2)
+	output2 := layer.Backward(tensor.FromVec(&inGrads2)).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{
 		0, 0, 0.75, 0.002468965 * 4,
 	})
@@ -222,12 +273,12 @@ func TestVSigmoid_Backward(t *testing.T) {
 
 func TestVTanh(t *testing.T) {
 	layer := layers.NewVTanh()
-	output1 := layer.Forward(mat.NewVecDense(4, []float64{-2, -1, 1, 2}))
+	output1 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-2, -1, 1, 2}))).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{-0.96402758007, -0.76159415595, 0.76159415595, 0.96402758007})
 	if !functools.IsEqualVec(output1, &target1, 0.001) {
 		t.Fail()
 	}
-	output2 := layer.Forward(mat.NewVecDense(4, []float64{-20, 2000, 0, 5.999}))
+	output2 := layer.Forward(tensor.FromVec(mat.NewVecDense(4, []float64{-20, 2000, 0, 5.999}))).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{-1, 1, 0, 0.99998768705})
 	if !functools.IsEqualVec(output2, &target2, 0.001) {
 		t.Fail()
@@ -239,11 +290,11 @@ func TestVTanh_Backward(t *testing.T) {
 	input1 := *mat.NewVecDense(4, []float64{
 		-2, -1, 1, 2,
 	})
-	layer.Forward(&input1)
+	layer.Forward(tensor.FromVec(&input1))
 	inGrads1 := *mat.NewVecDense(4, []float64{
 		1, 1, 1, 1,
 	})
-	output1 := layer.Backward(&inGrads1)
+	output1 := layer.Backward(tensor.FromVec(&inGrads1)).Vec(0)
 	target1 := *mat.NewVecDense(4, []float64{
 		0.070650825, 0.419974342, 0.419974342, 0.070650825,
 	})
@@ -255,11 +306,11 @@ func TestVTanh_Backward(t *testing.T) {
 	input2 := *mat.NewVecDense(4, []float64{
 		-2000, 2000, 0, 5.999,
 	})
-	layer.Forward(&input2)
+	layer.Forward(tensor.FromVec(&input2))
 	inGrads2 := *mat.NewVecDense(4, []float64{
 		1, 2, 3, 4,
 	})
-	output2 := layer.Backward(&inGrads2)
+	output2 := layer.Backward(tensor.FromVec(&inGrads2)).Vec(0)
 	target2 := *mat.NewVecDense(4, []float64{
 		0, 0, 3, 0.000024626 * 4,
 	})
@@ -0,0 +1,218 @@
+package layers
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"DoodleGan/tensor"
+)
+
+// Normalizes every feature over the batch, then scales it by gamma and shifts by beta.
+// In inference mode running mean and variance are used instead of batch statistics.
+type BatchNorm1D struct {
+	nFeatures int
+	momentum  float64 // running = momentum * running + (1 - momentum) * batch
+	eps       float64
+	training  bool
+
+	gamma       []float64
+	beta        []float64
+	runningMean []float64
+	runningVar  []float64
+
+	SavedData
+	SavedGrads
+	normalized *tensor.Tensor
+	invStd     []float64
+	gammaGrads *tensor.Tensor
+	betaGrads  *tensor.Tensor
+}
+
+func NewBatchNorm1D(nFeatures int, momentum, eps float64) BatchNorm1D {
+	if nFeatures < 1 {
+		mess := fmt.Sprintf(
+			"NewBatchNorm1D fail:\n\tNumber of features must be positive, have: %d",
+			nFeatures,
+		)
+		panic(mess)
+	}
+	if momentum < 0.0 || momentum >= 1.0 || eps <= 0.0 {
+		mess := fmt.Sprintf(
+			"NewBatchNorm1D fail:\n\tmomentum (%f) must be in range [0, 1) and eps (%f) positive",
+			momentum,
+			eps,
+		)
+		panic(mess)
+	}
+	gamma := make([]float64, nFeatures)
+	runningVar := make([]float64, nFeatures)
+	for i := range nFeatures {
+		gamma[i] = 1.0
+		runningVar[i] = 1.0
+	}
+	return BatchNorm1D{
+		nFeatures:   nFeatures,
+		momentum:    momentum,
+		eps:         eps,
+		training:    true,
+		gamma:       gamma,
+		beta:        make([]float64, nFeatures),
+		runningMean: make([]float64, nFeatures),
+		runningVar:  runningVar,
+		invStd:      make([]float64, nFeatures),
+		gammaGrads:  tensor.New(1, 1, 1, nFeatures, nil),
+		betaGrads:   tensor.New(1, 1, 1, nFeatures, nil),
+	}
+}
+
+func (layer *BatchNorm1D) SetTraining(training bool) {
+	layer.training = training
+}
+
+func (layer *BatchNorm1D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	if input.SampleLen() != layer.nFeatures {
+		mess := fmt.Sprintf(
+			"BatchNorm1D forward fail:\n\tSample length (%d) doesn't match number of features (%d)",
+			input.SampleLen(),
+			layer.nFeatures,
+		)
+		panic(mess)
+	}
+	n := input.Len()
+	if layer.training && n < 2 {
+		panic("BatchNorm1D forward fail:\n\tBatch size must be at least 2 in training mode, variance of one sample is 0")
+	}
+	layer.lastInput = input
+	data := input.RawData()
+	mean, variance := layer.runningMean, layer.runningVar
+	if layer.training {
+		mean, variance = batchMeanVar(data, n, layer.nFeatures)
+		for j := range layer.nFeatures {
+			layer.runningMean[j] = layer.momentum*layer.runningMean[j] + (1.0-layer.momentum)*mean[j]
+			layer.runningVar[j] = layer.momentum*layer.runningVar[j] + (1.0-layer.momentum)*variance[j]
+		}
+	}
+	for j := range layer.nFeatures {
+		layer.invStd[j] = 1.0 / math.Sqrt(variance[j]+layer.eps)
+	}
+
+	_, c, h, w := input.Dims()
+	layer.normalized = tensor.New(n, c, h, w, nil)
+	layer.lastOutput = tensor.New(n, c, h, w, nil)
+	normalized := layer.normalized.RawData()
+	output := layer.lastOutput.RawData()
+	for i, v := range data {
+		j := i % layer.nFeatures
+		normalized[i] = (v - mean[j]) * layer.invStd[j]
+		output[i] = layer.gamma[j]*normalized[i] + layer.beta[j]
+	}
+	return layer.lastOutput
+}
+
+// Gamma and beta gradients are summed over the batch
+func (layer *BatchNorm1D) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
+	n, c, h, w := inGrads.Dims()
+	grads := inGrads.RawData()
+	normalized := layer.normalized.RawData()
+	gammaGrads := layer.gammaGrads.RawData()
+	betaGrads := layer.betaGrads.RawData()
+	clear(gammaGrads)
+	clear(betaGrads)
+	for i, g := range grads {
+		j := i % layer.nFeatures
+		gammaGrads[j] += g * normalized[i]
+		betaGrads[j] += g
+	}
+
+	layer.lastOutGrads = tensor.New(n, c, h, w, nil)
+	outGrads := layer.lastOutGrads.RawData()
+	nFloat := float64(n)
+	for i, g := range grads {
+		j := i % layer.nFeatures
+		scale := layer.gamma[j] * layer.invStd[j]
+		if layer.training {
+			outGrads[i] = scale / nFloat * (nFloat*g - betaGrads[j] - normalized[i]*gammaGrads[j])
+		} else {
+			outGrads[i] = scale * g
+		}
+	}
+	return layer.lastOutGrads
+}
+
+func (layer *BatchNorm1D) GetWeightsGrads() *tensor.Tensor {
+	return layer.gammaGrads
+}
+
+func (layer *BatchNorm1D) GetBiasGrads() *tensor.Tensor {
+	return layer.betaGrads
+}
+
+func (layer *BatchNorm1D) Params() []tensor.Param {
+	return []tensor.Param{
+		{Name: "gamma", Value: tensor.New(1, 1, 1, layer.nFeatures, layer.gamma), Grad: layer.gammaGrads},
+		{Name: "beta", Value: tensor.New(1, 1, 1, layer.nFeatures, layer.beta), Grad: layer.betaGrads},
+	}
+}
+
+func (layer *BatchNorm1D) LoadGamma(gamma *[]float64) {
+	checkFeaturesLength("LoadGamma", gamma, layer.nFeatures)
+	layer.gamma = slices.Clone(*gamma)
+}
+
+func (layer *BatchNorm1D) LoadBeta(beta *[]float64) {
+	checkFeaturesLength("LoadBeta", beta, layer.nFeatures)
+	layer.beta = slices.Clone(*beta)
+}
+
+func (layer *BatchNorm1D) LoadRunningStats(mean, variance *[]float64) {
+	checkFeaturesLength("LoadRunningStats", mean, layer.nFeatures)
+	checkFeaturesLength("LoadRunningStats", variance, layer.nFeatures)
+	layer.runningMean = slices.Clone(*mean)
+	layer.runningVar = slices.Clone(*variance)
+}
+
+func (layer *BatchNorm1D) GetGamma() *[]float64 {
+	return &layer.gamma
+}
+
+func (layer *BatchNorm1D) GetBeta() *[]float64 {
+	return &layer.beta
+}
+
+func (layer *BatchNorm1D) GetRunningStats() (*[]float64, *[]float64) {
+	return &layer.runningMean, &layer.runningVar
+}
+
+// Biased mean and variance of every feature over n samples
+func batchMeanVar(data []float64, n, nFeatures int) ([]float64, []float64) {
+	mean := make([]float64, nFeatures)
+	variance := make([]float64, nFeatures)
+	for i, v := range data {
+		mean[i%nFeatures] += v
+	}
+	for j := range nFeatures {
+		mean[j] /= float64(n)
+	}
+	for i, v := range data {
+		d := v - mean[i%nFeatures]
+		variance[i%nFeatures] += d * d
+	}
+	for j := range nFeatures {
+		variance[j] /= float64(n)
+	}
+	return mean, variance
+}
+
+func checkFeaturesLength(funcName string, source *[]float64, nFeatures int) {
+	if len(*source) != nFeatures {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tSource length (%d) doesn't match number of features (%d)",
+			funcName,
+			len(*source),
+			nFeatures,
+		)
+		panic(mess)
+	}
+}
@@ -0,0 +1,155 @@
+package layers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/tensor"
+)
+
+func TestBatchNorm1D_Forward(t *testing.T) {
+	layer := layers.NewBatchNorm1D(2, 0.9, 1e-12)
+	gamma := []float64{2, 1}
+	beta := []float64{0.5, -1}
+	layer.LoadGamma(&gamma)
+	layer.LoadBeta(&beta)
+	input := tensor.New(2, 1, 1, 2, []float64{
+		1, 2,
+		3, 6,
+	})
+	output := layer.Forward(input).RawData()
+	target := []float64{
+		-1.5, -2,
+		2.5, 0,
+	}
+	if !functools.IsEqual(&target, &output, 1e-9) {
+		fmt.Println(output)
+		t.Fail()
+	}
+
+	mean, variance := layer.GetRunningStats()
+	targetMean := []float64{0.2, 0.4}
+	targetVar := []float64{1, 1.3}
+	if !functools.IsEqual(&targetMean, mean, 1e-9) || !functools.IsEqual(&targetVar, variance, 1e-9) {
+		fmt.Println(*mean, *variance)
+		t.Fail()
+	}
+}
+
+// Inference uses running statistics, so samples don't affect each other
+func TestBatchNorm1D_Eval(t *testing.T) {
+	layer := layers.NewBatchNorm1D(2, 0.9, 1e-12)
+	mean := []float64{1, -2}
+	variance := []float64{4, 0.25}
+	layer.LoadRunningStats(&mean, &variance)
+	layer.SetTraining(false)
+	output := layer.Forward(tensor.New(1, 1, 1, 2, []float64{3, -1})).RawData()
+	target := []float64{1, 2}
+	if !functools.IsEqual(&target, &output, 1e-9) {
+		fmt.Println(output)
+		t.Fail()
+	}
+
+	outGrads := layer.Backward(tensor.New(1, 1, 1, 2, []float64{1, 1})).RawData()
+	targetOutGrads := []float64{0.5, 2}
+	if !functools.IsEqual(&targetOutGrads, &outGrads, 1e-9) {
+		fmt.Println(outGrads)
+		t.Fail()
+	}
+}
+
+// Backward compared with numerical gradient of sum(weights * output)
+func TestBatchNorm1D_Backward(t *testing.T) {
+	gamma := []float64{1.5, -0.5}
+	beta := []float64{0.2, 0.1}
+	newLayer := func() layers.BatchNorm1D {
+		layer := layers.NewBatchNorm1D(2, 0.9, 1e-5)
+		layer.LoadGamma(&gamma)
+		layer.LoadBeta(&beta)
+		return layer
+	}
+	inputData := []float64{
+		0.5, -1,
+		2, 0.3,
+		-1.5, 1.2,
+	}
+	weights := []float64{
+		1, -2,
+		0.5, 1,
+		-1, 3,
+	}
+	lossAt := func(data []float64) float64 {
+		layer := newLayer()
+		output := layer.Forward(tensor.New(3, 1, 1, 2, data)).RawData()
+		retVal := 0.0
+		for i := range output {
+			retVal += weights[i] * output[i]
+		}
+		return retVal
+	}
+
+	layer := newLayer()
+	layer.Forward(tensor.New(3, 1, 1, 2, inputData))
+	outGrads := layer.Backward(tensor.New(3, 1, 1, 2, weights)).RawData()
+
+	h := 1e-6
+	numGrads := make([]float64, len(inputData))
+	for i := range inputData {
+		plus := append([]float64{}, inputData...)
+		minus := append([]float64{}, inputData...)
+		plus[i] += h
+		minus[i] -= h
+		numGrads[i] = (lossAt(plus) - lossAt(minus)) / (2 * h)
+	}
+	if !functools.IsEqual(&numGrads, &outGrads, 1e-5) {
+		fmt.Println(numGrads)
+		fmt.Println(outGrads)
+		t.Fail()
+	}
+
+	gammaGrads := layer.GetWeightsGrads().RawData()
+	betaGrads := layer.GetBiasGrads().RawData()
+	targetBetaGrads := []float64{0.5, 2}
+	if !functools.IsEqual(&targetBetaGrads, &betaGrads, 1e-9) {
+		fmt.Println(betaGrads)
+		t.Fail()
+	}
+	if len(gammaGrads) != 2 {
+		fmt.Println(gammaGrads)
+		t.Fail()
+	}
+}
+
+// Training updates only the layer copies of loaded values
+func TestBatchNorm1D_Load_Copies(t *testing.T) {
+	layer := layers.NewBatchNorm1D(2, 0.9, 1e-12)
+	gamma := []float64{2, 1}
+	beta := []float64{0.5, -1}
+	mean := []float64{0, 0}
+	variance := []float64{1, 1}
+	layer.LoadGamma(&gamma)
+	layer.LoadBeta(&beta)
+	layer.LoadRunningStats(&mean, &variance)
+	layer.Forward(tensor.New(2, 1, 1, 2, []float64{1, 2, 3, 6}))
+	(*layer.GetGamma())[0] = 5
+	(*layer.GetBeta())[0] = 5
+
+	loaded := []float64{gamma[0], gamma[1], beta[0], beta[1], mean[0], mean[1], variance[0], variance[1]}
+	target := []float64{2, 1, 0.5, -1, 0, 0, 1, 1}
+	if !functools.IsEqual(&target, &loaded, 1e-12) {
+		fmt.Println(loaded)
+		t.Fail()
+	}
+}
+
+func TestBatchNorm1D_Train_single_sample_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := layers.NewBatchNorm1D(2, 0.9, 1e-12)
+	layer.Forward(tensor.New(1, 1, 1, 2, []float64{1, 2}))
+}
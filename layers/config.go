@@ -0,0 +1,126 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"DoodleGan/regularizers"
+)
+
+// Serializable description of a layer: type, hyperparameters and trained values
+type LayerConfig struct {
+	Type        string    `json:"type"`
+	NInputs     int       `json:"n_inputs,omitempty"`
+	NNeurons    int       `json:"n_neurons,omitempty"`
+	NFeatures   int       `json:"n_features,omitempty"`
+	Alpha       float64   `json:"alpha,omitempty"`
+	Rate        float64   `json:"rate,omitempty"`
+	Momentum    float64   `json:"momentum,omitempty"`
+	Eps         float64   `json:"eps,omitempty"`
+	Weights     []float64 `json:"weights,omitempty"`
+	Bias        []float64 `json:"bias,omitempty"`
+	Gamma       []float64 `json:"gamma,omitempty"`
+	Beta        []float64 `json:"beta,omitempty"`
+	RunningMean []float64 `json:"running_mean,omitempty"`
+	RunningVar  []float64 `json:"running_var,omitempty"`
+
+	Regularizers []regularizers.Config `json:"regularizers,omitempty"`
+}
+
+func GetLayerConfig(layer Layer) (LayerConfig, error) {
+	switch l := layer.(type) {
+	case *DenseLayer:
+		regularizerConfigs, err := regularizers.GetConfigs(l.weightRegularizers)
+		if err != nil {
+			return LayerConfig{}, err
+		}
+		return LayerConfig{
+			Type:         "Dense",
+			NInputs:      l.nInputs,
+			NNeurons:     l.nNeurons,
+			Weights:      slices.Clone(l.weights.RawMatrix().Data),
+			Bias:         slices.Clone(l.bias.RawVector().Data),
+			Regularizers: regularizerConfigs,
+		}, nil
+	case *BatchNorm1D:
+		return LayerConfig{
+			Type:        "BatchNorm1D",
+			NFeatures:   l.nFeatures,
+			Momentum:    l.momentum,
+			Eps:         l.eps,
+			Gamma:       slices.Clone(l.gamma),
+			Beta:        slices.Clone(l.beta),
+			RunningMean: slices.Clone(l.runningMean),
+			RunningVar:  slices.Clone(l.runningVar),
+		}, nil
+	case *Dropout:
+		return LayerConfig{Type: "Dropout", Rate: l.rate}, nil
+	case *Softmax:
+		return LayerConfig{Type: "Softmax"}, nil
+	case *VReLU:
+		return LayerConfig{Type: "ReLU"}, nil
+	case *VLeakyReLU:
+		return LayerConfig{Type: "LeakyReLU", Alpha: l.alpha}, nil
+	case *VELU:
+		return LayerConfig{Type: "ELU", Alpha: l.alpha}, nil
+	case *VSigmoid:
+		return LayerConfig{Type: "Sigmoid"}, nil
+	case *VTanh:
+		return LayerConfig{Type: "Tanh"}, nil
+	}
+	return LayerConfig{}, fmt.Errorf("GetLayerConfig fail: unsupported layer type %T", layer)
+}
+
+// rng is used by dropout layers
+func NewLayerFromConfig(config *LayerConfig, rng *rand.Rand) (Layer, error) {
+	switch config.Type {
+	case "Dense":
+		weightRegularizers, err := regularizers.NewFromConfigs(config.Regularizers)
+		if err != nil {
+			return nil, err
+		}
+		layer := NewDenseLayer(config.NInputs, config.NNeurons, weightRegularizers...)
+		if len(config.Weights) > 0 {
+			layer.LoadWeights(&config.Weights)
+		}
+		if len(config.Bias) > 0 {
+			layer.LoadBias(&config.Bias)
+		}
+		return &layer, nil
+	case "BatchNorm1D":
+		layer := NewBatchNorm1D(config.NFeatures, config.Momentum, config.Eps)
+		if len(config.Gamma) > 0 {
+			layer.LoadGamma(&config.Gamma)
+		}
+		if len(config.Beta) > 0 {
+			layer.LoadBeta(&config.Beta)
+		}
+		if len(config.RunningMean) > 0 && len(config.RunningVar) > 0 {
+			layer.LoadRunningStats(&config.RunningMean, &config.RunningVar)
+		}
+		return &layer, nil
+	case "Dropout":
+		layer := NewDropout(config.Rate, rng)
+		return &layer, nil
+	case "Softmax":
+		layer := NewSoftmax()
+		return &layer, nil
+	case "ReLU":
+		layer := NewVReLU()
+		return &layer, nil
+	case "LeakyReLU":
+		layer := NewVLeakyReLU(config.Alpha)
+		return &layer, nil
+	case "ELU":
+		layer := NewVELU(config.Alpha)
+		return &layer, nil
+	case "Sigmoid":
+		layer := NewVSigmoid()
+		return &layer, nil
+	case "Tanh":
+		layer := NewVTanh()
+		return &layer, nil
+	}
+	return nil, fmt.Errorf("NewLayerFromConfig fail: unknown layer type %q", config.Type)
+}
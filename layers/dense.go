@@ -5,6 +5,10 @@ import (
 	"math/rand"
 
 	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/initializers"
+	"DoodleGan/regularizers"
+	"DoodleGan/tensor"
 )
 
 type DenseLayer struct {
@@ -13,13 +17,17 @@ type DenseLayer struct {
 	weights  mat.Dense
 	bias     mat.VecDense
 
-	SavedDataVec
-	SavedGradsVec
-	weightGrads mat.Dense
+	weightRegularizers []regularizers.Regularizer
+
+	SavedData
+	SavedGrads
+	weightGrads *tensor.Tensor
+	biasGrads   *tensor.Tensor
 }
 
-func NewDenseLayer(nInputs, nNeurons int) DenseLayer {
-	if nInputs < 0 || nNeurons < 0 {
+// Regularizers penalize weights only, their penalties are summed
+func NewDenseLayer(nInputs, nNeurons int, weightRegularizers ...regularizers.Regularizer) DenseLayer {
+	if nInputs < 1 || nNeurons < 1 {
 		mess := fmt.Sprintf(
 			"NewDenseLayer fail:\n\tNumber of inputs ans number of neurons must be positive,\n\thave: %d, %d",
 			nInputs,
@@ -29,9 +37,13 @@ func NewDenseLayer(nInputs, nNeurons int) DenseLayer {
 	}
 	bias := *mat.NewVecDense(nNeurons, nil)
 	return DenseLayer{
-		nInputs:  nInputs,
-		nNeurons: nNeurons,
-		bias:     bias,
+		nInputs:     nInputs,
+		nNeurons:    nNeurons,
+		bias:        bias,
+		weightGrads: tensor.New(1, 1, nNeurons, nInputs, nil),
+		biasGrads:   tensor.New(1, 1, 1, nNeurons, nil),
+
+		weightRegularizers: weightRegularizers,
 	}
 }
 
@@ -45,12 +57,17 @@ func (layer *DenseLayer) InitFilterRandom(minRange, maxRange float64) {
 		randWeights[i] = rand.Float64()*(maxRange-minRange) + minRange
 	}
 	layer.weights = *mat.NewDense(
-		layer.nInputs,
 		layer.nNeurons,
+		layer.nInputs,
 		randWeights,
 	)
 }
 
+func (layer *DenseLayer) InitWeights(initializer initializers.Initializer) {
+	weights := initializer.Initialize(initializers.DenseShape(layer.nInputs, layer.nNeurons))
+	layer.LoadWeights(&weights)
+}
+
 func (layer *DenseLayer) LoadWeights(source *[]float64) {
 	if len(*source) != layer.nInputs*layer.nNeurons {
 		mess := fmt.Sprintf(
@@ -80,43 +97,86 @@ func (layer *DenseLayer) LoadBias(bias *[]float64) {
 	layer.bias = *mat.NewVecDense(layer.nNeurons, *bias)
 }
 
-func (layer *DenseLayer) Forward(input *mat.VecDense) *mat.VecDense {
-	layer.lastInput = *input
-	layer.lastOutput.MulVec(&layer.weights, input)
-	layer.lastOutput.AddVec(&layer.lastOutput, &layer.bias)
-	return &layer.lastOutput
+func (layer *DenseLayer) Forward(input *tensor.Tensor) *tensor.Tensor {
+	if input.SampleLen() != layer.nInputs {
+		mess := fmt.Sprintf(
+			"Dense forward fail:\n\tSample length (%d) doesn't match number of inputs (%d)",
+			input.SampleLen(),
+			layer.nInputs,
+		)
+		panic(mess)
+	}
+	layer.lastInput = input
+	layer.lastOutput = tensor.New(input.Len(), 1, 1, layer.nNeurons, nil)
+	output := layer.lastOutput.BatchMat()
+	output.Mul(input.BatchMat(), layer.weights.T())
+	for n := range input.Len() {
+		row := output.RawRowView(n)
+		for i := range row {
+			row[i] += layer.bias.AtVec(i)
+		}
+	}
+	return layer.lastOutput
 }
 
-func (layer *DenseLayer) Backward(inGrads *mat.VecDense) *mat.VecDense {
-	layer.lastInGrads = *inGrads
-	layer.weightGrads.Mul(inGrads, layer.lastInput.T())
+// Weight and bias gradients are summed over the batch
+func (layer *DenseLayer) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	layer.lastInGrads = inGrads
+	grads := inGrads.BatchMat()
+	weightGrads := layer.weightGrads.Mat(0, 0)
+	weightGrads.Mul(grads.T(), layer.lastInput.BatchMat())
+	for _, regularizer := range layer.weightRegularizers {
+		regularizer.AddGrads(layer.weights.RawMatrix().Data, layer.weightGrads.RawData())
+	}
+
+	biasGrads := layer.biasGrads.RawData()
+	clear(biasGrads)
+	for n := range inGrads.Len() {
+		for i, g := range inGrads.Sample(n) {
+			biasGrads[i] += g
+		}
+	}
 
-	var result mat.VecDense
-	result.MulVec(layer.weights.T(), inGrads)
-	layer.lastOutGrads = result
-	return &layer.lastOutGrads
+	n, c, h, w := layer.lastInput.Dims()
+	layer.lastOutGrads = tensor.New(n, c, h, w, nil)
+	outGrads := layer.lastOutGrads.BatchMat()
+	outGrads.Mul(grads, &layer.weights)
+	return layer.lastOutGrads
 }
 
-func (layer *DenseLayer) GetOutWeightsGrads() *mat.Dense {
-	return &layer.weightGrads
+func (layer *DenseLayer) GetWeightsGrads() *tensor.Tensor {
+	return layer.weightGrads
 }
 
-func (layer *DenseLayer) GetOutBiasGrads() *mat.VecDense {
-	return &layer.lastInGrads
+func (layer *DenseLayer) GetBiasGrads() *tensor.Tensor {
+	return layer.biasGrads
 }
 
-func (layer *DenseLayer) ApplyGrads(
-	learningRate *float64,
-	dWeightsGrads *mat.Dense,
-	dBiasGrad *mat.VecDense,
-) {
-	var scaledWeightGrads mat.Dense
-	scaledWeightGrads.Scale(*learningRate, &*dWeightsGrads)
-	layer.weights.Sub(&layer.weights, &scaledWeightGrads)
+func (layer *DenseLayer) Penalty() float64 {
+	penalty := 0.0
+	for _, regularizer := range layer.weightRegularizers {
+		penalty += regularizer.Penalty(layer.weights.RawMatrix().Data)
+	}
+	return penalty
+}
 
-	var scaledBiasGrads mat.VecDense
-	scaledBiasGrads.ScaleVec(*learningRate, dBiasGrad)
-	layer.bias.SubVec(&layer.bias, &scaledBiasGrads)
+// Weights must be initialized first, otherwise optimizer would update a detached buffer
+func (layer *DenseLayer) Params() []tensor.Param {
+	if layer.weights.IsEmpty() {
+		panic("Dense params fail:\n\tWeights aren't initialized, use InitFilterRandom, InitWeights or LoadWeights")
+	}
+	return []tensor.Param{
+		{
+			Name:  "weights",
+			Value: tensor.New(1, 1, layer.nNeurons, layer.nInputs, layer.weights.RawMatrix().Data),
+			Grad:  layer.weightGrads,
+		},
+		{
+			Name:  "bias",
+			Value: tensor.New(1, 1, 1, layer.nNeurons, layer.bias.RawVector().Data),
+			Grad:  layer.biasGrads,
+		},
+	}
 }
 
 func (layer *DenseLayer) GetWeightsData() []float64 {
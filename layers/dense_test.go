@@ -2,13 +2,18 @@ package layers_test
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
 	"testing"
 
 	"gonum.org/v1/gonum/mat"
 
 	"DoodleGan/functools"
+	"DoodleGan/initializers"
 	"DoodleGan/layers"
+	"DoodleGan/optimizers"
+	"DoodleGan/regularizers"
+	"DoodleGan/tensor"
 )
 
 func TestDenseLayer_Forward_1(t *testing.T) {
@@ -16,7 +21,7 @@ func TestDenseLayer_Forward_1(t *testing.T) {
 	weights := []float64{2, 3}
 	layer.LoadWeights(&weights)
 	input := *mat.NewVecDense(1, []float64{2})
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromVec(&input)).Vec(0)
 	target := *mat.NewVecDense(2, []float64{4, 6})
 	if !functools.IsEqualVec(&target, output, 0.001) {
 		t.Fatal()
@@ -28,7 +33,7 @@ func TestDenseLayer_Forward_2(t *testing.T) {
 	weights := []float64{-1, 2, 4}
 	layer.LoadWeights(&weights)
 	input := *mat.NewVecDense(3, []float64{-4, 8.5, 4})
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromVec(&input)).Vec(0)
 	target := *mat.NewVecDense(1, []float64{4 + 17 + 16})
 	if !functools.IsEqualVec(&target, output, 0.001) {
 		t.Fatal()
@@ -42,7 +47,7 @@ func TestDenseLayer_Forward_3(t *testing.T) {
 	bias := []float64{1}
 	layer.LoadBias(&bias)
 	input := *mat.NewVecDense(3, []float64{-4, 8.5, 4})
-	output := layer.Forward(&input)
+	output := layer.Forward(tensor.FromVec(&input)).Vec(0)
 	target := *mat.NewVecDense(1, []float64{4 + 17 + 16 + 1})
 	if !functools.IsEqualVec(&target, output, 0.001) {
 		t.Fatal()
@@ -65,7 +70,7 @@ func TestDenseLayer_Forward_4(t *testing.T) {
 	input := mat.NewVecDense(3, []float64{
 		1, 2, 3,
 	})
-	output := layer.Forward(input)
+	output := layer.Forward(tensor.FromVec(input)).Vec(0)
 	target := []float64{
 		12.5, 2.5, 1, 2,
 	}
@@ -90,11 +95,11 @@ func TestDenseLayer_Backward_1(t *testing.T) {
 	input := mat.NewVecDense(2, []float64{
 		0.5, -0.5,
 	})
-	layer.Forward(input)
+	layer.Forward(tensor.FromVec(input))
 	inGrads := []float64{
 		4,
 	}
-	outGrads := layer.Backward(mat.NewVecDense(1, inGrads))
+	outGrads := layer.Backward(tensor.FromVec(mat.NewVecDense(1, inGrads))).Vec(0)
 	targetWeightsGrads := mat.NewDense(1, 2, []float64{
 		2, -2,
 	})
@@ -110,14 +115,14 @@ func TestDenseLayer_Backward_1(t *testing.T) {
 		fmt.Println(outGrads)
 		t.Fatal()
 	}
-	if !reflect.DeepEqual(targetWeightsGrads, layer.GetOutWeightsGrads()) {
+	if !reflect.DeepEqual(targetWeightsGrads, layer.GetWeightsGrads().Mat(0, 0)) {
 		fmt.Println(targetWeightsGrads)
-		fmt.Println(layer.GetOutWeightsGrads())
+		fmt.Println(layer.GetWeightsGrads().Mat(0, 0))
 		t.Fatal()
 	}
-	if !reflect.DeepEqual(targetBiasGrads, layer.GetOutBiasGrads()) {
+	if !reflect.DeepEqual(targetBiasGrads, layer.GetBiasGrads().Vec(0)) {
 		fmt.Println(targetBiasGrads)
-		fmt.Println(layer.GetOutBiasGrads())
+		fmt.Println(layer.GetBiasGrads().Vec(0))
 		t.Fatal()
 	}
 }
@@ -136,10 +141,10 @@ func TestDenseLayer_Backward_2(t *testing.T) {
 	input := mat.NewVecDense(3, []float64{
 		0.5, -0.5, 1,
 	})
-	layer.Forward(input)
-	outGrads := layer.Backward(mat.NewVecDense(2, []float64{
+	layer.Forward(tensor.FromVec(input))
+	outGrads := layer.Backward(tensor.FromVec(mat.NewVecDense(2, []float64{
 		4, -2,
-	}))
+	}))).Vec(0)
 	targetWeightsGrads := mat.NewDense(2, 3, []float64{
 		2, -2, 4,
 		-1, 1, -2,
@@ -156,21 +161,21 @@ func TestDenseLayer_Backward_2(t *testing.T) {
 		fmt.Println(outGrads)
 		t.Fatal()
 	}
-	if !reflect.DeepEqual(targetWeightsGrads, layer.GetOutWeightsGrads()) {
+	if !reflect.DeepEqual(targetWeightsGrads, layer.GetWeightsGrads().Mat(0, 0)) {
 		fmt.Println("--WEIGHT GRADS--")
 		fmt.Println(targetWeightsGrads)
-		fmt.Println(layer.GetOutWeightsGrads())
+		fmt.Println(layer.GetWeightsGrads().Mat(0, 0))
 		t.Fatal()
 	}
-	if !reflect.DeepEqual(targetBiasGrads, layer.GetOutBiasGrads()) {
+	if !reflect.DeepEqual(targetBiasGrads, layer.GetBiasGrads().Vec(0)) {
 		fmt.Println("--BIAS GRADS--")
 		fmt.Println(targetBiasGrads)
-		fmt.Println(layer.GetOutBiasGrads())
+		fmt.Println(layer.GetBiasGrads().Vec(0))
 		t.Fatal()
 	}
 
-	learningRate := 0.5
-	layer.ApplyGrads(&learningRate, layer.GetOutWeightsGrads(), layer.GetOutBiasGrads())
+	optimizer := optimizers.NewSGD(0.5, 0.0)
+	optimizer.Step(layer.Params())
 	targetUpdatedWeights := []float64{
 		1, 2, -4,
 		0, 0.25, 4,
@@ -192,15 +197,15 @@ func TestDenseLayer_Backward_3(t *testing.T) {
 	input := *mat.NewVecDense(1, []float64{
 		4,
 	})
-	layer.Forward(&input)
+	layer.Forward(tensor.FromVec(&input))
 	bias := []float64{
 		1, 0,
 	}
 	layer.LoadBias(&bias)
 
-	outGrads := layer.Backward(mat.NewVecDense(2, []float64{
+	outGrads := layer.Backward(tensor.FromVec(mat.NewVecDense(2, []float64{
 		3, -2,
-	}))
+	}))).Vec(0)
 	targetWeightsGrads := mat.NewDense(2, 1, []float64{
 		12, -8,
 	})
@@ -216,21 +221,21 @@ func TestDenseLayer_Backward_3(t *testing.T) {
 		fmt.Println(outGrads)
 		t.Fatal()
 	}
-	if !reflect.DeepEqual(targetWeightsGrads, layer.GetOutWeightsGrads()) {
+	if !reflect.DeepEqual(targetWeightsGrads, layer.GetWeightsGrads().Mat(0, 0)) {
 		fmt.Println("--WEIGHT GRADS--")
 		fmt.Println(targetWeightsGrads)
-		fmt.Println(layer.GetOutWeightsGrads())
+		fmt.Println(layer.GetWeightsGrads().Mat(0, 0))
 		t.Fatal()
 	}
-	if !reflect.DeepEqual(targetBiasGrads, layer.GetOutBiasGrads()) {
+	if !reflect.DeepEqual(targetBiasGrads, layer.GetBiasGrads().Vec(0)) {
 		fmt.Println("--BIAS GRADS--")
 		fmt.Println(targetBiasGrads)
-		fmt.Println(layer.GetOutBiasGrads())
+		fmt.Println(layer.GetBiasGrads().Vec(0))
 		t.Fatal()
 	}
 
-	learningRate := 0.25
-	layer.ApplyGrads(&learningRate, layer.GetOutWeightsGrads(), layer.GetOutBiasGrads())
+	optimizer := optimizers.NewSGD(0.25, 0.0)
+	optimizer.Step(layer.Params())
 	targetUpdatedWeights := []float64{
 		-3.5, 1.25,
 	}
@@ -241,3 +246,119 @@ func TestDenseLayer_Backward_3(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+// Gradients of a batch are sums of gradients of its samples
+func TestDenseLayer_Backward_Batch(t *testing.T) {
+	layer := layers.NewDenseLayer(2, 2)
+	weights := []float64{
+		1, -2,
+		0.5, 3,
+	}
+	layer.LoadWeights(&weights)
+	input := tensor.New(2, 1, 1, 2, []float64{
+		1, 2,
+		-1, 0.5,
+	})
+	output := layer.Forward(input)
+	targetOutput := []float64{
+		-3, 6.5,
+		-2, 1,
+	}
+	if !reflect.DeepEqual(targetOutput, output.RawData()) {
+		fmt.Println(output.RawData())
+		t.Fatal()
+	}
+
+	outGrads := layer.Backward(tensor.New(2, 1, 1, 2, []float64{
+		1, 0,
+		2, -1,
+	}))
+	targetOutGrads := []float64{
+		1, -2,
+		1.5, -7,
+	}
+	targetWeightsGrads := []float64{
+		-1, 3,
+		1, -0.5,
+	}
+	targetBiasGrads := []float64{3, -1}
+	if !reflect.DeepEqual(targetOutGrads, outGrads.RawData()) {
+		fmt.Println(outGrads.RawData())
+		t.Fail()
+	}
+	if !reflect.DeepEqual(targetWeightsGrads, layer.GetWeightsGrads().RawData()) {
+		fmt.Println(layer.GetWeightsGrads().RawData())
+		t.Fail()
+	}
+	if !reflect.DeepEqual(targetBiasGrads, layer.GetBiasGrads().RawData()) {
+		fmt.Println(layer.GetBiasGrads().RawData())
+		t.Fail()
+	}
+}
+
+func TestDenseLayer_InitFilterRandom(t *testing.T) {
+	layer := layers.NewDenseLayer(3, 2)
+	layer.InitFilterRandom(-1, 1)
+	output := layer.Forward(tensor.New(1, 1, 1, 3, []float64{1, 2, 3}))
+	if output.SampleLen() != 2 {
+		fmt.Println(output.Dims())
+		t.Fail()
+	}
+}
+
+func TestDenseLayer_InitWeights(t *testing.T) {
+	layer := layers.NewDenseLayer(3, 2)
+	initializer := initializers.NewGlorotUniform(rand.New(rand.NewSource(1)))
+	layer.InitWeights(&initializer)
+	output := layer.Forward(tensor.New(1, 1, 1, 3, []float64{1, 0, 0})).RawData()
+
+	// First input picks first column of nNeurons x nInputs weights
+	targetInitializer := initializers.NewGlorotUniform(rand.New(rand.NewSource(1)))
+	weights := targetInitializer.Initialize(initializers.DenseShape(3, 2))
+	target := []float64{weights[0], weights[3]}
+	if !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(target)
+		fmt.Println(output)
+		t.Fail()
+	}
+}
+
+func TestDenseLayer_Regularizers(t *testing.T) {
+	l2 := regularizers.NewL2(0.5)
+	l1 := regularizers.NewL1(0.1)
+	layer := layers.NewDenseLayer(2, 1, &l2, &l1)
+	weights := []float64{1, -2}
+	layer.LoadWeights(&weights)
+	layer.Forward(tensor.New(1, 1, 1, 2, []float64{3, 4}))
+	layer.Backward(tensor.New(1, 1, 1, 1, []float64{1}))
+
+	// Input grads {3, 4} with 2 * 0.5 * w and 0.1 * sign(w)
+	targetWeightsGrads := []float64{4.1, 1.9}
+	targetBiasGrads := []float64{1}
+	targetPenalty := 2.8
+	resultWeightsGrads := layer.GetWeightsGrads().RawData()
+	resultBiasGrads := layer.GetBiasGrads().RawData()
+	resultPenalty := layer.Penalty()
+	if !functools.IsEqual(&targetWeightsGrads, &resultWeightsGrads, 1e-12) {
+		fmt.Println(resultWeightsGrads)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBiasGrads, &resultBiasGrads, 1e-12) {
+		fmt.Println(resultBiasGrads)
+		t.Fail()
+	}
+	if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+		fmt.Println(resultPenalty)
+		t.Fail()
+	}
+}
+
+func TestDenseLayer_Params_Uninitialized_Weights(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	layer := layers.NewDenseLayer(2, 3)
+	layer.Params()
+}
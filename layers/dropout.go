@@ -0,0 +1,77 @@
+package layers
+
+import (
+	"fmt"
+	"math/rand"
+
+	"DoodleGan/tensor"
+)
+
+// Zeroes every value with probability rate while training and scales kept values
+// by 1 / (1 - rate), so inference passes input unchanged
+type Dropout struct {
+	rate     float64
+	rng      *rand.Rand
+	training bool
+
+	mask []float64 // 0 or 1 / (1 - rate) for every value of the last input
+}
+
+func NewDropout(rate float64, rng *rand.Rand) Dropout {
+	if rate < 0.0 || rate >= 1.0 {
+		panic(fmt.Sprintf("NewDropout fail:\n\trate (%f) must be in range [0, 1)", rate))
+	}
+	if rng == nil {
+		panic("NewDropout fail:\n\tRandom number generator can't be nil")
+	}
+	return Dropout{
+		rate:     rate,
+		rng:      rng,
+		training: true,
+	}
+}
+
+func (layer *Dropout) SetRand(rng *rand.Rand) {
+	if rng == nil {
+		panic("SetRand fail:\n\tRandom number generator can't be nil")
+	}
+	layer.rng = rng
+}
+
+func (layer *Dropout) SetTraining(training bool) {
+	layer.training = training
+}
+
+func (layer *Dropout) Forward(input *tensor.Tensor) *tensor.Tensor {
+	if !layer.training {
+		layer.mask = nil
+		return input
+	}
+	scale := 1.0 / (1.0 - layer.rate)
+	layer.mask = make([]float64, len(input.RawData()))
+	for i := range layer.mask {
+		if layer.rng.Float64() >= layer.rate {
+			layer.mask[i] = scale
+		}
+	}
+	output := input.Clone()
+	for i, m := range layer.mask {
+		output.RawData()[i] *= m
+	}
+	return output
+}
+
+func (layer *Dropout) Backward(inGrads *tensor.Tensor) *tensor.Tensor {
+	if layer.mask == nil {
+		return inGrads
+	}
+	outGrads := inGrads.Clone()
+	for i, m := range layer.mask {
+		outGrads.RawData()[i] *= m
+	}
+	return outGrads
+}
+
+func (layer *Dropout) GetRate() float64 {
+	return layer.rate
+}
@@ -0,0 +1,79 @@
+package layers_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/tensor"
+)
+
+func TestDropout_Forward_Backward(t *testing.T) {
+	layer := layers.NewDropout(0.5, rand.New(rand.NewSource(42)))
+	input := tensor.New(2, 1, 1, 50, nil)
+	for i := range input.RawData() {
+		input.RawData()[i] = float64(i + 1)
+	}
+	output := layer.Forward(input).RawData()
+	dropped := 0
+	for i, v := range output {
+		if v == 0.0 {
+			dropped++
+		} else if v != 2.0*input.RawData()[i] {
+			fmt.Println(i, v)
+			t.Fail()
+		}
+	}
+	if dropped == 0 || dropped == len(output) {
+		fmt.Println(dropped)
+		t.Fail()
+	}
+
+	grads := tensor.New(2, 1, 1, 50, nil)
+	for i := range grads.RawData() {
+		grads.RawData()[i] = 1.0
+	}
+	outGrads := layer.Backward(grads).RawData()
+	for i, v := range outGrads {
+		if (output[i] == 0.0) != (v == 0.0) {
+			fmt.Println(i, v)
+			t.Fail()
+		}
+	}
+}
+
+func TestDropout_Seed(t *testing.T) {
+	input := tensor.New(1, 1, 1, 20, nil)
+	for i := range input.RawData() {
+		input.RawData()[i] = 1.0
+	}
+	first := layers.NewDropout(0.3, rand.New(rand.NewSource(7)))
+	second := layers.NewDropout(0.3, rand.New(rand.NewSource(7)))
+	firstOutput := first.Forward(input).RawData()
+	secondOutput := second.Forward(input).RawData()
+	if !functools.IsEqual(&firstOutput, &secondOutput, 1e-12) {
+		fmt.Println(firstOutput)
+		fmt.Println(secondOutput)
+		t.Fail()
+	}
+}
+
+func TestDropout_Eval(t *testing.T) {
+	layer := layers.NewDropout(0.9, rand.New(rand.NewSource(1)))
+	layer.SetTraining(false)
+	input := tensor.New(1, 1, 1, 3, []float64{1, -2, 3})
+	output := layer.Forward(input).RawData()
+	target := []float64{1, -2, 3}
+	if !functools.IsEqual(&target, &output, 1e-12) {
+		fmt.Println(output)
+		t.Fail()
+	}
+	outGrads := layer.Backward(tensor.New(1, 1, 1, 3, []float64{0.5, 1, 2})).RawData()
+	targetGrads := []float64{0.5, 1, 2}
+	if !functools.IsEqual(&targetGrads, &outGrads, 1e-12) {
+		fmt.Println(outGrads)
+		t.Fail()
+	}
+}
@@ -1,26 +1,48 @@
 package layers
 
-import "gonum.org/v1/gonum/mat"
+import (
+	"math/rand"
 
+	"DoodleGan/tensor"
+)
+
+// Layers process a whole batch at once, dense layers treat every sample as a flat vector
 type Layer interface {
-	Forward(input *mat.VecDense) *mat.VecDense
-	Backward(inGrads *mat.VecDense) *mat.VecDense
+	Forward(input *tensor.Tensor) *tensor.Tensor
+	Backward(inGrads *tensor.Tensor) *tensor.Tensor
 }
 
+// Gradients are summed over all samples of the last backward pass.
+// Param names are unique within a layer.
 type LayerTrainable interface {
 	Layer
 
-	ApplyGrads(learningRate *float64, dWeightsGrads *mat.Dense, dBiasGrad *mat.VecDense)
-	GetOutWeightsGrads() *mat.Dense
-	GetOutBiasGrads() *mat.VecDense
+	Params() []tensor.Param
+}
+
+// Penalty is added to the loss, its gradient is part of grads after backward
+type LayerRegularized interface {
+	Layer
+
+	Penalty() float64
+}
+
+// Layers behaving differently in training and inference, like batch normalization
+type LayerTrainMode interface {
+	SetTraining(training bool)
+}
+
+// Layers drawing random numbers while training, like dropout
+type LayerRandom interface {
+	SetRand(rng *rand.Rand)
 }
 
-type SavedDataVec struct {
-	lastInput  mat.VecDense
-	lastOutput mat.VecDense
+type SavedData struct {
+	lastInput  *tensor.Tensor
+	lastOutput *tensor.Tensor
 }
 
-type SavedGradsVec struct {
-	lastInGrads  mat.VecDense
-	lastOutGrads mat.VecDense
+type SavedGrads struct {
+	lastInGrads  *tensor.Tensor
+	lastOutGrads *tensor.Tensor
 }
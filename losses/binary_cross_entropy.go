@@ -42,3 +42,14 @@ func (loss *BinaryCrossEntropy) CalculateTotal(yHat, y *[]mat.VecDense) float64
 	}
 	return -retVal
 }
+
+func (loss *BinaryCrossEntropy) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	retVal := make([]mat.VecDense, len(*yHat))
+	for i := range *yHat {
+		y_ := (*y)[i].AtVec(0)
+		yHat_ := min(max((*yHat)[i].AtVec(0), 1e-8), 0.99999999)
+		grad := (yHat_ - y_) / (yHat_ * (1.0 - yHat_) * loss.batchSizeFloat)
+		retVal[i] = *mat.NewVecDense(1, []float64{grad})
+	}
+	return retVal
+}
@@ -89,3 +89,27 @@ func TestBinaryCrossEntropy_3(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestBinaryCrossEntropy_Gradient_1(t *testing.T) {
+	loss := losses.NewBinaryCrossEntropy(2)
+	yHat := []mat.VecDense{
+		*mat.NewVecDense(1, []float64{0.3}),
+		*mat.NewVecDense(1, []float64{0.7}),
+	}
+	y := []mat.VecDense{
+		*mat.NewVecDense(1, []float64{1}),
+		*mat.NewVecDense(1, []float64{0}),
+	}
+	result := loss.Gradient(&yHat, &y)
+	target := []mat.VecDense{
+		*mat.NewVecDense(1, []float64{-1.66667}),
+		*mat.NewVecDense(1, []float64{1.66667}),
+	}
+	for i := range target {
+		if !functools.IsEqualVec(&target[i], &result[i], 0.001) {
+			fmt.Println(target[i].RawVector().Data)
+			fmt.Println(result[i].RawVector().Data)
+			t.Fail()
+		}
+	}
+}
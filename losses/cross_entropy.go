@@ -42,3 +42,16 @@ func (loss *CrossEntropy) CalculateTotal(yHat, y *[]mat.VecDense) float64 {
 	}
 	return retSum
 }
+
+func (loss *CrossEntropy) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	retVal := make([]mat.VecDense, len(*yHat))
+	for i := range *yHat {
+		retVal[i] = *mat.NewVecDense(loss.outputClasses, nil)
+		for j := range loss.outputClasses {
+			label := (*y)[i].AtVec(j)
+			pred := max((*yHat)[i].AtVec(j), 10e-8)
+			retVal[i].SetVec(j, -label/(pred*loss.batchSizeFloat))
+		}
+	}
+	return retVal
+}
@@ -61,3 +61,24 @@ func TestCE_2(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestCE_Gradient_1(t *testing.T) {
+	loss := losses.NewCrossEntropy(1, 3)
+	yHat := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{0.57, 0.20, 0.23}),
+	}
+	y := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{1, 0, 0}),
+	}
+	result := loss.Gradient(&yHat, &y)
+	target := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{-1.75439, 0, 0}),
+	}
+	for i := range target {
+		if !functools.IsEqualVec(&target[i], &result[i], 0.001) {
+			fmt.Println(target[i].RawVector().Data)
+			fmt.Println(result[i].RawVector().Data)
+			t.Fail()
+		}
+	}
+}
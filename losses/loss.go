@@ -11,6 +11,10 @@ type BatchSize struct {
 	batchSizeFloat float64
 }
 
+func (b *BatchSize) GetBatchSize() int {
+	return b.batchSizeInt
+}
+
 type OutputLen struct {
 	outputLenInt   int
 	outputLenFloat float64
@@ -19,6 +23,10 @@ type OutputLen struct {
 type Loss interface {
 	CalculateAvg(yHat, y *[]mat.VecDense) float64
 	CalculateTotal(yHat, y *[]mat.VecDense) float64
+	// Gradient of CalculateAvg with respect to every prediction.
+	// yHat can hold only a part of the batch, it's still scaled by the batch size.
+	Gradient(yHat, y *[]mat.VecDense) []mat.VecDense
+	GetBatchSize() int
 }
 
 func SumOfSquaresBatch(yHat, y *[]mat.VecDense, batchSizeInt, outputLen *int) float64 {
@@ -29,6 +37,15 @@ func SumOfSquaresBatch(yHat, y *[]mat.VecDense, batchSizeInt, outputLen *int) fl
 	return retSum
 }
 
+func SquaresGradient(yHat, y *[]mat.VecDense, scaler float64) []mat.VecDense {
+	retVal := make([]mat.VecDense, len(*yHat))
+	for i := range *yHat {
+		retVal[i].SubVec(&(*yHat)[i], &(*y)[i])
+		retVal[i].ScaleVec(2.0*scaler, &retVal[i])
+	}
+	return retVal
+}
+
 func SumOfSquares(yHat, y *mat.VecDense, outputLen *int) float64 {
 	retSum := 0.0
 	for j := range *outputLen {
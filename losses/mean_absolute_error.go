@@ -43,3 +43,20 @@ func (loss *MeanAbsoluteError) sumOfAbs(yHat, y *[]mat.VecDense) float64 {
 	}
 	return retVal
 }
+
+func (loss *MeanAbsoluteError) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	scaler := 1.0 / (loss.batchSizeFloat * loss.outputLenFloat)
+	retVal := make([]mat.VecDense, len(*yHat))
+	for i := range *yHat {
+		retVal[i] = *mat.NewVecDense(loss.outputLenInt, nil)
+		for j := range loss.outputLenInt {
+			diff := (*yHat)[i].AtVec(j) - (*y)[i].AtVec(j)
+			if diff > 0.0 {
+				retVal[i].SetVec(j, scaler)
+			} else if diff < 0.0 {
+				retVal[i].SetVec(j, -scaler)
+			}
+		}
+	}
+	return retVal
+}
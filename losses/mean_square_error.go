@@ -34,3 +34,7 @@ func (loss *MeanSquareError) CalculateAvg(yHat, y *[]mat.VecDense) float64 {
 func (loss *MeanSquareError) CalculateTotal(yHat, y *[]mat.VecDense) float64 {
 	return SumOfSquaresBatch(yHat, y, &loss.batchSizeInt, &loss.outputLenInt) / loss.outputLenFloat
 }
+
+func (loss *MeanSquareError) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	return SquaresGradient(yHat, y, 1.0/(loss.outputLenFloat*loss.batchSizeFloat))
+}
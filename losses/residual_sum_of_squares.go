@@ -39,3 +39,7 @@ func (loss *ResidualSumOfSquares) CalculateTotal(yHat, y *[]mat.VecDense) float6
 	}
 	return retSum
 }
+
+func (loss *ResidualSumOfSquares) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	return SquaresGradient(yHat, y, 1.0/loss.batchSizeFloat)
+}
@@ -37,3 +37,19 @@ func (loss *RootMeanSquareError) CalculateTotal(yHat, y *[]mat.VecDense) float64
 	}
 	return retVal
 }
+
+func (loss *RootMeanSquareError) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	retVal := make([]mat.VecDense, len(*yHat))
+	for i := range *yHat {
+		retVal[i].SubVec(&(*yHat)[i], &(*y)[i])
+		rms := math.Sqrt(
+			SumOfSquares(&(*yHat)[i], &(*y)[i], &loss.outputLenInt) / loss.outputLenFloat,
+		)
+		if rms == 0.0 {
+			retVal[i].Zero()
+			continue
+		}
+		retVal[i].ScaleVec(1.0/(loss.outputLenFloat*rms*loss.batchSizeFloat), &retVal[i])
+	}
+	return retVal
+}
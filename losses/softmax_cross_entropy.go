@@ -0,0 +1,64 @@
+package losses
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+)
+
+// Softmax and cross entropy fused into a single loss computed on logits,
+// so the model shouldn't end with a softmax layer
+type SoftmaxCrossEntropy struct {
+	BatchSize
+	outputClasses int
+}
+
+func NewSoftmaxCrossEntropy(batchSize, outputLen int) SoftmaxCrossEntropy {
+	return SoftmaxCrossEntropy{
+		BatchSize: BatchSize{
+			batchSizeInt:   batchSize,
+			batchSizeFloat: float64(batchSize),
+		},
+		outputClasses: outputLen,
+	}
+}
+
+func (loss *SoftmaxCrossEntropy) CalculateAvg(yHat, y *[]mat.VecDense) float64 {
+	return loss.CalculateTotal(yHat, y) / loss.batchSizeFloat
+}
+
+func (loss *SoftmaxCrossEntropy) CalculateTotal(yHat, y *[]mat.VecDense) float64 {
+	retSum := 0.0
+	for i := range loss.batchSizeInt {
+		logSumExp := logSumExp(&(*yHat)[i])
+		for j := range loss.outputClasses {
+			label := (*y)[i].AtVec(j)
+			retSum += label * (logSumExp - (*yHat)[i].AtVec(j))
+		}
+	}
+	return retSum
+}
+
+// Gradient with respect to logits: softmax(yHat) - y
+func (loss *SoftmaxCrossEntropy) Gradient(yHat, y *[]mat.VecDense) []mat.VecDense {
+	retVal := make([]mat.VecDense, len(*yHat))
+	for i := range *yHat {
+		labelSum := mat.Sum(&(*y)[i])
+		retVal[i] = functools.StableSoftmax(&(*yHat)[i])
+		retVal[i].ScaleVec(labelSum, &retVal[i])
+		retVal[i].SubVec(&retVal[i], &(*y)[i])
+		retVal[i].ScaleVec(1.0/loss.batchSizeFloat, &retVal[i])
+	}
+	return retVal
+}
+
+func logSumExp(logits *mat.VecDense) float64 {
+	maxVal := mat.Max(logits)
+	expSums := 0.0
+	for i := range logits.Len() {
+		expSums += math.Exp(logits.AtVec(i) - maxVal)
+	}
+	return maxVal + math.Log(expSums)
+}
@@ -0,0 +1,78 @@
+package losses_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/losses"
+)
+
+func TestSoftmaxCE_1(t *testing.T) {
+	loss := losses.NewSoftmaxCrossEntropy(2, 3)
+	yHat := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{2, 1, 0.1}),
+		*mat.NewVecDense(3, []float64{0.5, 2.5, -1}),
+	}
+	y := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{1, 0, 0}),
+		*mat.NewVecDense(3, []float64{0, 1, 0}),
+	}
+	result := loss.CalculateAvg(&yHat, &y)
+	resultTotal := loss.CalculateTotal(&yHat, &y)
+	target := float64(0.28511)
+	targetTotal := float64(0.57021)
+	if !functools.IsEqualVal(&target, &result, 0.001) {
+		fmt.Println(target)
+		fmt.Println(result)
+		t.Fail()
+	}
+	if !functools.IsEqualVal(&targetTotal, &resultTotal, 0.001) {
+		fmt.Println(targetTotal)
+		fmt.Println(resultTotal)
+		t.Fail()
+	}
+}
+
+func TestSoftmaxCE_Large_Logits(t *testing.T) {
+	loss := losses.NewSoftmaxCrossEntropy(1, 2)
+	yHat := []mat.VecDense{
+		*mat.NewVecDense(2, []float64{1000, 0}),
+	}
+	y := []mat.VecDense{
+		*mat.NewVecDense(2, []float64{0, 1}),
+	}
+	result := loss.CalculateAvg(&yHat, &y)
+	target := float64(1000)
+	if !functools.IsEqualVal(&target, &result, 0.001) {
+		fmt.Println(target)
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestSoftmaxCE_Gradient_1(t *testing.T) {
+	loss := losses.NewSoftmaxCrossEntropy(2, 3)
+	yHat := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{2, 1, 0.1}),
+		*mat.NewVecDense(3, []float64{0.5, 2.5, -1}),
+	}
+	y := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{1, 0, 0}),
+		*mat.NewVecDense(3, []float64{0, 1, 0}),
+	}
+	result := loss.Gradient(&yHat, &y)
+	target := []mat.VecDense{
+		*mat.NewVecDense(3, []float64{-0.1705, 0.12122, 0.04928}),
+		*mat.NewVecDense(3, []float64{0.05806, -0.07101, 0.01295}),
+	}
+	for i := range target {
+		if !functools.IsEqualVec(&target[i], &result[i], 0.001) {
+			fmt.Println(target[i].RawVector().Data)
+			fmt.Println(result[i].RawVector().Data)
+			t.Fail()
+		}
+	}
+}
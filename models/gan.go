@@ -0,0 +1,291 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/losses"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
+)
+
+type NoiseSampler func(latentDim int) []float64
+
+func UniformNoise(minRange, maxRange float64, rng *rand.Rand) NoiseSampler {
+	if maxRange < minRange {
+		panic("UniformNoise fail:\n\tminRange can't be greater than maxRange")
+	}
+	if rng == nil {
+		panic("UniformNoise fail:\n\tRandom number generator can't be nil")
+	}
+	return func(latentDim int) []float64 {
+		retVal := make([]float64, latentDim)
+		for i := range latentDim {
+			retVal[i] = rng.Float64()*(maxRange-minRange) + minRange
+		}
+		return retVal
+	}
+}
+
+func NormalNoise(mean, stdDev float64, rng *rand.Rand) NoiseSampler {
+	if stdDev < 0.0 {
+		panic("NormalNoise fail:\n\tStandard deviation can't be negative")
+	}
+	if rng == nil {
+		panic("NormalNoise fail:\n\tRandom number generator can't be nil")
+	}
+	return func(latentDim int) []float64 {
+		retVal := make([]float64, latentDim)
+		for i := range latentDim {
+			retVal[i] = rng.NormFloat64()*stdDev + mean
+		}
+		return retVal
+	}
+}
+
+// Generator maps noise to an image, discriminator maps an image to a single
+// probability of it being real, so it should end with a sigmoid.
+// Both networks are trained with optimizers (and schedulers) set on them.
+type GAN struct {
+	generator     *Sequential
+	discriminator *Sequential
+	noise         NoiseSampler
+	rng           *rand.Rand // shuffles real samples every epoch
+
+	batchSize int
+	epochs    int
+	kSteps    int
+	latentDim int
+
+	lossFunction losses.BinaryCrossEntropy
+}
+
+// Grad norms are epoch averages of optimizers GradNorm, measured before clipping
+type GANHistory struct {
+	DiscriminatorLoss     []float64
+	GeneratorLoss         []float64
+	DiscriminatorGradNorm []float64
+	GeneratorGradNorm     []float64
+}
+
+func NewGAN(
+	generator, discriminator *Sequential,
+	batchSize, epochs, kSteps int,
+	noise NoiseSampler,
+	rng *rand.Rand,
+) GAN {
+	if batchSize < 1 || epochs < 1 || kSteps < 1 {
+		mess := fmt.Sprintf(
+			"NewGAN fail:\n\tBatch size (%d), number of epochs (%d) and k steps (%d) must be positive",
+			batchSize,
+			epochs,
+			kSteps,
+		)
+		panic(mess)
+	}
+	if generator.outputLen != discriminator.sampleLen() {
+		mess := fmt.Sprintf(
+			"NewGAN fail:\n\tGenerator output length (%d) doesn't match discriminator input length (%d)",
+			generator.outputLen,
+			discriminator.sampleLen(),
+		)
+		panic(mess)
+	}
+	if discriminator.outputLen != 1 {
+		mess := fmt.Sprintf(
+			"NewGAN fail:\n\tDiscriminator output length must be 1, have: %d",
+			discriminator.outputLen,
+		)
+		panic(mess)
+	}
+	if noise == nil {
+		panic("NewGAN fail:\n\tNoise sampler can't be nil")
+	}
+	if rng == nil {
+		panic("NewGAN fail:\n\tRandom number generator can't be nil")
+	}
+	return GAN{
+		generator:     generator,
+		discriminator: discriminator,
+		noise:         noise,
+		rng:           rng,
+		batchSize:     batchSize,
+		epochs:        epochs,
+		kSteps:        kSteps,
+		latentDim:     generator.sampleLen(),
+		lossFunction:  losses.NewBinaryCrossEntropy(batchSize),
+	}
+}
+
+func (gan *GAN) SetOptimizers(generatorOpt, discriminatorOpt optimizers.Optimizer) {
+	gan.generator.SetOptimizer(generatorOpt)
+	gan.discriminator.SetOptimizer(discriminatorOpt)
+}
+
+// Schedulers step after every step of their network or after every epoch,
+// nil leaves the learning rate of a network fixed
+func (gan *GAN) SetSchedulers(generatorScheduler, discriminatorScheduler optimizers.Scheduler, perBatch bool) {
+	gan.generator.SetScheduler(generatorScheduler, perBatch)
+	gan.discriminator.SetScheduler(discriminatorScheduler, perBatch)
+}
+
+// realX holds flattened real images scaled to [0, 1].
+// Every iteration makes kSteps discriminator steps followed by one generator step.
+func (gan *GAN) Train(realX *[]float64) GANHistory {
+	if gan.generator.optimizer == nil || gan.discriminator.optimizer == nil {
+		panic("GAN fail:\n\tGenerator and discriminator optimizers must be set before training")
+	}
+	imageLen := gan.discriminator.sampleLen()
+	if len(*realX)%imageLen != 0 {
+		mess := fmt.Sprintf(
+			"Train fail:\n\tLength of real data (%d) isn't a multiple of image length (%d)",
+			len(*realX),
+			imageLen,
+		)
+		panic(mess)
+	}
+	nSamples := len(*realX) / imageLen
+	nIterations := nSamples / (gan.batchSize * gan.kSteps)
+	if nIterations == 0 {
+		mess := fmt.Sprintf(
+			"Train fail:\n\tNumber of samples (%d) is smaller than batch size * k steps (%d)",
+			nSamples,
+			gan.batchSize*gan.kSteps,
+		)
+		panic(mess)
+	}
+
+	gan.generator.optimizer.PreTrainInit(&gan.generator.layers)
+	gan.discriminator.optimizer.PreTrainInit(&gan.discriminator.layers)
+	gan.SetTraining(true)
+
+	history := GANHistory{
+		DiscriminatorLoss:     make([]float64, 0, gan.epochs),
+		GeneratorLoss:         make([]float64, 0, gan.epochs),
+		DiscriminatorGradNorm: make([]float64, 0, gan.epochs),
+		GeneratorGradNorm:     make([]float64, 0, gan.epochs),
+	}
+	for range gan.epochs {
+		order := gan.rng.Perm(nSamples)
+		discLoss, discGradNorm := 0.0, 0.0
+		genLoss, genGradNorm := 0.0, 0.0
+		for it := range nIterations {
+			for k := range gan.kSteps {
+				start := (it*gan.kSteps + k) * gan.batchSize
+				stepLoss, stepGradNorm := gan.discriminatorStep(realX, order[start:start+gan.batchSize])
+				gan.discriminator.scheduleBatch(stepLoss)
+				discLoss += stepLoss
+				discGradNorm += stepGradNorm
+			}
+			stepLoss, stepGradNorm := gan.generatorStep()
+			gan.generator.scheduleBatch(stepLoss)
+			genLoss += stepLoss
+			genGradNorm += stepGradNorm
+		}
+		discLoss /= float64(nIterations * gan.kSteps)
+		discGradNorm /= float64(nIterations * gan.kSteps)
+		genLoss /= float64(nIterations)
+		genGradNorm /= float64(nIterations)
+		gan.discriminator.scheduleEpoch(discLoss)
+		gan.generator.scheduleEpoch(genLoss)
+		history.DiscriminatorLoss = append(history.DiscriminatorLoss, discLoss)
+		history.GeneratorLoss = append(history.GeneratorLoss, genLoss)
+		history.DiscriminatorGradNorm = append(history.DiscriminatorGradNorm, discGradNorm)
+		history.GeneratorGradNorm = append(history.GeneratorGradNorm, genGradNorm)
+	}
+	return history
+}
+
+// Updates discriminator on real (label 1) and generated (label 0) images stacked
+// into one batch, so every discriminator step is a single optimizer step.
+// Batch normalization layers see statistics of both halves together.
+func (gan *GAN) discriminatorStep(realX *[]float64, batchIdxs []int) (float64, float64) {
+	realInput := gan.discriminator.batchInput(realX, batchIdxs)
+	generated := gan.generator.forward(gan.noiseBatch(gan.batchSize))
+	data := slices.Concat(realInput.RawData(), generated.RawData())
+	output := gan.discriminator.forward(gan.discriminator.inputTensor(data, 2*gan.batchSize))
+	preds := batchVecs(output)
+	realPreds, fakePreds := preds[:gan.batchSize], preds[gan.batchSize:]
+
+	ones := functools.RepeatSlice(*mat.NewVecDense(1, []float64{1.0}), gan.batchSize)
+	zeros := functools.RepeatSlice(*mat.NewVecDense(1, []float64{0.0}), gan.batchSize)
+	labels := slices.Concat(ones, zeros)
+	penalty := gan.discriminator.penalty()
+	// Loss is divided by batch size, not by 2 * batch size, so grads are
+	// the sum of grads of real and fake loss
+	gan.discriminator.backward(gradsLike(output, gan.lossFunction.Gradient(&preds, &labels)))
+
+	return gan.lossFunction.CalculateAvg(&realPreds, &ones) +
+		gan.lossFunction.CalculateAvg(&fakePreds, &zeros) + penalty, gan.discriminator.optimizer.GradNorm()
+}
+
+// Updates generator through frozen discriminator with non saturating loss -log(D(G(z)))
+func (gan *GAN) generatorStep() (float64, float64) {
+	generated := gan.generator.forward(gan.noiseBatch(gan.batchSize))
+	fakeOutput := gan.discriminator.forward(gan.discriminatorInput(generated))
+	fakePreds := batchVecs(fakeOutput)
+	discGrads := gan.discriminator.inputGrads(gan.predictionGrads(fakeOutput, &fakePreds, 1.0))
+	_, c, h, w := generated.Dims()
+	penalty := gan.generator.penalty()
+	gan.generator.backward(discGrads.Reshape(c, h, w))
+
+	ones := functools.RepeatSlice(*mat.NewVecDense(1, []float64{1.0}), gan.batchSize)
+	return gan.lossFunction.CalculateAvg(&fakePreds, &ones) + penalty, gan.generator.optimizer.GradNorm()
+}
+
+// Switches both networks between training and evaluation behaviour
+func (gan *GAN) SetTraining(training bool) {
+	gan.generator.SetTraining(training)
+	gan.discriminator.SetTraining(training)
+}
+
+// Generates n images with values scaled from [0, 1] to [0, 255],
+// generator runs in evaluation mode and gets its previous mode back afterwards
+func (gan *GAN) Sample(n int) [][]uint8 {
+	if n < 1 {
+		panic(fmt.Sprintf("Sample fail:\n\tNumber of samples must be positive, have: %d", n))
+	}
+	defer gan.generator.SetTraining(gan.generator.training)
+	gan.generator.SetTraining(false)
+	generated := gan.generator.forward(gan.noiseBatch(n))
+	retVal := make([][]uint8, n)
+	for i := range n {
+		sample := generated.Sample(i)
+		retVal[i] = make([]uint8, len(sample))
+		for j, v := range sample {
+			retVal[i][j] = uint8(math.Round(min(max(v, 0.0), 1.0) * 255.0))
+		}
+	}
+	return retVal
+}
+
+func (gan *GAN) GetGenerator() *Sequential {
+	return gan.generator
+}
+
+func (gan *GAN) GetDiscriminator() *Sequential {
+	return gan.discriminator
+}
+
+func (gan *GAN) noiseBatch(n int) *tensor.Tensor {
+	data := make([]float64, 0, n*gan.latentDim)
+	for range n {
+		data = append(data, gan.noise(gan.latentDim)...)
+	}
+	return gan.generator.inputTensor(data, n)
+}
+
+// Generator output seen as discriminator input, data is shared
+func (gan *GAN) discriminatorInput(generated *tensor.Tensor) *tensor.Tensor {
+	return gan.discriminator.inputTensor(generated.RawData(), generated.Len())
+}
+
+func (gan *GAN) predictionGrads(output *tensor.Tensor, preds *[]mat.VecDense, label float64) *tensor.Tensor {
+	labels := functools.RepeatSlice(*mat.NewVecDense(1, []float64{label}), len(*preds))
+	return gradsLike(output, gan.lossFunction.Gradient(preds, &labels))
+}
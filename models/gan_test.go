@@ -0,0 +1,235 @@
+package models_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"DoodleGan/conv"
+	"DoodleGan/layers"
+	"DoodleGan/models"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
+)
+
+func sinWeights(n int, scale float64) []float64 {
+	retVal := make([]float64, n)
+	for i := range n {
+		retVal[i] = math.Sin(float64(i)) * scale
+	}
+	return retVal
+}
+
+func TestGAN_1(t *testing.T) {
+	genDense := layers.NewDenseLayer(4, 784)
+	genWeights := sinWeights(4*784, 0.5)
+	genDense.LoadWeights(&genWeights)
+	genAct := layers.NewVSigmoid()
+	generator := models.NewSequential(2, 1, [2]int{1, 4}, 1, 784, rand.New(rand.NewSource(1)))
+	generator.AddDenseLayer(&genDense)
+	generator.AddDenseLayer(&genAct)
+
+	discConv := conv.NewConv2D([2]int{2, 2}, 1, [2]int{28, 28}, 1, [2]int{2, 2}, [4]int{0, 0, 0, 0})
+	discFilter := sinWeights(4, 0.3)
+	discConv.LoadFilter(&discFilter)
+	discConvAct := conv.NewLeakyReLU(0.2)
+	discDense := layers.NewDenseLayer(196, 1)
+	discWeights := sinWeights(196, 0.1)
+	discDense.LoadWeights(&discWeights)
+	discAct := layers.NewVSigmoid()
+	discriminator := models.NewSequential(2, 1, [2]int{28, 28}, 1, 1, rand.New(rand.NewSource(1)))
+	discriminator.AddConvLayer(&discConv)
+	discriminator.AddConvLayer(&discConvAct)
+	discriminator.AddDenseLayer(&discDense)
+	discriminator.AddDenseLayer(&discAct)
+
+	noise := models.UniformNoise(-1, 1, rand.New(rand.NewSource(2)))
+	gan := models.NewGAN(&generator, &discriminator, 2, 3, 2, noise, rand.New(rand.NewSource(3)))
+	genOpt := optimizers.NewAdam(0.001, 0.5, 0.999, 1e-8)
+	discOpt := optimizers.NewSGD(0.01, 0.0)
+	discOpt.SetClipping(optimizers.Clipping{GlobalNorm: 1.0})
+	gan.SetOptimizers(&genOpt, &discOpt)
+
+	realX := make([]float64, 4*784)
+	for i := range realX {
+		if (i%28)%7 == 0 {
+			realX[i] = 1.0
+		}
+	}
+	genDecay := optimizers.NewExponentialDecay(0.9)
+	genWarmup := optimizers.NewWarmup(2, &genDecay)
+	genScheduler := optimizers.NewScheduledRate(&genOpt, &genWarmup)
+	gan.SetSchedulers(&genScheduler, nil, true)
+	history := gan.Train(&realX)
+
+	if len(history.DiscriminatorLoss) != 3 || len(history.GeneratorLoss) != 3 {
+		fmt.Println(history)
+		t.Fatal()
+	}
+	for e := range 3 {
+		if math.IsNaN(history.DiscriminatorLoss[e]) || math.IsNaN(history.GeneratorLoss[e]) {
+			fmt.Println(history)
+			t.Fail()
+		}
+		if !(history.DiscriminatorGradNorm[e] > 0.0) || !(history.GeneratorGradNorm[e] > 0.0) {
+			fmt.Println(history)
+			t.Fail()
+		}
+	}
+
+	// One generator step every epoch, warmup takes first 2 of them
+	targetRate := 0.001 * 0.9
+	resultRate := genOpt.GetLearningRate()
+	discRate := discOpt.GetLearningRate()
+	if math.Abs(targetRate-resultRate) > 1e-12 || discRate != 0.01 {
+		fmt.Println(resultRate, discRate)
+		t.Fail()
+	}
+
+	samples := gan.Sample(5)
+	if len(samples) != 5 {
+		t.Fatal()
+	}
+	for _, sample := range samples {
+		if len(sample) != 784 {
+			fmt.Println(len(sample))
+			t.Fail()
+		}
+	}
+}
+
+// Same seeds give the same noise, shuffling and so the same training
+func TestGAN_Reproducible(t *testing.T) {
+	train := func(seed int64) (models.GANHistory, [][]uint8) {
+		genDense := layers.NewDenseLayer(2, 4)
+		genWeights := sinWeights(8, 0.5)
+		genDense.LoadWeights(&genWeights)
+		genAct := layers.NewVSigmoid()
+		generator := models.NewSequential(2, 1, [2]int{1, 2}, 1, 4, rand.New(rand.NewSource(seed)))
+		generator.AddDenseLayer(&genDense)
+		generator.AddDenseLayer(&genAct)
+
+		discDense := layers.NewDenseLayer(4, 1)
+		discWeights := sinWeights(4, 0.3)
+		discDense.LoadWeights(&discWeights)
+		discAct := layers.NewVSigmoid()
+		discriminator := models.NewSequential(2, 1, [2]int{1, 4}, 1, 1, rand.New(rand.NewSource(seed)))
+		discriminator.AddDenseLayer(&discDense)
+		discriminator.AddDenseLayer(&discAct)
+
+		noise := models.NormalNoise(0, 1, rand.New(rand.NewSource(seed)))
+		gan := models.NewGAN(&generator, &discriminator, 2, 4, 1, noise, rand.New(rand.NewSource(seed)))
+		genOpt := optimizers.NewSGD(0.1, 0.0)
+		discOpt := optimizers.NewSGD(0.1, 0.0)
+		gan.SetOptimizers(&genOpt, &discOpt)
+
+		realX := sinWeights(6*4, 1.0)
+		for i := range realX {
+			realX[i] = math.Abs(realX[i])
+		}
+		return gan.Train(&realX), gan.Sample(3)
+	}
+
+	history1, samples1 := train(7)
+	history2, samples2 := train(7)
+	if !reflect.DeepEqual(history1, history2) || !reflect.DeepEqual(samples1, samples2) {
+		fmt.Println(history1, history2)
+		t.Fail()
+	}
+}
+
+// Small dense GAN on 1 x 4 images, generator ends with dropout
+func newTinyGAN(kSteps int, dropout *layers.Dropout) models.GAN {
+	genDense := layers.NewDenseLayer(2, 4)
+	genWeights := sinWeights(8, 0.5)
+	genDense.LoadWeights(&genWeights)
+	genAct := layers.NewVSigmoid()
+	generator := models.NewSequential(2, 1, [2]int{1, 2}, 1, 4, rand.New(rand.NewSource(1)))
+	generator.AddDenseLayer(&genDense)
+	generator.AddDenseLayer(&genAct)
+	generator.AddDenseLayer(dropout)
+
+	discDense := layers.NewDenseLayer(4, 1)
+	discWeights := sinWeights(4, 0.3)
+	discDense.LoadWeights(&discWeights)
+	discAct := layers.NewVSigmoid()
+	discriminator := models.NewSequential(2, 1, [2]int{1, 4}, 1, 1, rand.New(rand.NewSource(1)))
+	discriminator.AddDenseLayer(&discDense)
+	discriminator.AddDenseLayer(&discAct)
+
+	noise := models.NormalNoise(0, 1, rand.New(rand.NewSource(2)))
+	return models.NewGAN(&generator, &discriminator, 2, 1, kSteps, noise, rand.New(rand.NewSource(3)))
+}
+
+type countingSGD struct {
+	optimizers.SGD
+	backwardCalls int
+}
+
+func (opt *countingSGD) Backward(layerList *[]layers.Layer, grads *tensor.Tensor) *tensor.Tensor {
+	opt.backwardCalls++
+	return opt.SGD.Backward(layerList, grads)
+}
+
+// Real and generated images are one batch, so one discriminator step is one update
+func TestGAN_Discriminator_Single_Update(t *testing.T) {
+	dropout := layers.NewDropout(0.0, rand.New(rand.NewSource(4)))
+	gan := newTinyGAN(3, &dropout)
+	genOpt := countingSGD{SGD: optimizers.NewSGD(0.1, 0.0)}
+	discOpt := countingSGD{SGD: optimizers.NewSGD(0.1, 0.0)}
+	gan.SetOptimizers(&genOpt, &discOpt)
+	realX := sinWeights(6*4, 1.0)
+	for i := range realX {
+		realX[i] = math.Abs(realX[i])
+	}
+	gan.Train(&realX)
+
+	if discOpt.backwardCalls != 3 || genOpt.backwardCalls != 1 {
+		fmt.Println(discOpt.backwardCalls, genOpt.backwardCalls)
+		t.Fail()
+	}
+}
+
+func TestGAN_Sample_Restores_Training(t *testing.T) {
+	dropout := layers.NewDropout(0.5, rand.New(rand.NewSource(4)))
+	gan := newTinyGAN(1, &dropout)
+	gan.SetTraining(true)
+	gan.Sample(2)
+
+	// Dropout in training mode zeroes about half of the values
+	output := dropout.Forward(tensor.New(1, 1, 1, 100, sinWeights(100, 1.0))).RawData()
+	zeros := 0
+	for _, v := range output {
+		if v == 0.0 {
+			zeros++
+		}
+	}
+	if zeros < 2 {
+		fmt.Println(output)
+		t.Fail()
+	}
+}
+
+func TestGAN_Sample_non_positive_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	dropout := layers.NewDropout(0.0, rand.New(rand.NewSource(4)))
+	gan := newTinyGAN(1, &dropout)
+	gan.Sample(0)
+}
+
+func TestNewGAN_nil_noise_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	generator := models.NewSequential(2, 1, [2]int{1, 2}, 1, 4, rand.New(rand.NewSource(1)))
+	discriminator := models.NewSequential(2, 1, [2]int{1, 4}, 1, 1, rand.New(rand.NewSource(1)))
+	models.NewGAN(&generator, &discriminator, 2, 1, 1, nil, rand.New(rand.NewSource(3)))
+}
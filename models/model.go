@@ -1,9 +1,14 @@
 package models
 
 type Model interface {
-	Train()
-	Test()
+	Train(X, y *[]float64) History
+	Test(X, y *[]float64) (float64, float64)
 
 	Save(filePath string) error
 	Load(filePath string) error
 }
+
+type History struct {
+	Loss     []float64
+	Accuracy []float64
+}
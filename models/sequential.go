@@ -1,47 +1,392 @@
 package models
 
 import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+
 	"DoodleGan/conv"
 	"DoodleGan/layers"
 	"DoodleGan/losses"
 	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
 )
 
 type Sequential struct {
 	batchSize int
 	epochs    int
 
-	denseLayers  []*layers.Layer
-	convLayers   []*conv.ConvLayer
-	optimizer    *optimizers.Optimizer
-	lossFunction *losses.Loss
+	inputSize     [2]int
+	inputChannels int
+	outputLen     int
+
+	layers       []layers.Layer // dense and conv layers in order of adding
+	optimizer    optimizers.Optimizer
+	lossFunction losses.Loss
+
+	scheduler        optimizers.Scheduler
+	schedulePerBatch bool
+
+	rng      *rand.Rand // shuffles samples every epoch
+	training bool       // mode last set on layers, new layers start in training mode
 
 	correctGuesses uint
 	totalGuesses   uint
 }
 
-func NewSequential() Sequential {
+// inputSize and inputChannels describe a single sample,
+// dense only models can pass {1, nInputs} and 1 channel
+func NewSequential(
+	batchSize, epochs int,
+	inputSize [2]int,
+	inputChannels, outputLen int,
+	rng *rand.Rand,
+) Sequential {
+	if batchSize < 1 || epochs < 1 {
+		mess := fmt.Sprintf(
+			"NewSequential fail:\n\tBatch size (%d) and number of epochs (%d) must be positive",
+			batchSize,
+			epochs,
+		)
+		panic(mess)
+	}
+	if inputSize[0] < 1 || inputSize[1] < 1 || inputChannels < 1 || outputLen < 1 {
+		mess := fmt.Sprintf(
+			"NewSequential fail:\n\tInput size (%d x %d x %d) and output length (%d) must be positive",
+			inputChannels,
+			inputSize[0],
+			inputSize[1],
+			outputLen,
+		)
+		panic(mess)
+	}
+	if rng == nil {
+		panic("NewSequential fail:\n\tRandom number generator can't be nil")
+	}
 	return Sequential{
-		denseLayers: make([]*layers.Layer, 0),
-		convLayers:  make([]*conv.ConvLayer, 0),
+		batchSize:     batchSize,
+		epochs:        epochs,
+		inputSize:     inputSize,
+		inputChannels: inputChannels,
+		outputLen:     outputLen,
+		layers:        make([]layers.Layer, 0),
+		rng:           rng,
+		training:      true,
 	}
 }
 
-func (model *Sequential) AddConvLayer(layer *conv.ConvLayer) {
-	model.convLayers = append(model.convLayers, layer)
+// Conv layers see samples as C x H x W maps, dense layers as flat vectors,
+// so a conv layer followed by a dense layer needs no flattening.
+// Dense output is turned into maps again with conv.Reshape.
+func (model *Sequential) AddConvLayer(layer conv.ConvLayer) {
+	model.layers = append(model.layers, layer)
+}
+
+func (model *Sequential) AddDenseLayer(layer layers.Layer) {
+	model.layers = append(model.layers, layer)
 }
 
-func (model *Sequential) AddDenseLayer(layer *layers.Layer) {
-	model.denseLayers = append(model.denseLayers, layer)
+// Sets generator used for shuffling and by random layers like dropout.
+// Loaded models use a randomly seeded generator unless they had one before loading.
+func (model *Sequential) SetRand(rng *rand.Rand) {
+	if rng == nil {
+		panic("SetRand fail:\n\tRandom number generator can't be nil")
+	}
+	model.rng = rng
+	for _, layer := range model.layers {
+		if randomLayer, ok := layer.(layers.LayerRandom); ok {
+			randomLayer.SetRand(rng)
+		}
+	}
 }
 
-func (model *Sequential) SetOptimizer(opt *optimizers.Optimizer) {
+func (model *Sequential) SetOptimizer(opt optimizers.Optimizer) {
 	model.optimizer = opt
 }
 
-func (model *Sequential) SetLoss(lossFunction *losses.Loss) {
+// Scheduler steps after every batch with its loss, or after every epoch
+// with average batch loss of the epoch. Nil removes the scheduler.
+func (model *Sequential) SetScheduler(scheduler optimizers.Scheduler, perBatch bool) {
+	model.scheduler = scheduler
+	model.schedulePerBatch = perBatch
+}
+
+// Loss function has to be created with the same batch size as the model
+func (model *Sequential) SetLoss(lossFunction losses.Loss) {
+	if lossFunction.GetBatchSize() != model.batchSize {
+		mess := fmt.Sprintf(
+			"SetLoss fail:\n\tLoss batch size (%d) doesn't match model batch size (%d)",
+			lossFunction.GetBatchSize(),
+			model.batchSize,
+		)
+		panic(mess)
+	}
 	model.lossFunction = lossFunction
 }
 
-func (model *Sequential) Train(X, y *[]float64) {
+func (model *Sequential) GetCorrectGuesses() uint {
+	return model.correctGuesses
+}
+
+func (model *Sequential) GetTotalGuesses() uint {
+	return model.totalGuesses
+}
+
+// X holds flattened samples (C x H x W each), y holds flattened labels.
+// Samples that don't fill the last batch are skipped in an epoch.
+func (model *Sequential) Train(X, y *[]float64) History {
+	model.checkReadyToTrain()
+	nSamples := model.numberOfSamples(X, y)
+	nBatches := nSamples / model.batchSize
+	if nBatches == 0 {
+		mess := fmt.Sprintf(
+			"Train fail:\n\tNumber of samples (%d) is smaller than batch size (%d)",
+			nSamples,
+			model.batchSize,
+		)
+		panic(mess)
+	}
+
+	model.optimizer.PreTrainInit(&model.layers)
+	model.SetTraining(true)
+
+	history := History{
+		Loss:     make([]float64, 0, model.epochs),
+		Accuracy: make([]float64, 0, model.epochs),
+	}
+	for range model.epochs {
+		model.correctGuesses = 0
+		model.totalGuesses = 0
+		epochLoss := 0.0
+		order := model.rng.Perm(nSamples)
+		for b := range nBatches {
+			batchIdxs := order[b*model.batchSize : (b+1)*model.batchSize]
+			batchLoss := model.trainBatch(X, y, batchIdxs)
+			model.scheduleBatch(batchLoss)
+			epochLoss += batchLoss
+		}
+		model.scheduleEpoch(epochLoss / float64(nBatches))
+		history.Loss = append(history.Loss, epochLoss/float64(nBatches))
+		history.Accuracy = append(history.Accuracy, model.accuracy())
+	}
+	return history
+}
+
+// Runs inference on every sample and returns average batch loss and accuracy.
+// Loss is averaged over full batches only, accuracy over all samples.
+func (model *Sequential) Test(X, y *[]float64) (float64, float64) {
+	if model.lossFunction == nil {
+		panic("Sequential fail:\n\tLoss function must be set before testing")
+	}
+	nSamples := model.numberOfSamples(X, y)
+	if nSamples < model.batchSize {
+		mess := fmt.Sprintf(
+			"Test fail:\n\tNumber of samples (%d) is smaller than batch size (%d)",
+			nSamples,
+			model.batchSize,
+		)
+		panic(mess)
+	}
+
+	model.SetTraining(false)
+	model.correctGuesses = 0
+	model.totalGuesses = 0
+	totalLoss := 0.0
+	nBatches := 0
+	for start := 0; start < nSamples; start += model.batchSize {
+		batchIdxs := make([]int, 0, model.batchSize)
+		for idx := start; idx < min(start+model.batchSize, nSamples); idx++ {
+			batchIdxs = append(batchIdxs, idx)
+		}
+		yHats := batchVecs(model.forward(model.batchInput(X, batchIdxs)))
+		labels := model.batchLabels(y, batchIdxs)
+		for i := range yHats {
+			model.countGuess(&yHats[i], &labels[i])
+		}
+		if len(yHats) == model.batchSize {
+			totalLoss += model.lossFunction.CalculateAvg(&yHats, &labels)
+			nBatches++
+		}
+	}
+	return totalLoss/float64(nBatches) + model.penalty(), model.accuracy()
+}
+
+// Whole batch goes forward and back at once, layers sum grads over the batch.
+// Returned loss includes penalties of weights before the update.
+func (model *Sequential) trainBatch(X, y *[]float64, batchIdxs []int) float64 {
+	output := model.forward(model.batchInput(X, batchIdxs))
+	yHats := batchVecs(output)
+	labels := model.batchLabels(y, batchIdxs)
+	for i := range yHats {
+		model.countGuess(&yHats[i], &labels[i])
+	}
+
+	penalty := model.penalty()
+	grads := model.lossFunction.Gradient(&yHats, &labels)
+	model.backward(gradsLike(output, grads))
+	return model.lossFunction.CalculateAvg(&yHats, &labels) + penalty
+}
+
+func (model *Sequential) forward(input *tensor.Tensor) *tensor.Tensor {
+	for _, layer := range model.layers {
+		input = layer.Forward(input)
+	}
+	return input
+}
+
+// Sum of penalties of all regularized layers
+func (model *Sequential) penalty() float64 {
+	penalty := 0.0
+	for _, layer := range model.layers {
+		if regularizedLayer, ok := layer.(layers.LayerRegularized); ok {
+			penalty += regularizedLayer.Penalty()
+		}
+	}
+	return penalty
+}
+
+func (model *Sequential) backward(grads *tensor.Tensor) {
+	model.optimizer.Backward(&model.layers, grads)
+}
+
+// Propagates grads back to the network input without updating any layer
+func (model *Sequential) inputGrads(grads *tensor.Tensor) *tensor.Tensor {
+	for _, layer := range slices.Backward(model.layers) {
+		grads = layer.Backward(grads)
+	}
+	return grads
+}
+
+func (model *Sequential) scheduleBatch(loss float64) {
+	if model.scheduler != nil && model.schedulePerBatch {
+		model.scheduler.Step(loss)
+	}
+}
+
+func (model *Sequential) scheduleEpoch(loss float64) {
+	if model.scheduler != nil && !model.schedulePerBatch {
+		model.scheduler.Step(loss)
+	}
+}
+
+// Switches layers like dropout and batch normalization between training and evaluation
+// behaviour. Train enables training mode and Test disables it.
+func (model *Sequential) SetTraining(training bool) {
+	model.training = training
+	for _, layer := range model.layers {
+		if modeLayer, ok := layer.(layers.LayerTrainMode); ok {
+			modeLayer.SetTraining(training)
+		}
+	}
+}
+
+// Wraps flat samples (N x C x H x W values) into model input, data is shared
+func (model *Sequential) inputTensor(data []float64, n int) *tensor.Tensor {
+	return tensor.New(n, model.inputChannels, model.inputSize[0], model.inputSize[1], data)
+}
+
+func (model *Sequential) batchInput(X *[]float64, batchIdxs []int) *tensor.Tensor {
+	data := make([]float64, 0, len(batchIdxs)*model.sampleLen())
+	for _, idx := range batchIdxs {
+		data = append(data, model.sampleAt(X, idx)...)
+	}
+	return model.inputTensor(data, len(batchIdxs))
+}
+
+func (model *Sequential) batchLabels(y *[]float64, batchIdxs []int) []mat.VecDense {
+	retVal := make([]mat.VecDense, len(batchIdxs))
+	for i, idx := range batchIdxs {
+		retVal[i] = *mat.NewVecDense(model.outputLen, model.labelAt(y, idx))
+	}
+	return retVal
+}
+
+// Copies every sample of the batch into a flat vector
+func batchVecs(output *tensor.Tensor) []mat.VecDense {
+	retVal := make([]mat.VecDense, output.Len())
+	for i := range retVal {
+		retVal[i] = *mat.VecDenseCopyOf(output.Vec(i))
+	}
+	return retVal
+}
+
+// Per sample flat grads shaped like the output they were computed for
+func gradsLike(output *tensor.Tensor, grads []mat.VecDense) *tensor.Tensor {
+	_, c, h, w := output.Dims()
+	return tensor.FromVecs(grads).Reshape(c, h, w)
+}
+
+func (model *Sequential) countGuess(yHat, y *mat.VecDense) {
+	model.totalGuesses++
+	if isCorrectGuess(yHat, y) {
+		model.correctGuesses++
+	}
+}
+
+func isCorrectGuess(yHat, y *mat.VecDense) bool {
+	if yHat.Len() == 1 {
+		predicted := 0.0
+		if yHat.AtVec(0) >= 0.5 {
+			predicted = 1.0
+		}
+		return predicted == y.AtVec(0)
+	}
+	return argMax(yHat) == argMax(y)
+}
+
+func argMax(v *mat.VecDense) int {
+	retVal := 0
+	for i := range v.Len() {
+		if v.AtVec(i) > v.AtVec(retVal) {
+			retVal = i
+		}
+	}
+	return retVal
+}
+
+func (model *Sequential) accuracy() float64 {
+	if model.totalGuesses == 0 {
+		return 0.0
+	}
+	return float64(model.correctGuesses) / float64(model.totalGuesses)
+}
+
+func (model *Sequential) sampleLen() int {
+	return model.inputChannels * model.inputSize[0] * model.inputSize[1]
+}
+
+func (model *Sequential) sampleAt(X *[]float64, idx int) []float64 {
+	sampleLen := model.sampleLen()
+	return (*X)[idx*sampleLen : (idx+1)*sampleLen]
+}
+
+func (model *Sequential) labelAt(y *[]float64, idx int) []float64 {
+	return slices.Clone((*y)[idx*model.outputLen : (idx+1)*model.outputLen])
+}
+
+func (model *Sequential) numberOfSamples(X, y *[]float64) int {
+	sampleLen := model.sampleLen()
+	if len(*X)%sampleLen != 0 || len(*y)%model.outputLen != 0 ||
+		len(*X)/sampleLen != len(*y)/model.outputLen {
+		mess := fmt.Sprintf(
+			"Sequential fail:\n\tX length (%d) and y length (%d) don't match sample length (%d) and output length (%d)",
+			len(*X),
+			len(*y),
+			sampleLen,
+			model.outputLen,
+		)
+		panic(mess)
+	}
+	return len(*X) / sampleLen
+}
+
+func (model *Sequential) checkReadyToTrain() {
+	if model.optimizer == nil || model.lossFunction == nil {
+		panic("Sequential fail:\n\tOptimizer and loss function must be set before training")
+	}
+	if len(model.layers) == 0 {
+		panic("Sequential fail:\n\tModel needs at least one layer")
+	}
 }
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"DoodleGan/conv"
+	"DoodleGan/layers"
+)
+
+const sequentialFileVersion = 3
+
+type sequentialFile struct {
+	Version       int           `json:"version"`
+	BatchSize     int           `json:"batch_size"`
+	Epochs        int           `json:"epochs"`
+	InputSize     [2]int        `json:"input_size"`
+	InputChannels int           `json:"input_channels"`
+	OutputLen     int           `json:"output_len"`
+	Layers        []layerConfig `json:"layers"`
+}
+
+// Exactly one of the configs is set
+type layerConfig struct {
+	Conv  *conv.LayerConfig   `json:"conv,omitempty"`
+	Dense *layers.LayerConfig `json:"dense,omitempty"`
+}
+
+// Saves architecture, hyperparameters and trained values of every layer.
+// Optimizer and loss function are not saved.
+func (model *Sequential) Save(filePath string) error {
+	content := sequentialFile{
+		Version:       sequentialFileVersion,
+		BatchSize:     model.batchSize,
+		Epochs:        model.epochs,
+		InputSize:     model.inputSize,
+		InputChannels: model.inputChannels,
+		OutputLen:     model.outputLen,
+		Layers:        make([]layerConfig, len(model.layers)),
+	}
+	for i, layer := range model.layers {
+		if config, err := layers.GetLayerConfig(layer); err == nil {
+			content.Layers[i].Dense = &config
+			continue
+		}
+		config, err := conv.GetLayerConfig(layer)
+		if err != nil {
+			return err
+		}
+		content.Layers[i].Conv = &config
+	}
+
+	data, err := json.Marshal(&content)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o644)
+}
+
+// Replaces architecture and layers of the model with the saved ones.
+// Optimizer and loss function have to be set again before training,
+// a loss built for another batch size is cleared.
+// Invalid values in the file are returned as an error, the model is left unchanged.
+func (model *Sequential) Load(filePath string) (err error) {
+	defer recoverLoadError(&err)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var content sequentialFile
+	if err := json.Unmarshal(data, &content); err != nil {
+		return err
+	}
+	if content.Version != sequentialFileVersion {
+		return fmt.Errorf(
+			"Load fail: unsupported file version %d, expected %d",
+			content.Version,
+			sequentialFileVersion,
+		)
+	}
+	rng := model.rng
+	if rng == nil {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	// Checks hyperparameters the same way as for a new model
+	loaded := NewSequential(
+		content.BatchSize,
+		content.Epochs,
+		content.InputSize,
+		content.InputChannels,
+		content.OutputLen,
+		rng,
+	)
+
+	modelLayers := make([]layers.Layer, len(content.Layers))
+	for i, config := range content.Layers {
+		switch {
+		case config.Dense != nil:
+			modelLayers[i], err = layers.NewLayerFromConfig(config.Dense, loaded.rng)
+		case config.Conv != nil:
+			modelLayers[i], err = conv.NewLayerFromConfig(config.Conv, loaded.rng)
+		default:
+			err = fmt.Errorf("Load fail: layer %d has no config", i)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if model.lossFunction != nil && model.lossFunction.GetBatchSize() != loaded.batchSize {
+		model.lossFunction = nil
+	}
+	model.batchSize = loaded.batchSize
+	model.epochs = loaded.epochs
+	model.inputSize = loaded.inputSize
+	model.inputChannels = loaded.inputChannels
+	model.outputLen = loaded.outputLen
+	model.rng = loaded.rng
+	model.training = loaded.training
+	model.layers = modelLayers
+	return nil
+}
+
+// Layer and model constructors panic on invalid values,
+// a corrupted or hand edited file gets an error instead
+func recoverLoadError(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("Load fail: invalid file content: %v", r)
+	}
+}
@@ -1,6 +1,592 @@
 package models_test
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/losses"
+	"DoodleGan/models"
+	"DoodleGan/optimizers"
+	"DoodleGan/regularizers"
+)
 
 func TestSequential_1(t *testing.T) {
+	dense := layers.NewDenseLayer(2, 1)
+	weights := []float64{0.5, -0.5}
+	bias := []float64{0}
+	dense.LoadWeights(&weights)
+	dense.LoadBias(&bias)
+
+	model := models.NewSequential(2, 20, [2]int{1, 2}, 1, 1, rand.New(rand.NewSource(1)))
+	model.AddDenseLayer(&dense)
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	model.SetOptimizer(&optimizer)
+	loss := losses.NewMeanSquareError(2, 1)
+	model.SetLoss(&loss)
+
+	X := []float64{
+		1, 0,
+		0, 1,
+		1, 1,
+		2, 1,
+	}
+	y := []float64{2, -1, 1, 3}
+	history := model.Train(&X, &y)
+
+	if len(history.Loss) != 20 || len(history.Accuracy) != 20 {
+		fmt.Println(len(history.Loss), len(history.Accuracy))
+		t.Fatal()
+	}
+	if history.Loss[19] >= history.Loss[0] {
+		fmt.Println(history.Loss)
+		t.Fail()
+	}
+	if model.GetTotalGuesses() != 4 {
+		fmt.Println(model.GetTotalGuesses())
+		t.Fail()
+	}
+}
+
+func TestSequential_Conv_1(t *testing.T) {
+	convLayer := conv.NewConv2D([2]int{2, 2}, 2, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	filter := []float64{
+		0.5, -0.5, 0.5, -0.5,
+		-0.5, 0.5, -0.5, 0.5,
+	}
+	convLayer.LoadFilter(&filter)
+	act := conv.NewReLU()
+	dense := layers.NewDenseLayer(8, 1)
+	weights := []float64{0.1, 0.1, 0.1, 0.1, -0.1, -0.1, -0.1, -0.1}
+	bias := []float64{0}
+	dense.LoadWeights(&weights)
+	dense.LoadBias(&bias)
+	sigmoid := layers.NewVSigmoid()
+
+	model := models.NewSequential(2, 10, [2]int{3, 3}, 1, 1, rand.New(rand.NewSource(1)))
+	model.AddConvLayer(&convLayer)
+	model.AddConvLayer(&act)
+	model.AddDenseLayer(&dense)
+	model.AddDenseLayer(&sigmoid)
+	optimizer := optimizers.NewAdam(0.01, 0.9, 0.999, 1e-8)
+	model.SetOptimizer(&optimizer)
+	loss := losses.NewBinaryCrossEntropy(2)
+	model.SetLoss(&loss)
+
+	X := []float64{
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		0, 0, 1, 0, 0, 1, 0, 0, 1,
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		0, 0, 1, 0, 0, 1, 0, 0, 1,
+	}
+	y := []float64{1, 0, 1, 0}
+	history := model.Train(&X, &y)
+
+	if len(history.Loss) != 10 {
+		fmt.Println(len(history.Loss))
+		t.Fatal()
+	}
+	if history.Loss[9] >= history.Loss[0] {
+		fmt.Println(history.Loss)
+		t.Fail()
+	}
+	if history.Accuracy[9] != 1.0 {
+		fmt.Println(history.Accuracy)
+		t.Fail()
+	}
+}
+
+func TestSequential_SaveLoad_1(t *testing.T) {
+	convLayer := conv.NewConv2D([2]int{2, 2}, 2, [2]int{4, 4}, 1, [2]int{1, 1}, [4]int{1, 0, 0, 1})
+	filter := []float64{
+		0.3, -0.2, 0.1, 0.4,
+		-0.1, 0.2, 0.5, -0.3,
+	}
+	convBias := []float64{0.1, -0.1}
+	convLayer.LoadFilter(&filter)
+	convLayer.LoadBias(&convBias)
+	act := conv.NewLeakyReLU(0.1)
+	pool := conv.NewMaxPool([2]int{2, 2}, [2]int{4, 4}, [2]int{2, 2}, 2)
+	dense := layers.NewDenseLayer(8, 2)
+	weights := []float64{
+		0.1, 0.2, -0.1, 0.3, -0.2, 0.1, 0.2, -0.3,
+		-0.1, 0.1, 0.2, -0.2, 0.3, -0.1, 0.1, 0.2,
+	}
+	bias := []float64{0.05, -0.05}
+	dense.LoadWeights(&weights)
+	dense.LoadBias(&bias)
+	denseAct := layers.NewVELU(0.5)
+
+	model := models.NewSequential(2, 1, [2]int{4, 4}, 1, 2, rand.New(rand.NewSource(1)))
+	model.AddConvLayer(&convLayer)
+	model.AddConvLayer(&act)
+	model.AddConvLayer(&pool)
+	model.AddDenseLayer(&dense)
+	model.AddDenseLayer(&denseAct)
+	loss := losses.NewMeanSquareError(2, 2)
+	model.SetLoss(&loss)
+
+	X := []float64{
+		1, 0, 0, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1, 0, 0, 1,
+		0, 2, 1, 0, 1, 0, 0, 1, 2, 0, 1, 1, 0, 1, 0, 0,
+	}
+	y := []float64{1, 0, 0, 1}
+	targetLoss, targetAccuracy := model.Test(&X, &y)
+
+	filePath := t.TempDir() + "/model.json"
+	if err := model.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+	var loaded models.Model = &models.Sequential{}
+	if err := loaded.Load(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loadedSequential := loaded.(*models.Sequential)
+	loadedSequential.SetLoss(&loss)
+	resultLoss, resultAccuracy := loaded.Test(&X, &y)
+
+	if !functools.IsEqualVal(&targetLoss, &resultLoss, 1e-12) {
+		fmt.Println(targetLoss)
+		fmt.Println(resultLoss)
+		t.Fail()
+	}
+	if targetAccuracy != resultAccuracy {
+		fmt.Println(targetAccuracy)
+		fmt.Println(resultAccuracy)
+		t.Fail()
+	}
+}
+
+func TestSequential_Load_Version(t *testing.T) {
+	filePath := t.TempDir() + "/model.json"
+	for _, content := range []string{`{"version": 0}`, `{"version": 2, "dense_layers": []}`} {
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		model := models.Sequential{}
+		if err := model.Load(filePath); err == nil {
+			fmt.Println(content)
+			t.Fail()
+		}
+	}
+}
+
+// Values the constructors panic on are returned as errors
+func TestSequential_Load_Invalid(t *testing.T) {
+	filePath := t.TempDir() + "/model.json"
+	header := `"version": 3, "batch_size": 2, "epochs": 1, "input_size": [3, 3], "input_channels": 1, "output_len": 2`
+	contents := []string{
+		`{"version": 3, "batch_size": -1, "epochs": 1, "input_size": [1, 2], "input_channels": 1, "output_len": 1}`,
+		`{` + header + `, "layers": [{"dense": {"type": "Dense", "n_inputs": -4, "n_neurons": 2}}]}`,
+		`{` + header + `, "layers": [{"dense": {"type": "Dense", "n_inputs": 2, "n_neurons": 2, "weights": [1, 2, 3]}}]}`,
+		`{` + header + `, "layers": [{"conv": {"type": "Conv2D", "kernel_size": [2, 2], "number_of_filters": 1,
+			"input_size": [3, 3], "input_channels": 1, "stride": [1, 1], "filters": [1, 2]}}]}`,
+	}
+	for _, content := range contents {
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		model := models.Sequential{}
+		if err := model.Load(filePath); err == nil {
+			fmt.Println(content)
+			t.Fail()
+		}
+	}
+}
+
+// Loss built for the old batch size can't be used with the loaded one
+func TestSequential_Load_Clears_Loss(t *testing.T) {
+	dense := layers.NewDenseLayer(2, 1)
+	weights := []float64{0.5, -0.5}
+	dense.LoadWeights(&weights)
+	saved := models.NewSequential(4, 1, [2]int{1, 2}, 1, 1, rand.New(rand.NewSource(1)))
+	saved.AddDenseLayer(&dense)
+	filePath := t.TempDir() + "/model.json"
+	if err := saved.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	model := models.NewSequential(2, 1, [2]int{1, 2}, 1, 1, rand.New(rand.NewSource(1)))
+	loss := losses.NewMeanSquareError(2, 1)
+	model.SetLoss(&loss)
+	if err := model.Load(filePath); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	X := []float64{1, 0, 0, 1, 1, 1, 2, 1}
+	y := []float64{2, -1, 1, 3}
+	model.Test(&X, &y)
+}
+
+func TestSequential_Reshape_1(t *testing.T) {
+	dense := layers.NewDenseLayer(2, 8)
+	weights := []float64{
+		0.1, -0.2, 0.3, 0.1, -0.1, 0.2, 0.2, -0.3,
+		-0.2, 0.1, 0.1, 0.3, 0.2, -0.1, -0.3, 0.2,
+	}
+	bias := make([]float64, 8)
+	dense.LoadWeights(&weights)
+	dense.LoadBias(&bias)
+	denseAct := layers.NewVLeakyReLU(0.1)
+	reshape := conv.NewReshape([2]int{2, 2}, 2)
+	convT := conv.NewConv2DTranspose(
+		[2]int{2, 2}, 1, [2]int{2, 2}, 2, [2]int{2, 2}, [4]int{0, 0, 0, 0}, [2]int{0, 0},
+	)
+	filter := []float64{
+		0.2, -0.1, 0.1, 0.3,
+		-0.2, 0.1, 0.3, -0.1,
+	}
+	convT.LoadFilter(&filter)
+	convAct := conv.NewSigmoid()
+
+	model := models.NewSequential(2, 30, [2]int{1, 2}, 1, 16, rand.New(rand.NewSource(1)))
+	model.AddDenseLayer(&dense)
+	model.AddDenseLayer(&denseAct)
+	model.AddConvLayer(&reshape)
+	model.AddConvLayer(&convT)
+	model.AddConvLayer(&convAct)
+	optimizer := optimizers.NewAdam(0.01, 0.9, 0.999, 1e-8)
+	model.SetOptimizer(&optimizer)
+	loss := losses.NewMeanSquareError(2, 16)
+	model.SetLoss(&loss)
+
+	X := []float64{
+		1, 0,
+		0, 1,
+	}
+	y := []float64{
+		1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0,
+		0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1,
+	}
+	history := model.Train(&X, &y)
+	if history.Loss[29] >= history.Loss[0] {
+		fmt.Println(history.Loss)
+		t.Fail()
+	}
+
+	targetLoss, _ := model.Test(&X, &y)
+	filePath := t.TempDir() + "/model.json"
+	if err := model.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded := models.Sequential{}
+	if err := loaded.Load(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded.SetLoss(&loss)
+	resultLoss, _ := loaded.Test(&X, &y)
+	if !functools.IsEqualVal(&targetLoss, &resultLoss, 1e-12) {
+		fmt.Println(targetLoss)
+		fmt.Println(resultLoss)
+		t.Fail()
+	}
+}
+
+// Whole dataset is one batch, so batch statistics never collapse on duplicate samples
+func TestSequential_BatchNorm_1(t *testing.T) {
+	convLayer := conv.NewConv2D([2]int{2, 2}, 2, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	filter := []float64{
+		0.5, -0.5, 0.5, -0.5,
+		-0.5, 0.5, -0.5, 0.5,
+	}
+	convLayer.LoadFilter(&filter)
+	convNorm := conv.NewBatchNorm2D(2, 0.9, 1e-5)
+	act := conv.NewReLU()
+	dense := layers.NewDenseLayer(8, 2)
+	weights := []float64{
+		0.1, 0.1, 0.1, 0.1, -0.1, -0.1, -0.1, -0.1,
+		-0.1, 0.2, 0.1, -0.1, 0.1, -0.2, 0.1, 0.1,
+	}
+	dense.LoadWeights(&weights)
+	denseNorm := layers.NewBatchNorm1D(2, 0.9, 1e-5)
+	softmax := layers.NewSoftmax()
+
+	model := models.NewSequential(4, 20, [2]int{3, 3}, 1, 2, rand.New(rand.NewSource(1)))
+	model.AddConvLayer(&convLayer)
+	model.AddConvLayer(&convNorm)
+	model.AddConvLayer(&act)
+	model.AddDenseLayer(&dense)
+	model.AddDenseLayer(&denseNorm)
+	model.AddDenseLayer(&softmax)
+	optimizer := optimizers.NewAdam(0.01, 0.9, 0.999, 1e-8)
+	model.SetOptimizer(&optimizer)
+	loss := losses.NewCrossEntropy(4, 2)
+	model.SetLoss(&loss)
+
+	X := []float64{
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		0, 0, 1, 0, 0, 1, 0, 0, 1,
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		0, 0, 1, 0, 0, 1, 0, 0, 1,
+	}
+	y := []float64{1, 0, 0, 1, 1, 0, 0, 1}
+	history := model.Train(&X, &y)
+	if history.Loss[19] >= history.Loss[0] {
+		fmt.Println(history.Loss)
+		t.Fail()
+	}
+
+	targetLoss, _ := model.Test(&X, &y)
+	filePath := t.TempDir() + "/model.json"
+	if err := model.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded := models.Sequential{}
+	if err := loaded.Load(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded.SetLoss(&loss)
+	resultLoss, _ := loaded.Test(&X, &y)
+	if !functools.IsEqualVal(&targetLoss, &resultLoss, 1e-12) {
+		fmt.Println(targetLoss)
+		fmt.Println(resultLoss)
+		t.Fail()
+	}
+}
+
+// Dropout is disabled by Test, so evaluation doesn't depend on the random generator
+func TestSequential_Dropout_1(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	convLayer := conv.NewConv2D([2]int{2, 2}, 2, [2]int{3, 3}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	filter := []float64{
+		0.5, -0.5, 0.5, -0.5,
+		-0.5, 0.5, -0.5, 0.5,
+	}
+	convLayer.LoadFilter(&filter)
+	convDropout := conv.NewDropout2D(0.25, rng)
+	dense := layers.NewDenseLayer(8, 2)
+	weights := []float64{
+		0.1, 0.1, 0.1, 0.1, -0.1, -0.1, -0.1, -0.1,
+		-0.1, 0.2, 0.1, -0.1, 0.1, -0.2, 0.1, 0.1,
+	}
+	dense.LoadWeights(&weights)
+	denseDropout := layers.NewDropout(0.25, rng)
+	softmax := layers.NewSoftmax()
+
+	model := models.NewSequential(2, 10, [2]int{3, 3}, 1, 2, rand.New(rand.NewSource(1)))
+	model.AddConvLayer(&convLayer)
+	model.AddConvLayer(&convDropout)
+	model.AddDenseLayer(&dense)
+	model.AddDenseLayer(&denseDropout)
+	model.AddDenseLayer(&softmax)
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	model.SetOptimizer(&optimizer)
+	loss := losses.NewCrossEntropy(2, 2)
+	model.SetLoss(&loss)
+
+	X := []float64{
+		1, 0, 0, 1, 0, 0, 1, 0, 0,
+		0, 0, 1, 0, 0, 1, 0, 0, 1,
+	}
+	y := []float64{1, 0, 0, 1}
+	model.Train(&X, &y)
+
+	targetLoss, _ := model.Test(&X, &y)
+	resultLoss, _ := model.Test(&X, &y)
+	if !functools.IsEqualVal(&targetLoss, &resultLoss, 1e-12) {
+		fmt.Println(targetLoss)
+		fmt.Println(resultLoss)
+		t.Fail()
+	}
+
+	filePath := t.TempDir() + "/model.json"
+	if err := model.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded := models.Sequential{}
+	if err := loaded.Load(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded.SetLoss(&loss)
+	resultLoss, _ = loaded.Test(&X, &y)
+	if !functools.IsEqualVal(&targetLoss, &resultLoss, 1e-12) {
+		fmt.Println(targetLoss)
+		fmt.Println(resultLoss)
+		t.Fail()
+	}
+}
+
+// Loaded dropout layers draw masks from the model generator
+func TestSequential_Load_Dropout_Reproducible(t *testing.T) {
+	dense := layers.NewDenseLayer(4, 2)
+	weights := []float64{0.1, -0.2, 0.3, 0.1, -0.1, 0.2, 0.1, -0.3}
+	dense.LoadWeights(&weights)
+	dropout := layers.NewDropout(0.5, rand.New(rand.NewSource(1)))
+	convDropout := conv.NewDropout2D(0.5, rand.New(rand.NewSource(1)))
+	model := models.NewSequential(2, 5, [2]int{2, 2}, 1, 2, rand.New(rand.NewSource(1)))
+	model.AddConvLayer(&convDropout)
+	model.AddDenseLayer(&dense)
+	model.AddDenseLayer(&dropout)
+	filePath := t.TempDir() + "/model.json"
+	if err := model.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	X := []float64{1, 0, 0, 1, 0, 1, 1, 0, 1, 1, 0, 0, 0, 0, 1, 1}
+	y := []float64{1, 0, 0, 1, 1, 0, 0, 1}
+	train := func() models.History {
+		loaded := models.Sequential{}
+		if err := loaded.Load(filePath); err != nil {
+			t.Fatal(err)
+		}
+		loaded.SetRand(rand.New(rand.NewSource(7)))
+		optimizer := optimizers.NewSGD(0.1, 0.0)
+		loaded.SetOptimizer(&optimizer)
+		loss := losses.NewMeanSquareError(2, 2)
+		loaded.SetLoss(&loss)
+		return loaded.Train(&X, &y)
+	}
+	history1, history2 := train(), train()
+	if !reflect.DeepEqual(history1, history2) {
+		fmt.Println(history1, history2)
+		t.Fail()
+	}
+}
+
+func TestSequential_Scheduler(t *testing.T) {
+	dense := layers.NewDenseLayer(2, 1)
+	weights := []float64{0.5, -0.5}
+	dense.LoadWeights(&weights)
+	model := models.NewSequential(2, 3, [2]int{1, 2}, 1, 1, rand.New(rand.NewSource(1)))
+	model.AddDenseLayer(&dense)
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	model.SetOptimizer(&optimizer)
+	loss := losses.NewMeanSquareError(2, 1)
+	model.SetLoss(&loss)
+	X := []float64{1, 0, 0, 1, 1, 1, 0, 0}
+	y := []float64{1, 0, 1, 0}
+
+	decay := optimizers.NewExponentialDecay(0.5)
+	scheduler := optimizers.NewScheduledRate(&optimizer, &decay)
+	model.SetScheduler(&scheduler, false)
+	model.Train(&X, &y)
+	targetRate := 0.1 * 0.125
+	resultRate := optimizer.GetLearningRate()
+	if !functools.IsEqualVal(&targetRate, &resultRate, 1e-12) {
+		fmt.Println(resultRate)
+		t.Fail()
+	}
+
+	// Two batches in every epoch
+	model.SetScheduler(&scheduler, true)
+	model.Train(&X, &y)
+	targetRate = 0.1 * math.Pow(0.5, 9)
+	resultRate = optimizer.GetLearningRate()
+	if !functools.IsEqualVal(&targetRate, &resultRate, 1e-12) {
+		fmt.Println(resultRate)
+		t.Fail()
+	}
+}
+
+func TestSequential_Regularizers(t *testing.T) {
+	newModel := func(dense *layers.DenseLayer) models.Sequential {
+		weights := []float64{0.5, -0.5}
+		dense.LoadWeights(&weights)
+		model := models.NewSequential(4, 20, [2]int{1, 2}, 1, 1, rand.New(rand.NewSource(1)))
+		model.AddDenseLayer(dense)
+		optimizer := optimizers.NewSGD(0.1, 0.0)
+		model.SetOptimizer(&optimizer)
+		loss := losses.NewMeanSquareError(4, 1)
+		model.SetLoss(&loss)
+		return model
+	}
+	l2 := regularizers.NewL2(0.1)
+	regularizedDense := layers.NewDenseLayer(2, 1, &l2)
+	plainDense := layers.NewDenseLayer(2, 1)
+	regularizedModel := newModel(&regularizedDense)
+	plainModel := newModel(&plainDense)
+	X := []float64{1, 0, 0, 1, 1, 1, 2, 1}
+	y := []float64{2, -1, 1, 3}
+
+	// 0.1 * (0.5^2 + 0.5^2)
+	targetPenalty := 0.05
+	regularizedLoss, _ := regularizedModel.Test(&X, &y)
+	plainLoss, _ := plainModel.Test(&X, &y)
+	resultPenalty := regularizedLoss - plainLoss
+	if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+		fmt.Println(regularizedLoss, plainLoss)
+		t.Fail()
+	}
+
+	// Single batch per epoch, so both models see the same grads apart from the penalty
+	regularizedModel.Train(&X, &y)
+	plainModel.Train(&X, &y)
+	squareSum := func(weights []float64) float64 {
+		sum := 0.0
+		for _, w := range weights {
+			sum += w * w
+		}
+		return sum
+	}
+	if squareSum(regularizedDense.GetWeightsData()) >= squareSum(plainDense.GetWeightsData()) {
+		fmt.Println(regularizedDense.GetWeightsData(), plainDense.GetWeightsData())
+		t.Fail()
+	}
+}
+
+func TestSequential_SaveLoad_Regularizers(t *testing.T) {
+	newModel := func(convRegularizers, denseRegularizers []regularizers.Regularizer) models.Sequential {
+		convLayer := conv.NewConv2D(
+			[2]int{2, 2}, 1, [2]int{2, 2}, 1, [2]int{1, 1}, [4]int{0, 0, 0, 0}, convRegularizers...,
+		)
+		filter := []float64{0.3, -0.2, 0.1, 0.4}
+		convLayer.LoadFilter(&filter)
+		dense := layers.NewDenseLayer(1, 2, denseRegularizers...)
+		weights := []float64{0.5, -1}
+		dense.LoadWeights(&weights)
+		model := models.NewSequential(1, 1, [2]int{2, 2}, 1, 2, rand.New(rand.NewSource(1)))
+		model.AddConvLayer(&convLayer)
+		model.AddDenseLayer(&dense)
+		return model
+	}
+	l1 := regularizers.NewL1(0.2)
+	l2 := regularizers.NewL2(0.5)
+	model := newModel([]regularizers.Regularizer{&l1}, []regularizers.Regularizer{&l2})
+	plainModel := newModel(nil, nil)
+	loss := losses.NewMeanSquareError(1, 2)
+	model.SetLoss(&loss)
+	plainModel.SetLoss(&loss)
+	X := []float64{1, 0, 0, 1}
+	y := []float64{1, 0}
+
+	filePath := t.TempDir() + "/model.json"
+	if err := model.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded := models.Sequential{}
+	if err := loaded.Load(filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded.SetLoss(&loss)
+
+	// 0.2 * (0.3 + 0.2 + 0.1 + 0.4) + 0.5 * (0.5^2 + 1^2)
+	targetPenalty := 0.825
+	loadedLoss, _ := loaded.Test(&X, &y)
+	plainLoss, _ := plainModel.Test(&X, &y)
+	resultPenalty := loadedLoss - plainLoss
+	if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+		fmt.Println(loadedLoss, plainLoss)
+		t.Fail()
+	}
+}
+
+func TestSequential_SetLoss_batch_size_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	model := models.NewSequential(2, 1, [2]int{1, 2}, 1, 1, rand.New(rand.NewSource(1)))
+	loss := losses.NewMeanSquareError(4, 1)
+	model.SetLoss(&loss)
 }
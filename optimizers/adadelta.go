@@ -0,0 +1,62 @@
+package optimizers
+
+import (
+	"math"
+
+	"DoodleGan/layers"
+	"DoodleGan/tensor"
+)
+
+// Learning rate only scales the step, usually it's left at 1
+type Adadelta struct {
+	learningRate float64
+
+	rhoSquareMechanism
+	clipMechanism
+	squaredUpdates paramMoments
+}
+
+func NewAdadelta(learningRate, rho, eps float64) Adadelta {
+	checkValidLearningRate(&learningRate, "NewAdadelta")
+	checkValidRho(&rho, "NewAdadelta")
+	if eps <= 0.0 {
+		panic("NewAdadelta fail:\n\teps must be greater than 0, otherwise params never change")
+	}
+	return Adadelta{
+		learningRate:       learningRate,
+		rhoSquareMechanism: newRhoSquareMechanism(rho, eps),
+		squaredUpdates:     make(paramMoments),
+	}
+}
+
+func (opt *Adadelta) PreTrainInit(layerList *[]layers.Layer) {
+	opt.initRhoMechanism()
+	opt.squaredUpdates = make(paramMoments)
+}
+
+func (opt *Adadelta) Backward(layerList *[]layers.Layer, grads *tensor.Tensor) *tensor.Tensor {
+	return backpropagate(opt, &opt.clipMechanism, layerList, grads)
+}
+
+// update = sqrt(u + eps) / sqrt(s + eps) * grad, u is running average of squared updates
+func (opt *Adadelta) Step(params []tensor.Param) {
+	for i := range params {
+		squared := opt.rhoUpdate(&params[i])
+		squaredUpdates := opt.squaredUpdates.of(&params[i]).RawData()
+		value := params[i].Value.RawData()
+		for j, g := range params[i].Grad.RawData() {
+			update := math.Sqrt(squaredUpdates[j]+opt.eps) / math.Sqrt(squared[j]+opt.eps) * g
+			squaredUpdates[j] = opt.rho*squaredUpdates[j] + opt.rhoComplement*update*update
+			value[j] -= opt.learningRate * update
+		}
+	}
+}
+
+func (opt *Adadelta) GetLearningRate() float64 {
+	return opt.learningRate
+}
+
+func (opt *Adadelta) SetLearningRate(learningRate float64) {
+	checkValidLearningRate(&learningRate, "Adadelta SetLearningRate")
+	opt.learningRate = learningRate
+}
@@ -0,0 +1,132 @@
+package optimizers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
+)
+
+func TestAdadelta_Conv_1(t *testing.T) {
+	conv1 := conv.NewConv2D([2]int{2, 1}, 2, [2]int{2, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	act1 := conv.NewReLU()
+	conv2 := conv.NewConv2D([2]int{1, 2}, 1, [2]int{1, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+
+	filter1 := []float64{
+		1, -2, -1, 2,
+		2, -1, 2, 1,
+	}
+	bias1 := []float64{
+		1, -1,
+	}
+	filter2 := []float64{
+		3, 1, -2, 2,
+	}
+	bias2 := []float64{
+		2,
+	}
+
+	conv1.LoadFilter(&filter1)
+	conv1.LoadBias(&bias1)
+	conv2.LoadFilter(&filter2)
+	conv2.LoadBias(&bias2)
+	convs := []layers.Layer{&conv1, &act1, &conv2}
+	optimizer := optimizers.NewAdadelta(1.0, 0.9, 1e-2)
+	optimizer.PreTrainInit(&convs)
+
+	input1 := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
+		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
+	}
+	conv1Out1 := conv1.Forward(tensor.FromMats(input1))
+	conv2Out1 := conv2.Forward(act1.Forward(conv1Out1))
+	optimizer.Backward(&convs, gradsLike(conv2Out1, mat.NewVecDense(1, []float64{3})))
+
+	targetFilter1_1 := []mat.Dense{
+		*mat.NewDense(2, 1, []float64{0.684, -1.684}),
+		*mat.NewDense(2, 1, []float64{-1, 1.684}),
+		*mat.NewDense(2, 1, []float64{2.316, -1.316}),
+		*mat.NewDense(2, 1, []float64{2.316, 1.316}),
+	}
+	targetBias1_1 := []float64{0.684, -0.684}
+	targetFilter2_1 := []mat.Dense{
+		*mat.NewDense(1, 2, []float64{2.684, 0.684}),
+		*mat.NewDense(1, 2, []float64{-2.316, 2}),
+	}
+	targetBias2_1 := []float64{1.686}
+	if !functools.IsEqualMatSlice(&targetFilter1_1, conv1.GetFilter(), 0.001) {
+		fmt.Println("I FILTER 1")
+		functools.PrintMatSlice(&targetFilter1_1, 3)
+		functools.PrintMatSlice(conv1.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias1_1, conv1.GetBias(), 0.001) {
+		fmt.Println("I BIAS 1")
+		fmt.Println(targetBias1_1)
+		fmt.Println(*conv1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilter2_1, conv2.GetFilter(), 0.001) {
+		fmt.Println("II FILTER 1")
+		functools.PrintMatSlice(&targetFilter2_1, 3)
+		functools.PrintMatSlice(conv2.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias2_1, conv2.GetBias(), 0.001) {
+		fmt.Println("II BIAS 1")
+		fmt.Println(targetBias2_1)
+		fmt.Println(*conv2.GetBias())
+		t.Fail()
+	}
+
+	input2 := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, 2, -5, 0}),
+		*mat.NewDense(2, 2, []float64{3, -2, 2, 2}),
+	}
+	conv1Out2 := conv1.Forward(tensor.FromMats(input2))
+	conv2Out2 := conv2.Forward(act1.Forward(conv1Out2))
+	optimizer.Backward(&convs, gradsLike(conv2Out2, mat.NewVecDense(1, []float64{2})))
+
+	targetFilter1_2 := []mat.Dense{
+		*mat.NewDense(2, 1, []float64{0.515, -1.258}),
+		*mat.NewDense(2, 1, []float64{-1.316, 1.557}),
+		*mat.NewDense(2, 1, []float64{2.189, -1.717}),
+		*mat.NewDense(2, 1, []float64{2.748, 1.341}),
+	}
+	targetBias1_2 := []float64{0.456, -0.635}
+	targetFilter2_2 := []mat.Dense{
+		*mat.NewDense(1, 2, []float64{2.481, 0.417}),
+		*mat.NewDense(1, 2, []float64{-2.652, 1.685}),
+	}
+	targetBias2_2 := []float64{1.43}
+	if !functools.IsEqualMatSlice(&targetFilter1_2, conv1.GetFilter(), 0.001) {
+		fmt.Println("I FILTER 2")
+		functools.PrintMatSlice(&targetFilter1_2, 3)
+		functools.PrintMatSlice(conv1.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias1_2, conv1.GetBias(), 0.001) {
+		fmt.Println("I BIAS 2")
+		fmt.Println(targetBias1_2)
+		fmt.Println(*conv1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilter2_2, conv2.GetFilter(), 0.001) {
+		fmt.Println("II FILTER 2")
+		functools.PrintMatSlice(&targetFilter2_2, 3)
+		functools.PrintMatSlice(conv2.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias2_2, conv2.GetBias(), 0.001) {
+		fmt.Println("II BIAS 2")
+		fmt.Println(targetBias2_2)
+		fmt.Println(*conv2.GetBias())
+		t.Fail()
+	}
+}
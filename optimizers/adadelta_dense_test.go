@@ -0,0 +1,99 @@
+package optimizers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
+)
+
+func TestAdadelta_Dense_1(t *testing.T) {
+	dense1 := layers.NewDenseLayer(3, 2)
+	weights1 := []float64{3, 2, 1, -3, 2, 2}
+	bias1 := []float64{2, -2}
+	dense1.LoadWeights(&weights1)
+	dense1.LoadBias(&bias1)
+	act := layers.NewVReLU()
+	dense2 := layers.NewDenseLayer(2, 1)
+	weights2 := []float64{1, 2}
+	bias2 := []float64{0}
+	dense2.LoadWeights(&weights2)
+	dense2.LoadBias(&bias2)
+
+	denses := []layers.Layer{&dense1, &act, &dense2}
+	optimizer := optimizers.NewAdadelta(1.0, 0.9, 1e-2)
+	optimizer.PreTrainInit(&denses)
+
+	input1 := mat.NewVecDense(3, []float64{1, -2, 4})
+	output1 := dense1.Forward(tensor.FromVec(input1))
+	dense2.Forward(act.Forward(output1))
+	optimizer.Backward(&denses, tensor.FromVec(mat.NewVecDense(1, []float64{5})))
+
+	targetWeights1_1 := mat.NewDense(2, 3, []float64{2.684, 2.316, 0.684, -3, 2, 2})
+	targetBias1_1 := mat.NewVecDense(2, []float64{1.684, -2})
+	targetWeights2_1 := mat.NewDense(1, 2, []float64{0.684, 2})
+	targetBias2_1 := mat.NewVecDense(1, []float64{-0.316})
+	if !functools.IsEqualMat(targetWeights1_1, dense1.GetWeights(), 0.001) {
+		fmt.Println("== I WEIGHTS 1 ==")
+		functools.PrintMat(targetWeights1_1, 3)
+		functools.PrintMat(dense1.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias1_1, dense1.GetBias(), 0.001) {
+		fmt.Println("== I BIAS 1 ==")
+		fmt.Println(targetBias1_1)
+		fmt.Println(dense1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMat(targetWeights2_1, dense2.GetWeights(), 0.001) {
+		fmt.Println("== II WEIGHTS 1 ==")
+		functools.PrintMat(targetWeights2_1, 3)
+		functools.PrintMat(dense2.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias2_1, dense2.GetBias(), 0.001) {
+		fmt.Println("== II BIAS 1 ==")
+		fmt.Println(targetBias2_1)
+		fmt.Println(dense2.GetBias())
+		t.Fail()
+	}
+
+	input2 := mat.NewVecDense(3, []float64{2, 1, -1})
+	output2 := dense1.Forward(tensor.FromVec(input2))
+	dense2.Forward(act.Forward(output2))
+	optimizer.Backward(&denses, tensor.FromVec(mat.NewVecDense(1, []float64{-3})))
+
+	targetWeights1_2 := mat.NewDense(2, 3, []float64{2.976, 2.411, 0.636, -3, 2, 2})
+	targetBias1_2 := mat.NewVecDense(2, []float64{1.861, -2})
+	targetWeights2_2 := mat.NewDense(1, 2, []float64{1.014, 2})
+	targetBias2_2 := mat.NewVecDense(1, []float64{-0.077})
+	if !functools.IsEqualMat(targetWeights1_2, dense1.GetWeights(), 0.001) {
+		fmt.Println("== I WEIGHTS 2 ==")
+		functools.PrintMat(targetWeights1_2, 3)
+		functools.PrintMat(dense1.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias1_2, dense1.GetBias(), 0.001) {
+		fmt.Println("== I BIAS 2 ==")
+		fmt.Println(targetBias1_2)
+		fmt.Println(dense1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMat(targetWeights2_2, dense2.GetWeights(), 0.001) {
+		fmt.Println("== II WEIGHTS 2 ==")
+		functools.PrintMat(targetWeights2_2, 3)
+		functools.PrintMat(dense2.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias2_2, dense2.GetBias(), 0.001) {
+		fmt.Println("== II BIAS 2 ==")
+		fmt.Println(targetBias2_2)
+		fmt.Println(dense2.GetBias())
+		t.Fail()
+	}
+}
@@ -0,0 +1,55 @@
+package optimizers
+
+import (
+	"DoodleGan/layers"
+	"DoodleGan/tensor"
+)
+
+type Adagrad struct {
+	learningRate float64
+
+	rhoSquareMechanism
+	clipMechanism
+}
+
+func NewAdagrad(learningRate, eps float64) Adagrad {
+	checkValidLearningRate(&learningRate, "NewAdagrad")
+	checkValidEps(&eps, "NewAdagrad")
+	return Adagrad{
+		learningRate: learningRate,
+		// Squares are summed over all steps instead of averaged
+		rhoSquareMechanism: rhoSquareMechanism{
+			rho:           1.0,
+			rhoComplement: 1.0,
+			eps:           eps,
+			squared:       make(paramMoments),
+		},
+	}
+}
+
+func (opt *Adagrad) PreTrainInit(layerList *[]layers.Layer) {
+	opt.initRhoMechanism()
+}
+
+func (opt *Adagrad) Backward(layerList *[]layers.Layer, grads *tensor.Tensor) *tensor.Tensor {
+	return backpropagate(opt, &opt.clipMechanism, layerList, grads)
+}
+
+func (opt *Adagrad) Step(params []tensor.Param) {
+	for i := range params {
+		squared := opt.rhoUpdate(&params[i])
+		value := params[i].Value.RawData()
+		for j, g := range params[i].Grad.RawData() {
+			value[j] -= opt.learningRate * g / opt.root(squared[j])
+		}
+	}
+}
+
+func (opt *Adagrad) GetLearningRate() float64 {
+	return opt.learningRate
+}
+
+func (opt *Adagrad) SetLearningRate(learningRate float64) {
+	checkValidLearningRate(&learningRate, "Adagrad SetLearningRate")
+	opt.learningRate = learningRate
+}
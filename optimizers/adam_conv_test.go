@@ -10,6 +10,7 @@ import (
 	"DoodleGan/functools"
 	"DoodleGan/layers"
 	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
 )
 
 func TestAdam_zero_momentum_zero_rho(t *testing.T) {
@@ -35,20 +36,20 @@ func TestAdam_zero_momentum_zero_rho(t *testing.T) {
 	conv1.LoadBias(&bias1)
 	conv2.LoadFilter(&filter2)
 	conv2.LoadBias(&bias2)
-	convs := []conv.ConvLayer{&conv1, &act1, &conv2}
+	convs := []layers.Layer{&conv1, &act1, &conv2}
 
 	input1 := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
 		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
 	}
 
-	conv1.Forward(&input1)
-	activated1 := act1.Forward(conv1.DeflatOutput())
-	conv2.Forward(activated1)
+	conv1Out := conv1.Forward(tensor.FromMats(input1))
+	activated1 := act1.Forward(conv1Out)
+	conv2Out := conv2.Forward(activated1)
 
 	optimizer := optimizers.NewAdam(0.1, 0, 0, 1e-8)
-	optimizer.PreTrainInit([2]int{1, 1}, &convs, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&convs, mat.NewVecDense(1, []float64{3}))
+	optimizer.PreTrainInit(&convs)
+	optimizer.Backward(&convs, gradsLike(conv2Out, mat.NewVecDense(1, []float64{3})))
 
 	resultFilter1_1 := conv1.GetFilter()
 	targetFilter1_1 := []mat.Dense{
@@ -115,21 +116,20 @@ func TestAdam_zero_momentum(t *testing.T) {
 	conv1.LoadBias(&bias1)
 	conv2.LoadFilter(&filter2)
 	conv2.LoadBias(&bias2)
-	convs := []conv.ConvLayer{&conv1, &act1, &conv2}
+	convs := []layers.Layer{&conv1, &act1, &conv2}
 
 	input1 := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
 		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
 	}
 
-	conv1.Forward(&input1)
-	activated1 := act1.Forward(conv1.DeflatOutput())
-	conv2.Forward(activated1)
+	conv1Out := conv1.Forward(tensor.FromMats(input1))
+	activated1 := act1.Forward(conv1Out)
+	conv2Out := conv2.Forward(activated1)
 
 	optimizer := optimizers.NewAdam(0.1, 0.0, 0.9, 1e-8)
-	optimizer.PreTrainInit([2]int{1, 1}, &convs, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&convs, mat.NewVecDense(1, []float64{3}))
-	optimizer.UpdateCorrectionDecay()
+	optimizer.PreTrainInit(&convs)
+	optimizer.Backward(&convs, gradsLike(conv2Out, mat.NewVecDense(1, []float64{3})))
 
 	resultFilter1_1 := conv1.GetFilter()
 	targetFilter1_1 := []mat.Dense{
@@ -177,12 +177,11 @@ func TestAdam_zero_momentum(t *testing.T) {
 		*mat.NewDense(2, 2, []float64{3, -2, 2, 2}),
 	}
 
-	conv1.Forward(&input2)
-	activated2 := act1.Forward(conv1.DeflatOutput())
-	conv2.Forward(activated2)
+	conv1Out = conv1.Forward(tensor.FromMats(input2))
+	activated2 := act1.Forward(conv1Out)
+	conv2Out = conv2.Forward(activated2)
 
-	optimizer.BackwardConv2DLayers(&convs, mat.NewVecDense(1, []float64{2}))
-	optimizer.UpdateCorrectionDecay()
+	optimizer.Backward(&convs, gradsLike(conv2Out, mat.NewVecDense(1, []float64{2})))
 
 	resultFilter1_2 := conv1.GetFilter()
 	targetFilter1_2 := []mat.Dense{
@@ -249,21 +248,20 @@ func TestAdam_zero_rho(t *testing.T) {
 	conv1.LoadBias(&bias1)
 	conv2.LoadFilter(&filter2)
 	conv2.LoadBias(&bias2)
-	convs := []conv.ConvLayer{&conv1, &act1, &conv2}
+	convs := []layers.Layer{&conv1, &act1, &conv2}
 
 	input1 := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
 		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
 	}
 
-	conv1.Forward(&input1)
-	activated1 := act1.Forward(conv1.DeflatOutput())
-	conv2.Forward(activated1)
+	conv1Out := conv1.Forward(tensor.FromMats(input1))
+	activated1 := act1.Forward(conv1Out)
+	conv2Out := conv2.Forward(activated1)
 
 	optimizer := optimizers.NewAdam(0.1, 0.9, 0.0, 1e-8)
-	optimizer.PreTrainInit([2]int{1, 1}, &convs, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&convs, mat.NewVecDense(1, []float64{3}))
-	optimizer.UpdateCorrectionDecay()
+	optimizer.PreTrainInit(&convs)
+	optimizer.Backward(&convs, gradsLike(conv2Out, mat.NewVecDense(1, []float64{3})))
 
 	// fmt.Println("****************************")
 	// functools.PrintMatSlice(conv1.GetFilterGrads(), 2)
@@ -324,7 +322,6 @@ func TestAdam_zero_rho(t *testing.T) {
 	// conv2.Forward(activated2)
 	//
 	// optimizer.BackwardConv2DLayers(&convs, mat.NewVecDense(1, []float64{2}))
-	// optimizer.UpdateCorrectionDecay()
 	//
 	// resultFilter1_2 := conv1.GetFilter()
 	// targetFilter1_2 := []mat.Dense{
@@ -0,0 +1,50 @@
+package optimizers
+
+import (
+	"fmt"
+
+	"DoodleGan/layers"
+	"DoodleGan/tensor"
+)
+
+// Adam with weight decay decoupled from the grads, applied to every param
+type AdamW struct {
+	Adam
+
+	weightDecay float64
+}
+
+func NewAdamW(learningRate, momentum, rho, eps, weightDecay float64) AdamW {
+	checkValidWeightDecay(&weightDecay, "NewAdamW")
+	return AdamW{
+		Adam:        NewAdam(learningRate, momentum, rho, eps),
+		weightDecay: weightDecay,
+	}
+}
+
+func (opt *AdamW) Backward(layerList *[]layers.Layer, grads *tensor.Tensor) *tensor.Tensor {
+	return backpropagate(opt, &opt.clipMechanism, layerList, grads)
+}
+
+// Params are decayed before the Adam step
+func (opt *AdamW) Step(params []tensor.Param) {
+	decay := 1.0 - opt.learningRate*opt.weightDecay
+	for i := range params {
+		value := params[i].Value.RawData()
+		for j := range value {
+			value[j] *= decay
+		}
+	}
+	opt.Adam.Step(params)
+}
+
+func (opt *AdamW) SetLearningRate(learningRate float64) {
+	checkValidLearningRate(&learningRate, "AdamW SetLearningRate")
+	opt.learningRate = learningRate
+}
+
+func checkValidWeightDecay(weightDecay *float64, funcName string) {
+	if *weightDecay < 0.0 {
+		panic(fmt.Sprintf("%s fail:\n\tweight decay can't be less than 0", funcName))
+	}
+}
@@ -0,0 +1,80 @@
+package optimizers
+
+import (
+	"math"
+
+	"DoodleGan/tensor"
+)
+
+// Zero fields disable the clipping, enabled ones apply in order of fields.
+// Grads are clipped in place, so layer grads getters return clipped grads.
+type Clipping struct {
+	Value      float64 // each grad to [-Value, Value]
+	Norm       float64 // L2 norm of every param grad separately
+	GlobalNorm float64 // L2 norm of all param grads of a backward pass together
+}
+
+type clipMechanism struct {
+	clipping Clipping
+	gradNorm float64
+}
+
+func (c *clipMechanism) SetClipping(clipping Clipping) {
+	if clipping.Value < 0.0 || clipping.Norm < 0.0 || clipping.GlobalNorm < 0.0 {
+		panic("SetClipping fail:\n\tclipping thresholds can't be less than 0")
+	}
+	c.clipping = clipping
+}
+
+func (c *clipMechanism) GetClipping() Clipping {
+	return c.clipping
+}
+
+// Global L2 norm of grads of the last backward pass before clipping
+func (c *clipMechanism) GradNorm() float64 {
+	return c.gradNorm
+}
+
+func (c *clipMechanism) clip(params []tensor.Param) {
+	c.gradNorm = globalNorm(params)
+	if c.clipping.Value != 0.0 {
+		for i := range params {
+			grad := params[i].Grad.RawData()
+			for j, g := range grad {
+				grad[j] = math.Max(-c.clipping.Value, math.Min(c.clipping.Value, g))
+			}
+		}
+	}
+	if c.clipping.Norm != 0.0 {
+		for i := range params {
+			scaleToNorm(params[i:i+1], globalNorm(params[i:i+1]), c.clipping.Norm)
+		}
+	}
+	if c.clipping.GlobalNorm != 0.0 {
+		scaleToNorm(params, globalNorm(params), c.clipping.GlobalNorm)
+	}
+}
+
+func globalNorm(params []tensor.Param) float64 {
+	sum := 0.0
+	for i := range params {
+		for _, g := range params[i].Grad.RawData() {
+			sum += g * g
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// Grads are left as they are when norm is within maxNorm
+func scaleToNorm(params []tensor.Param, norm, maxNorm float64) {
+	if norm <= maxNorm {
+		return
+	}
+	scale := maxNorm / norm
+	for i := range params {
+		grad := params[i].Grad.RawData()
+		for j := range grad {
+			grad[j] *= scale
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package optimizers_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/conv"
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
+)
+
+// Weights grads {6, -8}, bias grads {2}
+func clippedDenseStep(clipping optimizers.Clipping) (layers.DenseLayer, float64) {
+	dense := layers.NewDenseLayer(2, 1)
+	weights := []float64{1, 2}
+	bias := []float64{0}
+	dense.LoadWeights(&weights)
+	dense.LoadBias(&bias)
+	nn := []layers.Layer{&dense}
+
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	optimizer.SetClipping(clipping)
+	optimizer.PreTrainInit(&nn)
+	dense.Forward(tensor.FromVec(mat.NewVecDense(2, []float64{3, -4})))
+	optimizer.Backward(&nn, tensor.FromVec(mat.NewVecDense(1, []float64{2})))
+	return dense, optimizer.GradNorm()
+}
+
+func TestClipping_Dense(t *testing.T) {
+	tests := []struct {
+		name          string
+		clipping      optimizers.Clipping
+		targetWeights []float64
+		targetBias    []float64
+	}{
+		{"none", optimizers.Clipping{}, []float64{0.4, 2.8}, []float64{-0.2}},
+		{"value", optimizers.Clipping{Value: 1}, []float64{0.9, 2.1}, []float64{-0.1}},
+		{"norm", optimizers.Clipping{Norm: 5}, []float64{0.7, 2.4}, []float64{-0.2}},
+		{"global norm", optimizers.Clipping{GlobalNorm: math.Sqrt(104) / 2}, []float64{0.7, 2.4}, []float64{-0.1}},
+	}
+	for _, tt := range tests {
+		dense, gradNorm := clippedDenseStep(tt.clipping)
+		resultWeights := dense.GetWeightsData()
+		resultBias := dense.GetBiasData()
+		if !functools.IsEqual(&tt.targetWeights, &resultWeights, 1e-9) {
+			fmt.Println(tt.name, resultWeights)
+			t.Fail()
+		}
+		if !functools.IsEqual(&tt.targetBias, &resultBias, 1e-9) {
+			fmt.Println(tt.name, resultBias)
+			t.Fail()
+		}
+		if math.Abs(gradNorm-math.Sqrt(104)) > 1e-9 {
+			fmt.Println(tt.name, gradNorm)
+			t.Fail()
+		}
+	}
+}
+
+func TestClipping_GlobalNorm_Conv(t *testing.T) {
+	conv1 := conv.NewConv2D([2]int{2, 1}, 2, [2]int{2, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	act1 := conv.NewReLU()
+	conv2 := conv.NewConv2D([2]int{1, 2}, 1, [2]int{1, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	filter1 := []float64{1, -2, -1, 2, 2, -1, 2, 1}
+	bias1 := []float64{1, -1}
+	filter2 := []float64{3, 1, -2, 2}
+	bias2 := []float64{2}
+	conv1.LoadFilter(&filter1)
+	conv1.LoadBias(&bias1)
+	conv2.LoadFilter(&filter2)
+	conv2.LoadBias(&bias2)
+	convs := []layers.Layer{&conv1, &act1, &conv2}
+
+	optimizer := optimizers.NewAdam(0.1, 0.9, 0.999, 1e-8)
+	optimizer.SetClipping(optimizers.Clipping{GlobalNorm: 1})
+	optimizer.PreTrainInit(&convs)
+	input := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
+		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
+	}
+	conv2Out := conv2.Forward(act1.Forward(conv1.Forward(tensor.FromMats(input))))
+	optimizer.Backward(&convs, gradsLike(conv2Out, mat.NewVecDense(1, []float64{3})))
+
+	sum := 0.0
+	for _, grads := range []*tensor.Tensor{
+		conv1.GetWeightsGrads(), conv1.GetBiasGrads(), conv2.GetWeightsGrads(), conv2.GetBiasGrads(),
+	} {
+		for _, g := range grads.RawData() {
+			sum += g * g
+		}
+	}
+	if math.Abs(math.Sqrt(sum)-1) > 1e-9 {
+		fmt.Println("Clipped norm:", math.Sqrt(sum))
+		t.Fail()
+	}
+	if optimizer.GradNorm() <= 1 {
+		fmt.Println("Pre clip norm:", optimizer.GradNorm())
+		t.Fail()
+	}
+}
+
+func TestClipping_negative_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	optimizer.SetClipping(optimizers.Clipping{Norm: -1})
+}
@@ -1,56 +1,31 @@
 package optimizers
 
-import (
-	"gonum.org/v1/gonum/mat"
-)
-
-type correctionType interface {
-	correctedDense(dw *mat.Dense) mat.Dense
-	correctedDenseSlice(dw *[]mat.Dense) []mat.Dense
-	correctedVecDense(db *mat.VecDense) mat.VecDense
-	correctedFloatSlice(db *[]float64) []float64
-}
-
+// Bias correction of a moment started from zeros, decay^t is kept per param name
 type correctionMechanism struct {
 	decay  float64
-	decayT float64
-}
-
-func (c *correctionMechanism) updateDecayT() {
-	c.decayT *= c.decay
+	decayT map[string]float64
 }
 
-func (c *correctionMechanism) scaleFraction() float64 {
-	return 1.0 / (1.0 - c.decayT)
+func newCorrectionMechanism(decay float64) correctionMechanism {
+	return correctionMechanism{
+		decay:  decay,
+		decayT: make(map[string]float64),
+	}
 }
 
-func (c *correctionMechanism) correctedDense(dw *mat.Dense) mat.Dense {
-	var retVal mat.Dense
-	retVal.Scale(c.scaleFraction(), dw)
-	return retVal
+func (c *correctionMechanism) initCorrectionMechanism() {
+	c.decayT = make(map[string]float64)
 }
 
-func (c *correctionMechanism) correctedDenseSlice(dw *[]mat.Dense) []mat.Dense {
-	retVal := make([]mat.Dense, len(*dw))
-	cScaleFraction := c.scaleFraction()
-	for i, dChannel := range *dw {
-		retVal[i].Scale(cScaleFraction, &dChannel)
+// Called once every step of the param, before scaleFraction
+func (c *correctionMechanism) updateDecayT(name string) {
+	decayT, ok := c.decayT[name]
+	if !ok {
+		decayT = 1.0
 	}
-	return retVal
-}
-
-func (c correctionMechanism) correctedVecDense(db *mat.VecDense) mat.VecDense {
-	var retVal mat.VecDense
-	cScaleFraction := c.scaleFraction()
-	retVal.ScaleVec(cScaleFraction, db)
-	return retVal
+	c.decayT[name] = decayT * c.decay
 }
 
-func (c correctionMechanism) correctedFloatSlice(db *[]float64) []float64 {
-	retVal := make([]float64, len(*db))
-	cScaleFraction := c.scaleFraction()
-	for i, v := range *db {
-		retVal[i] = v * cScaleFraction
-	}
-	return retVal
+func (c *correctionMechanism) scaleFraction(name string) float64 {
+	return 1.0 / (1.0 - c.decayT[name])
 }
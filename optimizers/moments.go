@@ -0,0 +1,93 @@
+package optimizers
+
+import (
+	"math"
+
+	"DoodleGan/tensor"
+)
+
+// Running averages of a single statistic, key: param name
+type paramMoments map[string]*tensor.Tensor
+
+// Created with zeros on the first step of the param
+func (m paramMoments) of(param *tensor.Param) *tensor.Tensor {
+	moment, ok := m[param.Name]
+	if !ok {
+		n, c, h, w := param.Grad.Dims()
+		moment = tensor.New(n, c, h, w, nil)
+		m[param.Name] = moment
+	}
+	return moment
+}
+
+type momentumMechanism struct {
+	momentum           float64
+	momentumComplement float64
+
+	velocityCorrection correctionMechanism
+	velocities         paramMoments
+}
+
+func newMomentumMechanism(momentum float64) momentumMechanism {
+	return momentumMechanism{
+		momentum:           momentum,
+		momentumComplement: 1.0 - momentum,
+		velocityCorrection: newCorrectionMechanism(momentum),
+		velocities:         make(paramMoments),
+	}
+}
+
+func (m *momentumMechanism) initMomentumMechanism() {
+	m.velocityCorrection.initCorrectionMechanism()
+	m.velocities = make(paramMoments)
+}
+
+// v = momentum * v + (1 - momentum) * grad, grad has shape of param grad
+func (m *momentumMechanism) momentumUpdate(param *tensor.Param, grad []float64) []float64 {
+	velocity := m.velocities.of(param).RawData()
+	for i, g := range grad {
+		velocity[i] = m.momentum*velocity[i] + m.momentumComplement*g
+	}
+	return velocity
+}
+
+type rhoSquareMechanism struct {
+	rho           float64
+	rhoComplement float64
+	eps           float64
+
+	rhoCorrection correctionMechanism
+	squared       paramMoments
+}
+
+func newRhoSquareMechanism(rho, eps float64) rhoSquareMechanism {
+	return rhoSquareMechanism{
+		rho:           rho,
+		rhoComplement: 1.0 - rho,
+		eps:           eps,
+		rhoCorrection: newCorrectionMechanism(rho),
+		squared:       make(paramMoments),
+	}
+}
+
+func (r *rhoSquareMechanism) initRhoMechanism() {
+	r.rhoCorrection.initCorrectionMechanism()
+	r.squared = make(paramMoments)
+}
+
+// s = rho * s + (1 - rho) * grad^2
+func (r *rhoSquareMechanism) rhoUpdate(param *tensor.Param) []float64 {
+	squared := r.squared.of(param).RawData()
+	for i, g := range param.Grad.RawData() {
+		squared[i] = r.rho*squared[i] + r.rhoComplement*g*g
+	}
+	return squared
+}
+
+// eps only guards zero, so rho = 0 steps by sign of the grad
+func (r *rhoSquareMechanism) root(v float64) float64 {
+	if v == 0.0 {
+		return r.eps
+	}
+	return math.Sqrt(v)
+}
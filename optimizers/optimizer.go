@@ -2,16 +2,46 @@ package optimizers
 
 import (
 	"fmt"
+	"slices"
 
-	"gonum.org/v1/gonum/mat"
-
-	"DoodleGan/conv"
 	"DoodleGan/layers"
+	"DoodleGan/tensor"
 )
 
+// Dense and conv layers share one list, conv layers satisfy layers.Layer as well.
+// Step updates params in place, state is kept per param name and reset by PreTrainInit.
 type Optimizer interface {
-	BackwardDenseLayers(denses *[]layers.Layer, loss *mat.VecDense) *mat.VecDense
-	BackwardConv2DLayers(convs2D *[]conv.ConvLayer, denseGrads *mat.VecDense)
+	PreTrainInit(layerList *[]layers.Layer)
+	Backward(layerList *[]layers.Layer, grads *tensor.Tensor) *tensor.Tensor
+	Step(params []tensor.Param)
+	GetLearningRate() float64
+	SetLearningRate(learningRate float64)
+	SetClipping(clipping Clipping)
+	GradNorm() float64
+}
+
+// Steps params of all trainable layers once the whole backward pass is done,
+// layers don't read their own params after backward, so result is the same as stepping each layer.
+// Param names are prefixed with idx of layer in passed architecture.
+func backpropagate(
+	opt Optimizer,
+	clip *clipMechanism,
+	layerList *[]layers.Layer,
+	grads *tensor.Tensor,
+) *tensor.Tensor {
+	var params []tensor.Param
+	for i, layer := range slices.Backward(*layerList) {
+		grads = layer.Backward(grads)
+		if trainableLayer, ok := layer.(layers.LayerTrainable); ok {
+			for _, param := range trainableLayer.Params() {
+				param.Name = fmt.Sprintf("%d.%s", i, param.Name)
+				params = append(params, param)
+			}
+		}
+	}
+	clip.clip(params)
+	opt.Step(params)
+	return grads
 }
 
 func checkValidLearningRate(learningRate *float64, funcName string) {
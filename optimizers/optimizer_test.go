@@ -0,0 +1,67 @@
+package optimizers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/layers"
+	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
+)
+
+func TestSGD_BatchNorm1D_Params(t *testing.T) {
+	batchNorm := layers.NewBatchNorm1D(2, 0.9, 1e-5)
+	batchNorm.SetTraining(true)
+	nn := []layers.Layer{&batchNorm}
+
+	input := tensor.New(3, 1, 1, 2, []float64{1, 2, 3, -1, -1, 5})
+	batchNorm.Forward(input)
+
+	optimizer := optimizers.NewSGD(0.5, 0.0)
+	optimizer.PreTrainInit(&nn)
+	grads := tensor.New(3, 1, 1, 2, []float64{1, 0, -2, 1, 0.5, 2})
+	optimizer.Backward(&nn, grads)
+
+	gammaGrads := batchNorm.GetWeightsGrads().RawData()
+	betaGrads := batchNorm.GetBiasGrads().RawData()
+	targetGamma := []float64{1 - 0.5*gammaGrads[0], 1 - 0.5*gammaGrads[1]}
+	targetBeta := []float64{-0.5 * betaGrads[0], -0.5 * betaGrads[1]}
+	if !functools.IsEqual(&targetGamma, batchNorm.GetGamma(), 1e-9) {
+		fmt.Println(targetGamma)
+		fmt.Println(*batchNorm.GetGamma())
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBeta, batchNorm.GetBeta(), 1e-9) {
+		fmt.Println(targetBeta)
+		fmt.Println(*batchNorm.GetBeta())
+		t.Fail()
+	}
+}
+
+func TestAdam_Step_per_param_correction(t *testing.T) {
+	a := []float64{1, -1}
+	b := []float64{1, -1}
+	grads := []float64{0.3, -0.02}
+	paramA := tensor.Param{Name: "a", Value: tensor.New(1, 1, 1, 2, a), Grad: tensor.New(1, 1, 1, 2, grads)}
+	paramB := tensor.Param{Name: "b", Value: tensor.New(1, 1, 1, 2, b), Grad: tensor.New(1, 1, 1, 2, grads)}
+
+	optimizer := optimizers.NewAdam(0.1, 0.9, 0.999, 1e-8)
+	optimizer.Step([]tensor.Param{paramA})
+	optimizer.Step([]tensor.Param{paramA})
+	optimizer.Step([]tensor.Param{paramB})
+
+	// First corrected step moves by learning rate in direction of the grad
+	targetA := []float64{0.8, -0.8}
+	targetB := []float64{0.9, -0.9}
+	if !functools.IsEqual(&targetA, &a, 1e-6) {
+		fmt.Println(targetA)
+		fmt.Println(a)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetB, &b, 1e-6) {
+		fmt.Println(targetB)
+		fmt.Println(b)
+		t.Fail()
+	}
+}
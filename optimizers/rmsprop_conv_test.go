@@ -10,6 +10,7 @@ import (
 	"DoodleGan/functools"
 	"DoodleGan/layers"
 	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
 )
 
 func TestRMSProp_Conv_1(t *testing.T) {
@@ -46,15 +47,14 @@ func TestRMSProp_Conv_1(t *testing.T) {
 			3, 1, 0, 2, -1,
 		}),
 	}
-	convLayer_1.Forward(&input)
-	convLayer_2.Forward(convLayer_1.DeflatOutput())
+	convLayer_1Out := convLayer_1.Forward(tensor.FromMats(input))
+	convLayer_2Out := convLayer_2.Forward(convLayer_1Out)
 
-	outHeight, outWidth := convLayer_2.GetOutputSize()
 	optimizer := optimizers.NewRMSProp(0.5, 0.0, 10e-8)
-	convLayers := []conv.ConvLayer{&convLayer_1, &convLayer_2}
-	optimizer.PreTrainInit([2]int{outHeight, outWidth}, &convLayers, &[]layers.Layer{})
+	convLayers := []layers.Layer{&convLayer_1, &convLayer_2}
+	optimizer.PreTrainInit(&convLayers)
 	vec_grad := mat.NewVecDense(4, []float64{1, 0.5, -1, 1})
-	optimizer.BackwardConv2DLayers(&convLayers, vec_grad)
+	optimizer.Backward(&convLayers, gradsLike(convLayer_2Out, vec_grad))
 
 	result_filter_1 := convLayer_1.GetFilter()
 	result_bias_1 := convLayer_1.GetBias()
@@ -111,19 +111,18 @@ func TestRMSProp_Conv_2(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{-2, 1, 0, 0, 2, -1, 0, -1, 2}),
 	}
-	conv_1.Forward(&input)
-	activated_1 := act_1.Forward(conv_1.DeflatOutput())
-	conv_2.Forward(activated_1)
-	activated_2 := act_2.Forward(conv_2.DeflatOutput())
-	conv_3.Forward(activated_2)
+	conv_1Out := conv_1.Forward(tensor.FromMats(input))
+	activated_1 := act_1.Forward(conv_1Out)
+	conv_2Out := conv_2.Forward(activated_1)
+	activated_2 := act_2.Forward(conv_2Out)
+	conv_3Out := conv_3.Forward(activated_2)
 
-	outHeight, outWidth := conv_3.GetOutputSize()
 	optimizer := optimizers.NewRMSProp(0.1, 0.0, 10e-8)
-	convLayers := []conv.ConvLayer{&conv_1, &act_1, &conv_2, &act_2, &conv_3}
-	optimizer.PreTrainInit([2]int{outHeight, outWidth}, &convLayers, &[]layers.Layer{})
+	convLayers := []layers.Layer{&conv_1, &act_1, &conv_2, &act_2, &conv_3}
+	optimizer.PreTrainInit(&convLayers)
 
 	vec_grad := mat.NewVecDense(1, []float64{-2})
-	optimizer.BackwardConv2DLayers(&convLayers, vec_grad)
+	optimizer.Backward(&convLayers, gradsLike(conv_3Out, vec_grad))
 
 	target_filter_1 := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2.1, 0.9, 4.1, 0.9}),
@@ -203,15 +202,15 @@ func TestRMSProp_Conv_3(t *testing.T) {
 			-3, 0, -3, -3, 2, 1, 0, 0, -1, 1, 1, 3, -1, -1, -1, 3,
 		}),
 	}
-	conv_1.Forward(&input)
-	pooled := pool_1.Forward(conv_1.DeflatOutput())
-	act_1.Forward(pooled)
+	conv_1Out := conv_1.Forward(tensor.FromMats(input))
+	pooled := pool_1.Forward(conv_1Out)
+	act_1Out := act_1.Forward(pooled)
 
-	cnn := []conv.ConvLayer{&conv_1, &pool_1, &act_1}
+	cnn := []layers.Layer{&conv_1, &pool_1, &act_1}
 	grads := mat.NewVecDense(2, []float64{1, 2})
 	optimizer := optimizers.NewRMSProp(0.5, 0.0, 10e-8)
-	optimizer.PreTrainInit([2]int{1, 1}, &cnn, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&cnn, grads)
+	optimizer.PreTrainInit(&cnn)
+	optimizer.Backward(&cnn, gradsLike(act_1Out, grads))
 
 	result_filter := conv_1.GetFilter()
 	result_bias := conv_1.GetBias()
@@ -249,14 +248,14 @@ func TestRMSProp_Conv_4(t *testing.T) {
 		*mat.NewDense(3, 3, []float64{1, 3, -1, 2, 3, -2, 1, 0, 4}),
 		*mat.NewDense(3, 3, []float64{1, 2, 4, 0, 3, 3, 2, -2, -2}),
 	}
-	conv_1.Forward(&input)
-	pool.Forward(conv_1.DeflatOutput())
+	conv_1Out := conv_1.Forward(tensor.FromMats(input))
+	poolOut := pool.Forward(conv_1Out)
 
-	cnn := []conv.ConvLayer{&conv_1, &pool}
+	cnn := []layers.Layer{&conv_1, &pool}
 	grads := mat.NewVecDense(1, []float64{5})
 	optimizer := optimizers.NewRMSProp(0.1, 0.0, 10e-8)
-	optimizer.PreTrainInit([2]int{1, 1}, &cnn, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&cnn, grads)
+	optimizer.PreTrainInit(&cnn)
+	optimizer.Backward(&cnn, gradsLike(poolOut, grads))
 
 	result_filter := conv_1.GetFilter()
 	result_bias := conv_1.GetBias()
@@ -308,17 +307,16 @@ func TestRMSProp_Conv_Rho_1(t *testing.T) {
 		*mat.NewDense(1, 1, []float64{0.5}),
 		*mat.NewDense(1, 1, []float64{3}),
 	}
-	convLayer_1.Forward(&input)
-	activated_1 := act_1.Forward(convLayer_1.DeflatOutput())
-	convLayer_2.Forward(activated_1)
-	act_2.Forward(convLayer_2.DeflatOutput())
+	convLayer_1Out := convLayer_1.Forward(tensor.FromMats(input))
+	activated_1 := act_1.Forward(convLayer_1Out)
+	convLayer_2Out := convLayer_2.Forward(activated_1)
+	act_2Out := act_2.Forward(convLayer_2Out)
 
-	outHeight, outWidth := convLayer_2.GetOutputSize()
 	optimizer := optimizers.NewRMSProp(0.1, 0.5, 10e-8)
-	convLayers := []conv.ConvLayer{&convLayer_1, &act_1, &convLayer_2, &act_2}
-	optimizer.PreTrainInit([2]int{outHeight, outWidth}, &convLayers, &[]layers.Layer{})
+	convLayers := []layers.Layer{&convLayer_1, &act_1, &convLayer_2, &act_2}
+	optimizer.PreTrainInit(&convLayers)
 	vec_grad := mat.NewVecDense(2, []float64{1, 2})
-	optimizer.BackwardConv2DLayers(&convLayers, vec_grad)
+	optimizer.Backward(&convLayers, gradsLike(act_2Out, vec_grad))
 
 	result_filter_1 := convLayer_1.GetFilter()
 	result_filter_2 := convLayer_2.GetFilter()
@@ -368,20 +366,20 @@ func TestRMSProp_Conv_Rho_2(t *testing.T) {
 	convLayer.LoadFilter(&filter)
 	bias := []float64{1}
 	convLayer.LoadBias(&bias)
-	convLayers := []conv.ConvLayer{&convLayer}
+	convLayers := []layers.Layer{&convLayer}
 
 	optimizer := optimizers.NewRMSProp(0.5, 0.9, 10e-8)
-	optimizer.PreTrainInit([2]int{1, 1}, &convLayers, &[]layers.Layer{})
+	optimizer.PreTrainInit(&convLayers)
 
 	input1 := []mat.Dense{*mat.NewDense(2, 2, []float64{1, -1, 2, -3})}
-	convLayer.Forward(&input1)
+	convLayerOut := convLayer.Forward(tensor.FromMats(input1))
 	grad1 := mat.NewVecDense(1, []float64{2})
-	optimizer.BackwardConv2DLayers(&convLayers, grad1)
+	optimizer.Backward(&convLayers, gradsLike(convLayerOut, grad1))
 
 	input2 := []mat.Dense{*mat.NewDense(2, 2, []float64{2, -2, 1, -3})}
-	convLayer.Forward(&input2)
+	convLayerOut = convLayer.Forward(tensor.FromMats(input2))
 	grad2 := mat.NewVecDense(1, []float64{2})
-	optimizer.BackwardConv2DLayers(&convLayers, grad2)
+	optimizer.Backward(&convLayers, gradsLike(convLayerOut, grad2))
 
 	resultFilter := convLayer.GetFilter()
 	targetFilter := []mat.Dense{*mat.NewDense(2, 2, []float64{-1.0097, 3.0096, -0.3184, 1.7281})}
@@ -401,3 +399,121 @@ func TestRMSProp_Conv_Rho_2(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRMSPropMomentum_Conv_1(t *testing.T) {
+	conv1 := conv.NewConv2D([2]int{2, 1}, 2, [2]int{2, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	act1 := conv.NewReLU()
+	conv2 := conv.NewConv2D([2]int{1, 2}, 1, [2]int{1, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+
+	filter1 := []float64{
+		1, -2, -1, 2,
+		2, -1, 2, 1,
+	}
+	bias1 := []float64{
+		1, -1,
+	}
+	filter2 := []float64{
+		3, 1, -2, 2,
+	}
+	bias2 := []float64{
+		2,
+	}
+
+	conv1.LoadFilter(&filter1)
+	conv1.LoadBias(&bias1)
+	conv2.LoadFilter(&filter2)
+	conv2.LoadBias(&bias2)
+	convs := []layers.Layer{&conv1, &act1, &conv2}
+	optimizer := optimizers.NewRMSPropMomentum(0.1, 0.9, 0.5, 1e-8)
+	optimizer.PreTrainInit(&convs)
+
+	input1 := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
+		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
+	}
+	conv1Out1 := conv1.Forward(tensor.FromMats(input1))
+	conv2Out1 := conv2.Forward(act1.Forward(conv1Out1))
+	optimizer.Backward(&convs, gradsLike(conv2Out1, mat.NewVecDense(1, []float64{3})))
+
+	targetFilter1_1 := []mat.Dense{
+		*mat.NewDense(2, 1, []float64{0.842, -1.842}),
+		*mat.NewDense(2, 1, []float64{-1, 1.842}),
+		*mat.NewDense(2, 1, []float64{2.158, -1.158}),
+		*mat.NewDense(2, 1, []float64{2.158, 1.158}),
+	}
+	targetBias1_1 := []float64{0.842, -0.842}
+	targetFilter2_1 := []mat.Dense{
+		*mat.NewDense(1, 2, []float64{2.842, 0.842}),
+		*mat.NewDense(1, 2, []float64{-2.158, 2}),
+	}
+	targetBias2_1 := []float64{1.842}
+	if !functools.IsEqualMatSlice(&targetFilter1_1, conv1.GetFilter(), 0.001) {
+		fmt.Println("I FILTER 1")
+		functools.PrintMatSlice(&targetFilter1_1, 3)
+		functools.PrintMatSlice(conv1.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias1_1, conv1.GetBias(), 0.001) {
+		fmt.Println("I BIAS 1")
+		fmt.Println(targetBias1_1)
+		fmt.Println(*conv1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilter2_1, conv2.GetFilter(), 0.001) {
+		fmt.Println("II FILTER 1")
+		functools.PrintMatSlice(&targetFilter2_1, 3)
+		functools.PrintMatSlice(conv2.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias2_1, conv2.GetBias(), 0.001) {
+		fmt.Println("II BIAS 1")
+		fmt.Println(targetBias2_1)
+		fmt.Println(*conv2.GetBias())
+		t.Fail()
+	}
+
+	input2 := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, 2, -5, 0}),
+		*mat.NewDense(2, 2, []float64{3, -2, 2, 2}),
+	}
+	conv1Out2 := conv1.Forward(tensor.FromMats(input2))
+	conv2Out2 := conv2.Forward(act1.Forward(conv1Out2))
+	optimizer.Backward(&convs, gradsLike(conv2Out2, mat.NewVecDense(1, []float64{2})))
+
+	targetFilter1_2 := []mat.Dense{
+		*mat.NewDense(2, 1, []float64{0.697, -1.611}),
+		*mat.NewDense(2, 1, []float64{-1.158, 1.714}),
+		*mat.NewDense(2, 1, []float64{2.188, -1.377}),
+		*mat.NewDense(2, 1, []float64{2.39, 1.242}),
+	}
+	targetBias1_2 := []float64{0.677, -0.754}
+	targetFilter2_2 := []mat.Dense{
+		*mat.NewDense(1, 2, []float64{2.683, 0.662}),
+		*mat.NewDense(1, 2, []float64{-2.35, 1.842}),
+	}
+	targetBias2_2 := []float64{1.672}
+	if !functools.IsEqualMatSlice(&targetFilter1_2, conv1.GetFilter(), 0.001) {
+		fmt.Println("I FILTER 2")
+		functools.PrintMatSlice(&targetFilter1_2, 3)
+		functools.PrintMatSlice(conv1.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias1_2, conv1.GetBias(), 0.001) {
+		fmt.Println("I BIAS 2")
+		fmt.Println(targetBias1_2)
+		fmt.Println(*conv1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilter2_2, conv2.GetFilter(), 0.001) {
+		fmt.Println("II FILTER 2")
+		functools.PrintMatSlice(&targetFilter2_2, 3)
+		functools.PrintMatSlice(conv2.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias2_2, conv2.GetBias(), 0.001) {
+		fmt.Println("II BIAS 2")
+		fmt.Println(targetBias2_2)
+		fmt.Println(*conv2.GetBias())
+		t.Fail()
+	}
+}
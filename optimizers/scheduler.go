@@ -0,0 +1,196 @@
+package optimizers
+
+import (
+	"fmt"
+	"math"
+)
+
+// Decayed rates don't go below it, so underflow can't reach the invalid rate 0
+const minDecayedRate = math.SmallestNonzeroFloat64
+
+// Updates learning rate of its optimizer, the training loop calls Step after every
+// batch or epoch with the loss of it
+type Scheduler interface {
+	Step(loss float64)
+}
+
+// Learning rate after step steps, given the rate the optimizer started with
+type Schedule interface {
+	LearningRate(baseRate float64, step int) float64
+}
+
+// Sets the rate of a Schedule, step 0 rate is set on creation
+type ScheduledRate struct {
+	optimizer Optimizer
+	schedule  Schedule
+	baseRate  float64
+	step      int
+}
+
+func NewScheduledRate(optimizer Optimizer, schedule Schedule) ScheduledRate {
+	retVal := ScheduledRate{
+		optimizer: optimizer,
+		schedule:  schedule,
+		baseRate:  optimizer.GetLearningRate(),
+	}
+	optimizer.SetLearningRate(schedule.LearningRate(retVal.baseRate, 0))
+	return retVal
+}
+
+func (s *ScheduledRate) Step(loss float64) {
+	s.step++
+	s.optimizer.SetLearningRate(s.schedule.LearningRate(s.baseRate, s.step))
+}
+
+// Multiplies rate by gamma every stepSize steps
+type StepDecay struct {
+	stepSize int
+	gamma    float64
+}
+
+func NewStepDecay(stepSize int, gamma float64) StepDecay {
+	if stepSize < 1 {
+		panic(fmt.Sprintf("NewStepDecay fail:\n\tStep size must be positive, have: %d", stepSize))
+	}
+	checkValidDecay(gamma, "NewStepDecay")
+	return StepDecay{stepSize: stepSize, gamma: gamma}
+}
+
+func (s *StepDecay) LearningRate(baseRate float64, step int) float64 {
+	return max(baseRate*math.Pow(s.gamma, float64(step/s.stepSize)), minDecayedRate)
+}
+
+// Multiplies rate by gamma every step
+type ExponentialDecay struct {
+	gamma float64
+}
+
+func NewExponentialDecay(gamma float64) ExponentialDecay {
+	checkValidDecay(gamma, "NewExponentialDecay")
+	return ExponentialDecay{gamma: gamma}
+}
+
+func (s *ExponentialDecay) LearningRate(baseRate float64, step int) float64 {
+	return max(baseRate*math.Pow(s.gamma, float64(step)), minDecayedRate)
+}
+
+// Anneals rate from base to minRate along half of cosine over maxSteps,
+// then keeps minRate. minRate can't be greater than the base rate.
+//
+// https://arxiv.org/abs/1608.03983
+type CosineAnnealing struct {
+	maxSteps int
+	minRate  float64
+}
+
+func NewCosineAnnealing(maxSteps int, minRate float64) CosineAnnealing {
+	if maxSteps < 1 || minRate <= 0.0 {
+		mess := fmt.Sprintf(
+			"NewCosineAnnealing fail:\n\tmaxSteps (%d) and minRate (%f) must be positive",
+			maxSteps,
+			minRate,
+		)
+		panic(mess)
+	}
+	return CosineAnnealing{maxSteps: maxSteps, minRate: minRate}
+}
+
+func (s *CosineAnnealing) LearningRate(baseRate float64, step int) float64 {
+	if s.minRate > baseRate {
+		mess := fmt.Sprintf(
+			"CosineAnnealing fail:\n\tminRate (%f) can't be greater than base rate (%f)",
+			s.minRate,
+			baseRate,
+		)
+		panic(mess)
+	}
+	progress := float64(min(step, s.maxSteps)) / float64(s.maxSteps)
+	return s.minRate + (baseRate-s.minRate)*(1.0+math.Cos(math.Pi*progress))/2.0
+}
+
+// Grows rate linearly from base / warmupSteps to base over warmupSteps,
+// then follows next schedule started from step 0. Nil next keeps the base rate.
+type Warmup struct {
+	warmupSteps int
+	next        Schedule
+}
+
+func NewWarmup(warmupSteps int, next Schedule) Warmup {
+	if warmupSteps < 1 {
+		panic(fmt.Sprintf("NewWarmup fail:\n\tWarmup steps must be positive, have: %d", warmupSteps))
+	}
+	return Warmup{warmupSteps: warmupSteps, next: next}
+}
+
+func (s *Warmup) LearningRate(baseRate float64, step int) float64 {
+	if step < s.warmupSteps {
+		return baseRate * float64(step+1) / float64(s.warmupSteps)
+	}
+	if s.next == nil {
+		return baseRate
+	}
+	return s.next.LearningRate(baseRate, step-s.warmupSteps)
+}
+
+// Multiplies rate by factor when loss hasn't improved by more than threshold
+// (relative, in range [0, 1)) for patience steps, rate doesn't go below positive minRate
+type ReduceOnPlateau struct {
+	optimizer Optimizer
+	factor    float64
+	patience  int
+	threshold float64
+	minRate   float64
+
+	best float64
+	wait int
+}
+
+func NewReduceOnPlateau(
+	optimizer Optimizer,
+	factor float64,
+	patience int,
+	threshold, minRate float64,
+) ReduceOnPlateau {
+	checkValidDecay(factor, "NewReduceOnPlateau")
+	if patience < 0 || threshold < 0.0 {
+		mess := fmt.Sprintf(
+			"NewReduceOnPlateau fail:\n\tpatience (%d) and threshold (%f) can't be negative",
+			patience,
+			threshold,
+		)
+		panic(mess)
+	}
+	if minRate <= 0.0 {
+		panic(fmt.Sprintf("NewReduceOnPlateau fail:\n\tminRate (%f) must be positive", minRate))
+	}
+	if threshold >= 1.0 {
+		panic(fmt.Sprintf("NewReduceOnPlateau fail:\n\tthreshold (%f) must be in range [0, 1)", threshold))
+	}
+	return ReduceOnPlateau{
+		optimizer: optimizer,
+		factor:    factor,
+		patience:  patience,
+		threshold: threshold,
+		minRate:   minRate,
+		best:      math.Inf(1),
+	}
+}
+
+func (s *ReduceOnPlateau) Step(loss float64) {
+	if loss < s.best*(1.0-s.threshold) {
+		s.best = loss
+		s.wait = 0
+		return
+	}
+	s.wait++
+	if s.wait > s.patience {
+		s.optimizer.SetLearningRate(max(s.optimizer.GetLearningRate()*s.factor, s.minRate))
+		s.wait = 0
+	}
+}
+
+func checkValidDecay(gamma float64, funcName string) {
+	if gamma <= 0.0 || gamma > 1.0 {
+		panic(fmt.Sprintf("%s fail:\n\tDecay factor (%f) must be in range (0, 1]", funcName, gamma))
+	}
+}
@@ -0,0 +1,129 @@
+package optimizers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/optimizers"
+)
+
+func scheduledRates(schedule optimizers.Schedule, steps int) []float64 {
+	opt := optimizers.NewSGD(0.1, 0.0)
+	scheduler := optimizers.NewScheduledRate(&opt, schedule)
+	retVal := []float64{opt.GetLearningRate()}
+	for range steps {
+		scheduler.Step(0)
+		retVal = append(retVal, opt.GetLearningRate())
+	}
+	return retVal
+}
+
+func TestStepDecay(t *testing.T) {
+	schedule := optimizers.NewStepDecay(2, 0.5)
+	result := scheduledRates(&schedule, 4)
+	target := []float64{0.1, 0.1, 0.05, 0.05, 0.025}
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestExponentialDecay(t *testing.T) {
+	schedule := optimizers.NewExponentialDecay(0.9)
+	result := scheduledRates(&schedule, 2)
+	target := []float64{0.1, 0.09, 0.081}
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestCosineAnnealing(t *testing.T) {
+	schedule := optimizers.NewCosineAnnealing(2, 0.02)
+	result := scheduledRates(&schedule, 3)
+	target := []float64{0.1, 0.06, 0.02, 0.02}
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestCosineAnnealing_minRate_above_base_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	opt := optimizers.NewSGD(0.01, 0.0)
+	schedule := optimizers.NewCosineAnnealing(10, 0.02)
+	optimizers.NewScheduledRate(&opt, &schedule)
+}
+
+func TestWarmup(t *testing.T) {
+	decay := optimizers.NewExponentialDecay(0.5)
+	schedule := optimizers.NewWarmup(4, &decay)
+	result := scheduledRates(&schedule, 5)
+	target := []float64{0.025, 0.05, 0.075, 0.1, 0.1, 0.05}
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(result)
+		t.Fail()
+	}
+
+	constant := optimizers.NewWarmup(2, nil)
+	result = scheduledRates(&constant, 3)
+	target = []float64{0.05, 0.1, 0.1, 0.1}
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	opt := optimizers.NewAdam(0.1, 0.9, 0.999, 1e-8)
+	scheduler := optimizers.NewReduceOnPlateau(&opt, 0.5, 1, 0.01, 0.03)
+	losses := []float64{1, 0.5, 0.499, 0.498, 0.4, 0.4, 0.4, 0.4, 0.4}
+	result := []float64{}
+	for _, loss := range losses {
+		scheduler.Step(loss)
+		result = append(result, opt.GetLearningRate())
+	}
+	target := []float64{0.1, 0.1, 0.1, 0.05, 0.05, 0.05, 0.03, 0.03, 0.03}
+	if !functools.IsEqual(&target, &result, 1e-12) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestReduceOnPlateau_threshold_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	opt := optimizers.NewSGD(0.1, 0.0)
+	optimizers.NewReduceOnPlateau(&opt, 0.5, 1, 1.0, 0.01)
+}
+
+func TestReduceOnPlateau_zero_minRate_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	opt := optimizers.NewSGD(0.1, 0.0)
+	optimizers.NewReduceOnPlateau(&opt, 0.5, 1, 0.01, 0.0)
+}
+
+// Rate underflowing to 0 would be rejected by the optimizer
+func TestExponentialDecay_underflow(t *testing.T) {
+	opt := optimizers.NewSGD(0.1, 0.0)
+	schedule := optimizers.NewExponentialDecay(1e-200)
+	scheduler := optimizers.NewScheduledRate(&opt, &schedule)
+	scheduler.Step(0.0)
+	scheduler.Step(0.0)
+	if !(opt.GetLearningRate() > 0.0) {
+		fmt.Println(opt.GetLearningRate())
+		t.Fail()
+	}
+}
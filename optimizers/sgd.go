@@ -1,20 +1,16 @@
 package optimizers
 
 import (
-	"slices"
-
-	"gonum.org/v1/gonum/mat"
-
-	"DoodleGan/conv"
-	"DoodleGan/functools"
 	"DoodleGan/layers"
+	"DoodleGan/tensor"
 )
 
 type SGD struct {
 	learningRate float64
+	nesterov     bool
 
 	momentumMechanism
-	lastConvOutputSize conv.MatSize
+	clipMechanism
 }
 
 func NewSGD(learningRate, momentum float64) SGD {
@@ -26,71 +22,58 @@ func NewSGD(learningRate, momentum float64) SGD {
 	}
 }
 
-func (opt *SGD) PreTrainInit(
-	lastConvOutputSize [2]int,
-	convLayers *[]conv.ConvLayer,
-	denseLayers *[]layers.Layer,
-) {
-	opt.lastConvOutputSize = *conv.NewMatSize(
-		lastConvOutputSize[0],
-		lastConvOutputSize[1],
-	)
-	if opt.momentum != 0.0 {
-		opt.initMomentumMechanizm(convLayers, denseLayers)
+// Steps by velocity looked ahead with the current grad
+func NewNesterovSGD(learningRate, momentum float64) SGD {
+	checkValidLearningRate(&learningRate, "NewNesterovSGD")
+	checkValidMomentum(&momentum, "NewNesterovSGD")
+	return SGD{
+		learningRate:      learningRate,
+		nesterov:          true,
+		momentumMechanism: newMomentumMechanism(momentum),
 	}
 }
 
-func (opt *SGD) BackwardDenseLayers(denses *[]layers.Layer, loss *mat.VecDense) *mat.VecDense {
-	grads := *loss
-	for i, denseLayer := range slices.Backward(*denses) {
-		grads = *denseLayer.Backward(&grads)
-		if trainableLayer, ok := denseLayer.(layers.LayerTrainable); ok {
-			if opt.momentum == 0.0 {
-				trainableLayer.ApplyGrads(
-					&opt.learningRate,
-					trainableLayer.GetOutWeightsGrads(),
-					trainableLayer.GetOutBiasGrads(),
-				)
-			} else { // Non Nesterov
-				weightGrads := trainableLayer.GetOutWeightsGrads()
-				biasGrads := trainableLayer.GetOutBiasGrads()
-				opt.momentumUpdateDense(i, weightGrads, biasGrads)
-				trainableLayer.ApplyGrads(
-					&opt.learningRate,
-					opt.getMomentumDenseWeights(i),
-					opt.getMomentumDenseBias(i),
-				)
-			}
+func (opt *SGD) PreTrainInit(layerList *[]layers.Layer) {
+	opt.initMomentumMechanism()
+}
+
+func (opt *SGD) Backward(layerList *[]layers.Layer, grads *tensor.Tensor) *tensor.Tensor {
+	return backpropagate(opt, &opt.clipMechanism, layerList, grads)
+}
+
+// Zero momentum steps by the raw grad
+func (opt *SGD) Step(params []tensor.Param) {
+	for i := range params {
+		grad := params[i].Grad.RawData()
+		if opt.momentum == 0.0 {
+			opt.apply(&params[i], grad)
+			continue
+		}
+		velocity := opt.momentumUpdate(&params[i], grad)
+		if !opt.nesterov {
+			opt.apply(&params[i], velocity)
+			continue
 		}
+		lookAhead := make([]float64, len(grad))
+		for j, g := range grad {
+			lookAhead[j] = opt.momentum*velocity[j] + opt.momentumComplement*g
+		}
+		opt.apply(&params[i], lookAhead)
 	}
-	return &grads
 }
 
-func (opt *SGD) BackwardConv2DLayers(convs2D *[]conv.ConvLayer, denseGrads *mat.VecDense) {
-	gradsMat := functools.VecToMatSlice(
-		denseGrads,
-		opt.lastConvOutputSize.Height(),
-		opt.lastConvOutputSize.Width(),
-	)
-	for i := len(*convs2D) - 1; i >= 0; i-- {
-		gradsMat = *(*convs2D)[i].Backward(&gradsMat)
-		if trainableLayer, ok := (*convs2D)[i].(conv.ConvLayerTrainable); ok {
-			if opt.momentum == 0.0 {
-				trainableLayer.ApplyGrads(
-					&opt.learningRate,
-					trainableLayer.GetFilterGrads(),
-					trainableLayer.GetBiasGrads(),
-				)
-			} else { // Non Nesterov
-				filterGrads := trainableLayer.GetFilterGrads()
-				biasGrads := trainableLayer.GetBiasGrads()
-				opt.momentumUpdateConv(i, filterGrads, biasGrads)
-				trainableLayer.ApplyGrads(
-					&opt.learningRate,
-					opt.getMomentumConvWeigts(i),
-					opt.getMomentumConvBias(i),
-				)
-			}
-		}
+func (opt *SGD) apply(param *tensor.Param, update []float64) {
+	value := param.Value.RawData()
+	for j, u := range update {
+		value[j] -= opt.learningRate * u
 	}
 }
+
+func (opt *SGD) GetLearningRate() float64 {
+	return opt.learningRate
+}
+
+func (opt *SGD) SetLearningRate(learningRate float64) {
+	checkValidLearningRate(&learningRate, "SGD SetLearningRate")
+	opt.learningRate = learningRate
+}
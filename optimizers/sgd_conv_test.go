@@ -10,8 +10,15 @@ import (
 	"DoodleGan/functools"
 	"DoodleGan/layers"
 	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
 )
 
+// Flat grads reshaped to the dims of the last layer output
+func gradsLike(output *tensor.Tensor, grads *mat.VecDense) *tensor.Tensor {
+	_, c, h, w := output.Dims()
+	return tensor.FromVec(grads).Reshape(c, h, w)
+}
+
 func TestSGD_Conv_1(t *testing.T) {
 	convLayer_1 := conv.NewConv2D(
 		[2]int{3, 3},
@@ -46,15 +53,14 @@ func TestSGD_Conv_1(t *testing.T) {
 			3, 1, 0, 2, -1,
 		}),
 	}
-	convLayer_1.Forward(&input)
-	convLayer_2.Forward(convLayer_1.DeflatOutput())
+	convLayer_1Out := convLayer_1.Forward(tensor.FromMats(input))
+	convLayer_2Out := convLayer_2.Forward(convLayer_1Out)
 
-	outHeight, outWidth := convLayer_2.GetOutputSize()
 	optimizer := optimizers.NewSGD(0.5, 0.0)
-	convLayers := []conv.ConvLayer{&convLayer_1, &convLayer_2}
-	optimizer.PreTrainInit([2]int{outHeight, outWidth}, &convLayers, &[]layers.Layer{})
+	convLayers := []layers.Layer{&convLayer_1, &convLayer_2}
+	optimizer.PreTrainInit(&convLayers)
 	vec_grad := mat.NewVecDense(4, []float64{1, 0.5, -1, 1})
-	optimizer.BackwardConv2DLayers(&convLayers, vec_grad)
+	optimizer.Backward(&convLayers, gradsLike(convLayer_2Out, vec_grad))
 
 	result_filter_1 := convLayer_1.GetFilter()
 	result_bias_1 := convLayer_1.GetBias()
@@ -111,19 +117,18 @@ func TestSGD_Conv_2(t *testing.T) {
 	input := []mat.Dense{
 		*mat.NewDense(3, 3, []float64{-2, 1, 0, 0, 2, -1, 0, -1, 2}),
 	}
-	conv_1.Forward(&input)
-	activated_1 := act_1.Forward(conv_1.DeflatOutput())
-	conv_2.Forward(activated_1)
-	activated_2 := act_2.Forward(conv_2.DeflatOutput())
-	conv_3.Forward(activated_2)
+	conv_1Out := conv_1.Forward(tensor.FromMats(input))
+	activated_1 := act_1.Forward(conv_1Out)
+	conv_2Out := conv_2.Forward(activated_1)
+	activated_2 := act_2.Forward(conv_2Out)
+	conv_3Out := conv_3.Forward(activated_2)
 
-	outHeight, outWidth := conv_3.GetOutputSize()
 	optimizer := optimizers.NewSGD(0.1, 0.0)
-	convLayers := []conv.ConvLayer{&conv_1, &act_1, &conv_2, &act_2, &conv_3}
-	optimizer.PreTrainInit([2]int{outHeight, outWidth}, &convLayers, &[]layers.Layer{})
+	convLayers := []layers.Layer{&conv_1, &act_1, &conv_2, &act_2, &conv_3}
+	optimizer.PreTrainInit(&convLayers)
 
 	vec_grad := mat.NewVecDense(1, []float64{-2})
-	optimizer.BackwardConv2DLayers(&convLayers, vec_grad)
+	optimizer.Backward(&convLayers, gradsLike(conv_3Out, vec_grad))
 
 	target_filter_1 := []mat.Dense{
 		*mat.NewDense(2, 2, []float64{2.6, 0.98, 5.1, 0.48}),
@@ -203,15 +208,15 @@ func TestSGD_Conv_3(t *testing.T) {
 			-3, 0, -3, -3, 2, 1, 0, 0, -1, 1, 1, 3, -1, -1, -1, 3,
 		}),
 	}
-	conv_1.Forward(&input)
-	pooled := pool_1.Forward(conv_1.DeflatOutput())
-	act_1.Forward(pooled)
+	conv_1Out := conv_1.Forward(tensor.FromMats(input))
+	pooled := pool_1.Forward(conv_1Out)
+	act_1Out := act_1.Forward(pooled)
 
-	cnn := []conv.ConvLayer{&conv_1, &pool_1, &act_1}
+	cnn := []layers.Layer{&conv_1, &pool_1, &act_1}
 	grads := mat.NewVecDense(2, []float64{1, 2})
 	optimizer := optimizers.NewSGD(0.5, 0.0)
-	optimizer.PreTrainInit([2]int{1, 1}, &cnn, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&cnn, grads)
+	optimizer.PreTrainInit(&cnn)
+	optimizer.Backward(&cnn, gradsLike(act_1Out, grads))
 
 	result_filter := conv_1.GetFilter()
 	result_bias := conv_1.GetBias()
@@ -249,14 +254,14 @@ func TestSGD_Conv_4(t *testing.T) {
 		*mat.NewDense(3, 3, []float64{1, 3, -1, 2, 3, -2, 1, 0, 4}),
 		*mat.NewDense(3, 3, []float64{1, 2, 4, 0, 3, 3, 2, -2, -2}),
 	}
-	conv_1.Forward(&input)
-	pool.Forward(conv_1.DeflatOutput())
+	conv_1Out := conv_1.Forward(tensor.FromMats(input))
+	poolOut := pool.Forward(conv_1Out)
 
-	cnn := []conv.ConvLayer{&conv_1, &pool}
+	cnn := []layers.Layer{&conv_1, &pool}
 	grads := mat.NewVecDense(1, []float64{5})
 	optimizer := optimizers.NewSGD(0.1, 0.0)
-	optimizer.PreTrainInit([2]int{1, 1}, &cnn, &[]layers.Layer{})
-	optimizer.BackwardConv2DLayers(&cnn, grads)
+	optimizer.PreTrainInit(&cnn)
+	optimizer.Backward(&cnn, gradsLike(poolOut, grads))
 
 	result_filter := conv_1.GetFilter()
 	result_bias := conv_1.GetBias()
@@ -308,17 +313,16 @@ func TestSGD_Conv_Momentum_1(t *testing.T) {
 		*mat.NewDense(1, 1, []float64{0.5}),
 		*mat.NewDense(1, 1, []float64{3}),
 	}
-	convLayer_1.Forward(&input)
-	activated_1 := act_1.Forward(convLayer_1.DeflatOutput())
-	convLayer_2.Forward(activated_1)
-	act_2.Forward(convLayer_2.DeflatOutput())
+	convLayer_1Out := convLayer_1.Forward(tensor.FromMats(input))
+	activated_1 := act_1.Forward(convLayer_1Out)
+	convLayer_2Out := convLayer_2.Forward(activated_1)
+	act_2Out := act_2.Forward(convLayer_2Out)
 
-	outHeight, outWidth := convLayer_2.GetOutputSize()
 	optimizer := optimizers.NewSGD(0.1, 0.5)
-	convLayers := []conv.ConvLayer{&convLayer_1, &act_1, &convLayer_2, &act_2}
-	optimizer.PreTrainInit([2]int{outHeight, outWidth}, &convLayers, &[]layers.Layer{})
+	convLayers := []layers.Layer{&convLayer_1, &act_1, &convLayer_2, &act_2}
+	optimizer.PreTrainInit(&convLayers)
 	vec_grad := mat.NewVecDense(2, []float64{1, 2})
-	optimizer.BackwardConv2DLayers(&convLayers, vec_grad)
+	optimizer.Backward(&convLayers, gradsLike(act_2Out, vec_grad))
 
 	result_filter_1 := convLayer_1.GetFilter()
 	result_filter_2 := convLayer_2.GetFilter()
@@ -368,20 +372,20 @@ func TestSGD_Conv_Momentum_2(t *testing.T) {
 	convLayer.LoadFilter(&filter)
 	bias := []float64{1}
 	convLayer.LoadBias(&bias)
-	convLayers := []conv.ConvLayer{&convLayer}
+	convLayers := []layers.Layer{&convLayer}
 
 	optimizer := optimizers.NewSGD(0.5, 0.9)
-	optimizer.PreTrainInit([2]int{1, 1}, &convLayers, &[]layers.Layer{})
+	optimizer.PreTrainInit(&convLayers)
 
 	input1 := []mat.Dense{*mat.NewDense(2, 2, []float64{1, -1, 2, -3})}
-	convLayer.Forward(&input1)
+	convLayerOut := convLayer.Forward(tensor.FromMats(input1))
 	grad1 := mat.NewVecDense(1, []float64{2})
-	optimizer.BackwardConv2DLayers(&convLayers, grad1)
+	optimizer.Backward(&convLayers, gradsLike(convLayerOut, grad1))
 
 	input2 := []mat.Dense{*mat.NewDense(2, 2, []float64{2, -2, 1, -3})}
-	convLayer.Forward(&input2)
+	convLayerOut = convLayer.Forward(tensor.FromMats(input2))
 	grad2 := mat.NewVecDense(1, []float64{2})
-	optimizer.BackwardConv2DLayers(&convLayers, grad2)
+	optimizer.Backward(&convLayers, gradsLike(convLayerOut, grad2))
 
 	resultFilter := convLayer.GetFilter()
 	targetFilter := []mat.Dense{*mat.NewDense(2, 2, []float64{1.61, 0.39, 1.52, -0.13})}
@@ -401,3 +405,191 @@ func TestSGD_Conv_Momentum_2(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSGD_Conv_Transpose_Momentum_1(t *testing.T) {
+	layer := conv.NewConv2DTranspose(
+		[2]int{2, 3}, 2, [2]int{2, 2}, 2, [2]int{2, 1}, [4]int{1, 0, 0, 1}, [2]int{1, 0},
+	)
+	filter := []float64{
+		1, 0, -1, 2, 1, 0,
+		0, 1, 1, -1, 0, 2,
+
+		1, 1, 1, 0, -1, 0,
+		2, 0, 1, 1, -2, 1,
+	}
+	bias := []float64{0.5, -1}
+	layer.LoadFilter(&filter)
+	layer.LoadBias(&bias)
+	input := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, 2, 3, -1}),
+		*mat.NewDense(2, 2, []float64{0, 1, -2, 2}),
+	}
+	layerOut := layer.Forward(tensor.FromMats(input))
+
+	optimizer := optimizers.NewSGD(0.1, 0.9)
+	convLayers := []layers.Layer{&layer}
+	optimizer.PreTrainInit(&convLayers)
+	vecGrad := mat.NewVecDense(24, []float64{
+		-1, 2, -2, 0, -2, 1, 1, 1, 1, -1, -2, 1,
+		-2, 1, 1, 2, -2, 1, 0, -1, 2, -2, 0, -2,
+	})
+	optimizer.Backward(&convLayers, gradsLike(layerOut, vecGrad))
+
+	targetFilter := []mat.Dense{
+		*mat.NewDense(2, 3, []float64{1, -0.02, -0.93, 2.03, 0.95, 0}),
+		*mat.NewDense(2, 3, []float64{0, 1.04, 0.94, -1.01, -0.02, 2.02}),
+		*mat.NewDense(2, 3, []float64{1.02, 0.92, 1.07, 0.04, -1.01, 0.02}),
+		*mat.NewDense(2, 3, []float64{1.96, 0.08, 0.94, 1.02, -1.99, 0.93}),
+	}
+	targetBias := []float64{0.51, -0.98}
+	if !functools.IsEqualMatSlice(&targetFilter, layer.GetFilter(), 0.001) {
+		fmt.Println("== FILTER ==")
+		functools.PrintMatSlice(layer.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias, layer.GetBias(), 0.001) {
+		fmt.Println("== BIAS ==")
+		fmt.Println(*layer.GetBias())
+		t.Fail()
+	}
+}
+
+func TestSGD_BatchNorm2D(t *testing.T) {
+	batchNorm := conv.NewBatchNorm2D(1, 0.9, 1e-12)
+	cnn := []layers.Layer{&batchNorm}
+	optimizer := optimizers.NewSGD(0.1, 0.9)
+	optimizer.PreTrainInit(&cnn)
+
+	batchNorm.Forward(tensor.New(1, 1, 2, 2, []float64{1, 3, 1, 3}))
+	optimizer.Backward(&cnn, tensor.New(1, 1, 2, 2, []float64{1, 2, 3, -2}))
+
+	// Velocities start at zero, so the first step is scaled by (1 - momentum)
+	targetGamma := []float64{1.04}
+	targetBeta := []float64{-0.04}
+	if !functools.IsEqual(&targetGamma, batchNorm.GetGamma(), 1e-9) {
+		fmt.Println(*batchNorm.GetGamma())
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBeta, batchNorm.GetBeta(), 1e-9) {
+		fmt.Println(*batchNorm.GetBeta())
+		t.Fail()
+	}
+}
+
+func TestSGD_Conv_Nesterov_1(t *testing.T) {
+	conv1 := conv.NewConv2D([2]int{2, 1}, 2, [2]int{2, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+	act1 := conv.NewReLU()
+	conv2 := conv.NewConv2D([2]int{1, 2}, 1, [2]int{1, 2}, 2, [2]int{1, 1}, [4]int{0, 0, 0, 0})
+
+	filter1 := []float64{
+		1, -2, -1, 2,
+		2, -1, 2, 1,
+	}
+	bias1 := []float64{
+		1, -1,
+	}
+	filter2 := []float64{
+		3, 1, -2, 2,
+	}
+	bias2 := []float64{
+		2,
+	}
+
+	conv1.LoadFilter(&filter1)
+	conv1.LoadBias(&bias1)
+	conv2.LoadFilter(&filter2)
+	conv2.LoadBias(&bias2)
+	convs := []layers.Layer{&conv1, &act1, &conv2}
+	optimizer := optimizers.NewNesterovSGD(0.1, 0.9)
+	optimizer.PreTrainInit(&convs)
+
+	input1 := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{2, 1, -2, 3}),
+		*mat.NewDense(2, 2, []float64{1, -3, 4, 4}),
+	}
+	conv1Out1 := conv1.Forward(tensor.FromMats(input1))
+	conv2Out1 := conv2.Forward(act1.Forward(conv1Out1))
+	optimizer.Backward(&convs, gradsLike(conv2Out1, mat.NewVecDense(1, []float64{3})))
+
+	targetFilter1_1 := []mat.Dense{
+		*mat.NewDense(2, 1, []float64{0.601, -1.829}),
+		*mat.NewDense(2, 1, []float64{-1, 1.088}),
+		*mat.NewDense(2, 1, []float64{2.228, -1.228}),
+		*mat.NewDense(2, 1, []float64{2.114, 1.456}),
+	}
+	targetBias1_1 := []float64{0.772, -0.886}
+	targetFilter2_1 := []mat.Dense{
+		*mat.NewDense(1, 2, []float64{2.202, 0.601}),
+		*mat.NewDense(1, 2, []float64{-2.627, 2}),
+	}
+	targetBias2_1 := []float64{1.943}
+	if !functools.IsEqualMatSlice(&targetFilter1_1, conv1.GetFilter(), 0.001) {
+		fmt.Println("I FILTER 1")
+		functools.PrintMatSlice(&targetFilter1_1, 3)
+		functools.PrintMatSlice(conv1.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias1_1, conv1.GetBias(), 0.001) {
+		fmt.Println("I BIAS 1")
+		fmt.Println(targetBias1_1)
+		fmt.Println(*conv1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilter2_1, conv2.GetFilter(), 0.001) {
+		fmt.Println("II FILTER 1")
+		functools.PrintMatSlice(&targetFilter2_1, 3)
+		functools.PrintMatSlice(conv2.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias2_1, conv2.GetBias(), 0.001) {
+		fmt.Println("II BIAS 1")
+		fmt.Println(targetBias2_1)
+		fmt.Println(*conv2.GetBias())
+		t.Fail()
+	}
+
+	input2 := []mat.Dense{
+		*mat.NewDense(2, 2, []float64{1, 2, -5, 0}),
+		*mat.NewDense(2, 2, []float64{3, -2, 2, 2}),
+	}
+	conv1Out2 := conv1.Forward(tensor.FromMats(input2))
+	conv2Out2 := conv2.Forward(act1.Forward(conv1Out2))
+	optimizer.Backward(&convs, gradsLike(conv2Out2, mat.NewVecDense(1, []float64{2})))
+
+	targetFilter1_2 := []mat.Dense{
+		*mat.NewDense(2, 1, []float64{0.302, -1.338}),
+		*mat.NewDense(2, 1, []float64{-1.205, 0.486}),
+		*mat.NewDense(2, 1, []float64{2.273, -1.824}),
+		*mat.NewDense(2, 1, []float64{2.614, 1.698}),
+	}
+	targetBias1_2 := []float64{0.568, -0.814}
+	targetFilter2_2 := []mat.Dense{
+		*mat.NewDense(1, 2, []float64{1.493, 0.197}),
+		*mat.NewDense(1, 2, []float64{-3.53, 1.914}),
+	}
+	targetBias2_2 := []float64{1.881}
+	if !functools.IsEqualMatSlice(&targetFilter1_2, conv1.GetFilter(), 0.001) {
+		fmt.Println("I FILTER 2")
+		functools.PrintMatSlice(&targetFilter1_2, 3)
+		functools.PrintMatSlice(conv1.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias1_2, conv1.GetBias(), 0.001) {
+		fmt.Println("I BIAS 2")
+		fmt.Println(targetBias1_2)
+		fmt.Println(*conv1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMatSlice(&targetFilter2_2, conv2.GetFilter(), 0.001) {
+		fmt.Println("II FILTER 2")
+		functools.PrintMatSlice(&targetFilter2_2, 3)
+		functools.PrintMatSlice(conv2.GetFilter(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBias2_2, conv2.GetBias(), 0.001) {
+		fmt.Println("II BIAS 2")
+		fmt.Println(targetBias2_2)
+		fmt.Println(*conv2.GetBias())
+		t.Fail()
+	}
+}
@@ -7,10 +7,10 @@ import (
 
 	"gonum.org/v1/gonum/mat"
 
-	"DoodleGan/conv"
 	"DoodleGan/functools"
 	"DoodleGan/layers"
 	"DoodleGan/optimizers"
+	"DoodleGan/tensor"
 )
 
 func TestSGD_Dense_1(t *testing.T) {
@@ -29,7 +29,7 @@ func TestSGD_Dense_1(t *testing.T) {
 	act_2 := layers.NewVReLU()
 
 	input := mat.NewVecDense(3, []float64{-1, 0, 4})
-	output_1 := dense_1.Forward(input)
+	output_1 := dense_1.Forward(tensor.FromVec(input))
 	activated_1 := act_1.Forward(output_1)
 	output_2 := dense_2.Forward(activated_1)
 	act_2.Forward(output_2)
@@ -37,8 +37,8 @@ func TestSGD_Dense_1(t *testing.T) {
 	nn := []layers.Layer{&dense_1, &act_1, &dense_2, &act_2}
 	optimizers := optimizers.NewSGD(0.1, 0.0)
 	vec_grad := mat.NewVecDense(3, []float64{0.5, 0.1, 0.2})
-	optimizers.PreTrainInit([2]int{0, 0}, &[]conv.ConvLayer{}, &nn)
-	optimizers.BackwardDenseLayers(&nn, vec_grad)
+	optimizers.PreTrainInit(&nn)
+	optimizers.Backward(&nn, tensor.FromVec(vec_grad))
 
 	result_weights_1 := dense_1.GetWeightsData()
 	result_weights_2 := dense_2.GetWeightsData()
@@ -91,14 +91,14 @@ func TestSGD_Dense_Momentum_1(t *testing.T) {
 
 	denses := []layers.Layer{&dense1, &act, &dense2}
 	optimizer := optimizers.NewSGD(0.1, 0.9)
-	optimizer.PreTrainInit([2]int{0, 0}, &[]conv.ConvLayer{}, &denses)
+	optimizer.PreTrainInit(&denses)
 
 	input1 := mat.NewVecDense(3, []float64{1, -2, 4})
-	output1 := dense1.Forward(input1)
+	output1 := dense1.Forward(tensor.FromVec(input1))
 	activated := act.Forward(output1)
 	dense2.Forward(activated)
 
-	optimizer.BackwardDenseLayers(&denses, mat.NewVecDense(1, []float64{5}))
+	optimizer.Backward(&denses, tensor.FromVec(mat.NewVecDense(1, []float64{5})))
 
 	resultWeights1 := dense1.GetWeights()
 	targetWeights1 := mat.NewDense(2, 3, []float64{2.95, 2.1, 0.8, -3, 2, 2})
@@ -137,3 +137,110 @@ func TestSGD_Dense_Momentum_1(t *testing.T) {
 
 func TestSGD_Dense_Momentum_2(t *testing.T) {
 }
+
+func TestSGD_BatchNorm1D(t *testing.T) {
+	batchNorm := layers.NewBatchNorm1D(2, 0.9, 1e-12)
+	nn := []layers.Layer{&batchNorm}
+	optimizer := optimizers.NewSGD(0.1, 0.0)
+	optimizer.PreTrainInit(&nn)
+
+	batchNorm.Forward(tensor.New(2, 1, 1, 2, []float64{1, 2, 3, 6}))
+	optimizer.Backward(&nn, tensor.New(2, 1, 1, 2, []float64{1, 2, 3, -2}))
+
+	targetGamma := []float64{0.8, 1.4}
+	targetBeta := []float64{-0.4, 0}
+	if !functools.IsEqual(&targetGamma, batchNorm.GetGamma(), 1e-9) {
+		fmt.Println(*batchNorm.GetGamma())
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetBeta, batchNorm.GetBeta(), 1e-9) {
+		fmt.Println(*batchNorm.GetBeta())
+		t.Fail()
+	}
+}
+
+func TestSGD_Dense_Nesterov_1(t *testing.T) {
+	dense1 := layers.NewDenseLayer(3, 2)
+	weights1 := []float64{3, 2, 1, -3, 2, 2}
+	bias1 := []float64{2, -2}
+	dense1.LoadWeights(&weights1)
+	dense1.LoadBias(&bias1)
+	act := layers.NewVReLU()
+	dense2 := layers.NewDenseLayer(2, 1)
+	weights2 := []float64{1, 2}
+	bias2 := []float64{0}
+	dense2.LoadWeights(&weights2)
+	dense2.LoadBias(&bias2)
+
+	denses := []layers.Layer{&dense1, &act, &dense2}
+	optimizer := optimizers.NewNesterovSGD(0.1, 0.9)
+	optimizer.PreTrainInit(&denses)
+
+	input1 := mat.NewVecDense(3, []float64{1, -2, 4})
+	output1 := dense1.Forward(tensor.FromVec(input1))
+	dense2.Forward(act.Forward(output1))
+	optimizer.Backward(&denses, tensor.FromVec(mat.NewVecDense(1, []float64{5})))
+
+	targetWeights1_1 := mat.NewDense(2, 3, []float64{2.905, 2.19, 0.62, -3, 2, 2})
+	targetBias1_1 := mat.NewVecDense(2, []float64{1.905, -2})
+	targetWeights2_1 := mat.NewDense(1, 2, []float64{0.525, 2})
+	targetBias2_1 := mat.NewVecDense(1, []float64{-0.095})
+	if !functools.IsEqualMat(targetWeights1_1, dense1.GetWeights(), 0.001) {
+		fmt.Println("== I WEIGHTS 1 ==")
+		functools.PrintMat(targetWeights1_1, 3)
+		functools.PrintMat(dense1.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias1_1, dense1.GetBias(), 0.001) {
+		fmt.Println("== I BIAS 1 ==")
+		fmt.Println(targetBias1_1)
+		fmt.Println(dense1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMat(targetWeights2_1, dense2.GetWeights(), 0.001) {
+		fmt.Println("== II WEIGHTS 1 ==")
+		functools.PrintMat(targetWeights2_1, 3)
+		functools.PrintMat(dense2.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias2_1, dense2.GetBias(), 0.001) {
+		fmt.Println("== II BIAS 1 ==")
+		fmt.Println(targetBias2_1)
+		fmt.Println(dense2.GetBias())
+		t.Fail()
+	}
+
+	input2 := mat.NewVecDense(3, []float64{2, 1, -1})
+	output2 := dense1.Forward(tensor.FromVec(input2))
+	dense2.Forward(act.Forward(output2))
+	optimizer.Backward(&denses, tensor.FromVec(mat.NewVecDense(1, []float64{-3})))
+
+	targetWeights1_2 := mat.NewDense(2, 3, []float64{2.924, 2.301, 0.428, -3, 2, 2})
+	targetBias1_2 := mat.NewVecDense(2, []float64{1.894, -2})
+	targetWeights2_2 := mat.NewDense(1, 2, []float64{0.852, 2})
+	targetBias2_2 := mat.NewVecDense(1, []float64{-0.078})
+	if !functools.IsEqualMat(targetWeights1_2, dense1.GetWeights(), 0.001) {
+		fmt.Println("== I WEIGHTS 2 ==")
+		functools.PrintMat(targetWeights1_2, 3)
+		functools.PrintMat(dense1.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias1_2, dense1.GetBias(), 0.001) {
+		fmt.Println("== I BIAS 2 ==")
+		fmt.Println(targetBias1_2)
+		fmt.Println(dense1.GetBias())
+		t.Fail()
+	}
+	if !functools.IsEqualMat(targetWeights2_2, dense2.GetWeights(), 0.001) {
+		fmt.Println("== II WEIGHTS 2 ==")
+		functools.PrintMat(targetWeights2_2, 3)
+		functools.PrintMat(dense2.GetWeights(), 3)
+		t.Fail()
+	}
+	if !functools.IsEqualVec(targetBias2_2, dense2.GetBias(), 0.001) {
+		fmt.Println("== II BIAS 2 ==")
+		fmt.Println(targetBias2_2)
+		fmt.Println(dense2.GetBias())
+		t.Fail()
+	}
+}
@@ -0,0 +1,337 @@
+package preprocess
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/tensor"
+)
+
+// Random transform of a grayscale image with values in [0, 1] and 0 as background,
+// every call draws new parameters from rng
+type Transform interface {
+	Apply(image *mat.Dense, rng *rand.Rand) mat.Dense
+}
+
+// Applies transforms in order, e.g. to every batch of BatchIterator
+type Augmenter struct {
+	transforms []Transform
+	rng        *rand.Rand
+}
+
+func NewAugmenter(rng *rand.Rand, transforms ...Transform) Augmenter {
+	if rng == nil {
+		panic("NewAugmenter fail:\n\tRandom number generator can't be nil")
+	}
+	return Augmenter{
+		transforms: transforms,
+		rng:        rng,
+	}
+}
+
+func (a *Augmenter) Augment(image *mat.Dense) mat.Dense {
+	var retVal mat.Dense
+	retVal.CloneFrom(image)
+	for _, transform := range a.transforms {
+		retVal = transform.Apply(&retVal, a.rng)
+	}
+	return retVal
+}
+
+// Square image of uint8 values, e.g. QuickDraw 28 x 28 bitmap
+func (a *Augmenter) AugmentBytes(image []uint8, side int) []uint8 {
+	if len(image) != side*side {
+		mess := fmt.Sprintf(
+			"AugmentBytes fail:\n\tImage length (%d) doesn't match side %d",
+			len(image),
+			side,
+		)
+		panic(mess)
+	}
+	values := make([]float64, len(image))
+	for i, v := range image {
+		values[i] = float64(v) / 255.0
+	}
+	augmented := a.Augment(mat.NewDense(side, side, values))
+	retVal := make([]uint8, len(image))
+	for i, v := range augmented.RawMatrix().Data {
+		retVal[i] = uint8(math.Round(min(max(v, 0.0), 1.0) * 255.0))
+	}
+	return retVal
+}
+
+// Every channel of every sample is augmented independently, in place
+func (a *Augmenter) AugmentTensor(batch *tensor.Tensor) {
+	n, c, _, _ := batch.Dims()
+	for sample := range n {
+		for ch := range c {
+			channel := batch.Mat(sample, ch)
+			augmented := a.Augment(channel)
+			channel.Copy(&augmented)
+		}
+	}
+}
+
+// Moves the image by up to maxShift pixels along both axes
+type Shift struct {
+	maxShift int
+}
+
+func NewShift(maxShift int) Shift {
+	if maxShift < 0 {
+		panic(fmt.Sprintf("NewShift fail:\n\tMax shift can't be negative, have: %d", maxShift))
+	}
+	return Shift{maxShift: maxShift}
+}
+
+func (s *Shift) Apply(image *mat.Dense, rng *rand.Rand) mat.Dense {
+	dy := rng.Intn(2*s.maxShift+1) - s.maxShift
+	dx := rng.Intn(2*s.maxShift+1) - s.maxShift
+	h, w := image.Dims()
+	retVal := mat.NewDense(h, w, nil)
+	for i := max(dy, 0); i < min(h+dy, h); i++ {
+		for j := max(dx, 0); j < min(w+dx, w); j++ {
+			retVal.Set(i, j, image.At(i-dy, j-dx))
+		}
+	}
+	return *retVal
+}
+
+// Rotates the image around its center by up to maxDegrees in both directions
+type Rotation struct {
+	maxRadians float64
+}
+
+func NewRotation(maxDegrees float64) Rotation {
+	if maxDegrees < 0.0 {
+		panic(fmt.Sprintf("NewRotation fail:\n\tMax angle can't be negative, have: %f", maxDegrees))
+	}
+	return Rotation{maxRadians: maxDegrees * math.Pi / 180.0}
+}
+
+func (r *Rotation) Apply(image *mat.Dense, rng *rand.Rand) mat.Dense {
+	angle := (rng.Float64()*2.0 - 1.0) * r.maxRadians
+	sin, cos := math.Sincos(angle)
+	h, w := image.Dims()
+	cy, cx := float64(h-1)/2.0, float64(w-1)/2.0
+	return warp(image, func(y, x float64) (float64, float64) {
+		return cos*(y-cy) - sin*(x-cx) + cy, sin*(y-cy) + cos*(x-cx) + cx
+	})
+}
+
+// Mirrors the image left to right with given probability
+type HorizontalFlip struct {
+	probability float64
+}
+
+func NewHorizontalFlip(probability float64) HorizontalFlip {
+	if probability < 0.0 || probability > 1.0 {
+		mess := fmt.Sprintf(
+			"NewHorizontalFlip fail:\n\tProbability (%f) must be in range [0, 1]",
+			probability,
+		)
+		panic(mess)
+	}
+	return HorizontalFlip{probability: probability}
+}
+
+func (f *HorizontalFlip) Apply(image *mat.Dense, rng *rand.Rand) mat.Dense {
+	var retVal mat.Dense
+	retVal.CloneFrom(image)
+	if rng.Float64() >= f.probability {
+		return retVal
+	}
+	h, w := image.Dims()
+	for i := range h {
+		for j := range w {
+			retVal.Set(i, j, image.At(i, w-1-j))
+		}
+	}
+	return retVal
+}
+
+// Zooms the image around its center by a factor from [minFactor, maxFactor]
+type Scaling struct {
+	minFactor float64
+	maxFactor float64
+}
+
+func NewScaling(minFactor, maxFactor float64) Scaling {
+	if minFactor <= 0.0 || maxFactor < minFactor {
+		mess := fmt.Sprintf(
+			"NewScaling fail:\n\tFactors must be positive and min (%f) can't be greater than max (%f)",
+			minFactor,
+			maxFactor,
+		)
+		panic(mess)
+	}
+	return Scaling{minFactor: minFactor, maxFactor: maxFactor}
+}
+
+func (s *Scaling) Apply(image *mat.Dense, rng *rand.Rand) mat.Dense {
+	factor := s.minFactor + rng.Float64()*(s.maxFactor-s.minFactor)
+	h, w := image.Dims()
+	cy, cx := float64(h-1)/2.0, float64(w-1)/2.0
+	return warp(image, func(y, x float64) (float64, float64) {
+		return (y-cy)/factor + cy, (x-cx)/factor + cx
+	})
+}
+
+// Moves every pixel by a random displacement field smoothed with a gaussian
+// of width sigma and scaled by alpha
+//
+// https://doi.org/10.1109/ICDAR.2003.1227801
+type ElasticDistortion struct {
+	alpha float64
+	sigma float64
+}
+
+func NewElasticDistortion(alpha, sigma float64) ElasticDistortion {
+	if alpha < 0.0 || sigma <= 0.0 {
+		mess := fmt.Sprintf(
+			"NewElasticDistortion fail:\n\talpha (%f) can't be negative and sigma (%f) must be positive",
+			alpha,
+			sigma,
+		)
+		panic(mess)
+	}
+	return ElasticDistortion{alpha: alpha, sigma: sigma}
+}
+
+func (e *ElasticDistortion) Apply(image *mat.Dense, rng *rand.Rand) mat.Dense {
+	h, w := image.Dims()
+	dy := e.displacementField(h, w, rng)
+	dx := e.displacementField(h, w, rng)
+	return warp(image, func(y, x float64) (float64, float64) {
+		i, j := int(y), int(x)
+		return y + dy.At(i, j), x + dx.At(i, j)
+	})
+}
+
+func (e *ElasticDistortion) displacementField(h, w int, rng *rand.Rand) *mat.Dense {
+	field := mat.NewDense(h, w, nil)
+	for i := range h {
+		for j := range w {
+			field.Set(i, j, rng.Float64()*2.0-1.0)
+		}
+	}
+	radius := int(math.Ceil(3.0 * e.sigma))
+	kernel := make([]float64, 2*radius+1)
+	for k := range kernel {
+		d := float64(k - radius)
+		kernel[k] = math.Exp(-d * d / (2.0 * e.sigma * e.sigma))
+	}
+	kernelSum := 0.0
+	for _, v := range kernel {
+		kernelSum += v
+	}
+	for k := range kernel {
+		kernel[k] *= e.alpha / kernelSum
+	}
+
+	// Separable blur, rows then columns, zero outside the image
+	rowsBlurred := mat.NewDense(h, w, nil)
+	for i := range h {
+		for j := range w {
+			sum := 0.0
+			for k, v := range kernel {
+				if jj := j + k - radius; jj >= 0 && jj < w {
+					sum += v * field.At(i, jj)
+				}
+			}
+			rowsBlurred.Set(i, j, sum)
+		}
+	}
+	retVal := mat.NewDense(h, w, nil)
+	for i := range h {
+		for j := range w {
+			sum := 0.0
+			for k, v := range kernel {
+				if ii := i + k - radius; ii >= 0 && ii < h {
+					sum += v * rowsBlurred.At(ii, j)
+				}
+			}
+			retVal.Set(i, j, sum)
+		}
+	}
+	return retVal
+}
+
+// Makes strokes thicker (dilation) or thinner (erosion) by up to maxRadius pixels
+type ThicknessJitter struct {
+	maxRadius int
+}
+
+func NewThicknessJitter(maxRadius int) ThicknessJitter {
+	if maxRadius < 0 {
+		panic(fmt.Sprintf("NewThicknessJitter fail:\n\tMax radius can't be negative, have: %d", maxRadius))
+	}
+	return ThicknessJitter{maxRadius: maxRadius}
+}
+
+func (t *ThicknessJitter) Apply(image *mat.Dense, rng *rand.Rand) mat.Dense {
+	radius := rng.Intn(2*t.maxRadius+1) - t.maxRadius
+	var retVal mat.Dense
+	retVal.CloneFrom(image)
+	for range max(radius, -radius) {
+		retVal = morphology(&retVal, radius > 0)
+	}
+	return retVal
+}
+
+// Max (dilate) or min (erode) of every 3 x 3 neighbourhood, border is background
+func morphology(image *mat.Dense, dilate bool) mat.Dense {
+	h, w := image.Dims()
+	retVal := mat.NewDense(h, w, nil)
+	for i := range h {
+		for j := range w {
+			v := image.At(i, j)
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					neighbour := 0.0
+					if ii, jj := i+di, j+dj; ii >= 0 && ii < h && jj >= 0 && jj < w {
+						neighbour = image.At(ii, jj)
+					}
+					if dilate {
+						v = max(v, neighbour)
+					} else {
+						v = min(v, neighbour)
+					}
+				}
+			}
+			retVal.Set(i, j, v)
+		}
+	}
+	return *retVal
+}
+
+// Every output pixel takes the bilinear interpolated value at source(y, x)
+func warp(image *mat.Dense, source func(y, x float64) (float64, float64)) mat.Dense {
+	h, w := image.Dims()
+	retVal := mat.NewDense(h, w, nil)
+	for i := range h {
+		for j := range w {
+			sy, sx := source(float64(i), float64(j))
+			retVal.Set(i, j, bilinear(image, sy, sx))
+		}
+	}
+	return *retVal
+}
+
+func bilinear(image *mat.Dense, y, x float64) float64 {
+	h, w := image.Dims()
+	y0, x0 := math.Floor(y), math.Floor(x)
+	fy, fx := y-y0, x-x0
+	at := func(i, j int) float64 {
+		if i < 0 || i >= h || j < 0 || j >= w {
+			return 0.0
+		}
+		return image.At(i, j)
+	}
+	i, j := int(y0), int(x0)
+	return (1.0-fy)*((1.0-fx)*at(i, j)+fx*at(i, j+1)) +
+		fy*((1.0-fx)*at(i+1, j)+fx*at(i+1, j+1))
+}
@@ -0,0 +1,147 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/preprocess"
+)
+
+func testImage() *mat.Dense {
+	return mat.NewDense(5, 5, []float64{
+		0, 0, 0, 0, 0,
+		0, 1, 0.5, 0, 0,
+		0, 0, 1, 0, 0,
+		0, 0, 0.2, 0, 0,
+		0, 0, 0, 0, 0,
+	})
+}
+
+func TestTransforms_Identity(t *testing.T) {
+	shift := preprocess.NewShift(0)
+	rotation := preprocess.NewRotation(0)
+	flip := preprocess.NewHorizontalFlip(0)
+	scaling := preprocess.NewScaling(1, 1)
+	elastic := preprocess.NewElasticDistortion(0, 2)
+	thickness := preprocess.NewThicknessJitter(0)
+	transforms := []preprocess.Transform{&shift, &rotation, &flip, &scaling, &elastic, &thickness}
+	rng := rand.New(rand.NewSource(1))
+	for _, transform := range transforms {
+		result := transform.Apply(testImage(), rng)
+		if !functools.IsEqualMat(testImage(), &result, 1e-12) {
+			fmt.Printf("%T\n", transform)
+			functools.PrintMat(&result, 2)
+			t.Fail()
+		}
+	}
+}
+
+func TestHorizontalFlip(t *testing.T) {
+	flip := preprocess.NewHorizontalFlip(1)
+	result := flip.Apply(testImage(), rand.New(rand.NewSource(1)))
+	target := mat.NewDense(5, 5, []float64{
+		0, 0, 0, 0, 0,
+		0, 0, 0.5, 1, 0,
+		0, 0, 1, 0, 0,
+		0, 0, 0.2, 0, 0,
+		0, 0, 0, 0, 0,
+	})
+	if !functools.IsEqualMat(target, &result, 0) {
+		functools.PrintMat(&result, 2)
+		t.Fail()
+	}
+}
+
+// Single pixel away from the border keeps its value and moves by at most maxShift
+func TestShift(t *testing.T) {
+	shift := preprocess.NewShift(2)
+	rng := rand.New(rand.NewSource(3))
+	for range 20 {
+		image := mat.NewDense(7, 7, nil)
+		image.Set(3, 3, 1)
+		result := shift.Apply(image, rng)
+		if mat.Sum(&result) != 1 {
+			functools.PrintMat(&result, 1)
+			t.Fail()
+		}
+	}
+}
+
+// Center of an odd sized image is the rotation axis
+func TestRotation_Center(t *testing.T) {
+	rotation := preprocess.NewRotation(180)
+	rng := rand.New(rand.NewSource(1))
+	for range 10 {
+		result := rotation.Apply(testImage(), rng)
+		if result.At(2, 2) != 1 {
+			functools.PrintMat(&result, 2)
+			t.Fail()
+		}
+	}
+}
+
+func TestThicknessJitter(t *testing.T) {
+	thickness := preprocess.NewThicknessJitter(1)
+	rng := rand.New(rand.NewSource(2))
+	seen := map[float64]bool{}
+	for range 30 {
+		image := mat.NewDense(5, 5, nil)
+		image.Set(2, 2, 1)
+		result := thickness.Apply(image, rng)
+		sum := mat.Sum(&result)
+		if sum != 0 && sum != 1 && sum != 9 {
+			functools.PrintMat(&result, 1)
+			t.Fail()
+		}
+		seen[sum] = true
+	}
+	if len(seen) != 3 {
+		fmt.Println(seen)
+		t.Fail()
+	}
+}
+
+func TestAugmenter_Seed(t *testing.T) {
+	newAugmenter := func() preprocess.Augmenter {
+		shift := preprocess.NewShift(1)
+		rotation := preprocess.NewRotation(15)
+		scaling := preprocess.NewScaling(0.9, 1.1)
+		elastic := preprocess.NewElasticDistortion(2, 1)
+		return preprocess.NewAugmenter(rand.New(rand.NewSource(8)), &shift, &rotation, &scaling, &elastic)
+	}
+	first := newAugmenter()
+	second := newAugmenter()
+	image := make([]uint8, 28*28)
+	for i := range image {
+		image[i] = uint8(i % 256)
+	}
+	firstResult := first.AugmentBytes(image, 28)
+	secondResult := second.AugmentBytes(image, 28)
+	if fmt.Sprint(firstResult) != fmt.Sprint(secondResult) || fmt.Sprint(firstResult) == fmt.Sprint(image) {
+		t.Fail()
+	}
+}
+
+func TestBatchIterator_Augmenter(t *testing.T) {
+	scaling := preprocess.NewScaling(0.5, 0.5)
+	augmenter := preprocess.NewAugmenter(rand.New(rand.NewSource(1)), &scaling)
+	options := preprocess.BatchOptions{Prefetch: true, Augmenter: &augmenter}
+	source := &countingSource{3}
+	it := preprocess.NewBatchIterator(source, 2, [3]int{1, 2, 2}, nil, options)
+	defer it.Close()
+	it.Next()
+	batch, _ := it.Next()
+
+	// Zoomed out 2 x 2 sample spreads every pixel over background, so values drop 4 times
+	input := batch.Input.RawData()
+	v := 2.0 / 255.0 / 4.0
+	target := []float64{v, v, v, v}
+	if !functools.IsEqual(&target, &input, 1e-12) {
+		fmt.Println(input)
+		t.Fail()
+	}
+}
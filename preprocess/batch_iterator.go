@@ -0,0 +1,193 @@
+package preprocess
+
+import (
+	"fmt"
+	"math/rand"
+
+	"DoodleGan/tensor"
+)
+
+// Anything holding labelled uint8 samples of the same length, e.g. Dataset
+type LabelledSource interface {
+	Len() int
+	SampleLen() int
+	NumClasses() int
+	Sample(idx int) []uint8
+	Label(idx int) int
+}
+
+type Batch struct {
+	Input   *tensor.Tensor // N x C x H x W, values scaled to [0, 1]
+	Targets *tensor.Tensor // N x 1 x 1 x classes, one-hot
+	Labels  []int
+}
+
+type BatchOptions struct {
+	DropLast bool // skip the last batch smaller than batch size
+	Shuffle  bool // new sample order every epoch
+	Prefetch bool // prepare batches on a goroutine while the current one is used
+
+	// Applied to every batch input, its generator is used only by the goroutine making batches
+	Augmenter *Augmenter
+}
+
+// Yields batches of one epoch, then Next returns false once and the iterator
+// moves to the next epoch, so the same loop can be run every epoch.
+// With Prefetch the producer goroutine waits on its channel until the epoch is
+// read to the end or Close is called, so leaving a loop early needs Close,
+// usually as defer it.Close() right after creating the iterator.
+type BatchIterator struct {
+	source    LabelledSource
+	batchSize int
+	shape     [3]int // C, H, W
+	options   BatchOptions
+	rng       *rand.Rand
+
+	order []int
+	pos   int
+	epoch int
+
+	batches chan Batch
+	done    chan struct{}
+}
+
+func NewBatchIterator(
+	source LabelledSource,
+	batchSize int,
+	shape [3]int,
+	rng *rand.Rand,
+	options BatchOptions,
+) BatchIterator {
+	if batchSize < 1 || source.Len() < 1 {
+		mess := fmt.Sprintf(
+			"NewBatchIterator fail:\n\tBatch size (%d) and number of samples (%d) must be positive",
+			batchSize,
+			source.Len(),
+		)
+		panic(mess)
+	}
+	if shape[0]*shape[1]*shape[2] != source.SampleLen() {
+		mess := fmt.Sprintf(
+			"NewBatchIterator fail:\n\tShape %d x %d x %d doesn't match sample length (%d)",
+			shape[0], shape[1], shape[2],
+			source.SampleLen(),
+		)
+		panic(mess)
+	}
+	if options.Shuffle && rng == nil {
+		panic("NewBatchIterator fail:\n\tShuffling needs a random number generator")
+	}
+	it := BatchIterator{
+		source:    source,
+		batchSize: batchSize,
+		shape:     shape,
+		options:   options,
+		rng:       rng,
+		order:     make([]int, source.Len()),
+	}
+	for i := range it.order {
+		it.order[i] = i
+	}
+	it.shuffle()
+	return it
+}
+
+func (it *BatchIterator) NumBatches() int {
+	if it.options.DropLast {
+		return it.source.Len() / it.batchSize
+	}
+	return (it.source.Len() + it.batchSize - 1) / it.batchSize
+}
+
+// Number of finished epochs
+func (it *BatchIterator) Epoch() int {
+	return it.epoch
+}
+
+func (it *BatchIterator) Next() (Batch, bool) {
+	if !it.options.Prefetch {
+		if it.pos >= it.NumBatches() {
+			it.nextEpoch()
+			return Batch{}, false
+		}
+		it.pos++
+		return it.makeBatch(it.pos - 1), true
+	}
+
+	if it.batches == nil {
+		it.startPrefetch()
+	}
+	batch, ok := <-it.batches
+	if !ok {
+		it.batches = nil
+		it.nextEpoch()
+		return Batch{}, false
+	}
+	return batch, true
+}
+
+// Abandons the current epoch and stops its prefetching, the next call to Next starts a new epoch.
+// Calling it again, or after a finished epoch, does nothing.
+func (it *BatchIterator) Close() {
+	if it.batches != nil {
+		close(it.done)
+		for range it.batches {
+		}
+		it.batches = nil
+		it.nextEpoch()
+	} else if it.pos > 0 {
+		it.nextEpoch()
+	}
+}
+
+// Order is read only while the producer runs, it's reshuffled after the channel is closed
+func (it *BatchIterator) startPrefetch() {
+	it.batches = make(chan Batch, 1)
+	it.done = make(chan struct{})
+	go func(batches chan<- Batch, done <-chan struct{}) {
+		defer close(batches)
+		for b := range it.NumBatches() {
+			select {
+			case batches <- it.makeBatch(b):
+			case <-done:
+				return
+			}
+		}
+	}(it.batches, it.done)
+}
+
+func (it *BatchIterator) nextEpoch() {
+	it.pos = 0
+	it.epoch++
+	it.shuffle()
+}
+
+func (it *BatchIterator) shuffle() {
+	if it.options.Shuffle {
+		it.rng.Shuffle(len(it.order), func(i, j int) {
+			it.order[i], it.order[j] = it.order[j], it.order[i]
+		})
+	}
+}
+
+func (it *BatchIterator) makeBatch(b int) Batch {
+	idxs := it.order[b*it.batchSize : min((b+1)*it.batchSize, len(it.order))]
+	numClasses := it.source.NumClasses()
+	batch := Batch{
+		Input:   tensor.New(len(idxs), it.shape[0], it.shape[1], it.shape[2], nil),
+		Targets: tensor.New(len(idxs), 1, 1, numClasses, nil),
+		Labels:  make([]int, len(idxs)),
+	}
+	for n, idx := range idxs {
+		input := batch.Input.Sample(n)
+		for i, v := range it.source.Sample(idx) {
+			input[i] = float64(v) / 255.0
+		}
+		batch.Labels[n] = it.source.Label(idx)
+		batch.Targets.Sample(n)[batch.Labels[n]] = 1.0
+	}
+	if it.options.Augmenter != nil {
+		it.options.Augmenter.AugmentTensor(batch.Input)
+	}
+	return batch
+}
@@ -0,0 +1,135 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/preprocess"
+)
+
+// Sample i is 2 x 2 filled with i, its label is i % 3
+type countingSource struct {
+	n int
+}
+
+func (s *countingSource) Len() int        { return s.n }
+func (s *countingSource) SampleLen() int  { return 4 }
+func (s *countingSource) NumClasses() int { return 3 }
+func (s *countingSource) Label(idx int) int {
+	return idx % 3
+}
+
+func (s *countingSource) Sample(idx int) []uint8 {
+	return []uint8{uint8(idx), uint8(idx), uint8(idx), uint8(idx)}
+}
+
+// Sample indices of every batch of one epoch
+func epochIdxs(it *preprocess.BatchIterator) [][]int {
+	var retVal [][]int
+	for {
+		batch, ok := it.Next()
+		if !ok {
+			return retVal
+		}
+		idxs := make([]int, batch.Input.Len())
+		for n := range idxs {
+			idxs[n] = int(batch.Input.Sample(n)[0]*255.0 + 0.5)
+		}
+		retVal = append(retVal, idxs)
+	}
+}
+
+func TestBatchIterator_Epoch(t *testing.T) {
+	testCases := []struct {
+		options preprocess.BatchOptions
+		sizes   []int
+	}{
+		{preprocess.BatchOptions{}, []int{4, 4, 2}},
+		{preprocess.BatchOptions{DropLast: true}, []int{4, 4}},
+		{preprocess.BatchOptions{Shuffle: true}, []int{4, 4, 2}},
+		{preprocess.BatchOptions{Shuffle: true, DropLast: true, Prefetch: true}, []int{4, 4}},
+	}
+	for _, tc := range testCases {
+		it := preprocess.NewBatchIterator(&countingSource{10}, 4, [3]int{1, 2, 2}, rand.New(rand.NewSource(1)), tc.options)
+		defer it.Close()
+		for epoch := range 2 {
+			batches := epochIdxs(&it)
+			var all []int
+			sizes := make([]int, len(batches))
+			for b, idxs := range batches {
+				sizes[b] = len(idxs)
+				all = append(all, idxs...)
+			}
+			slices.Sort(all)
+			if fmt.Sprint(sizes) != fmt.Sprint(tc.sizes) || len(slices.Compact(all)) != len(all) {
+				fmt.Println(tc.options, batches)
+				t.Fail()
+			}
+			if it.Epoch() != epoch+1 {
+				fmt.Println(it.Epoch())
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestBatchIterator_Shuffle(t *testing.T) {
+	options := preprocess.BatchOptions{Shuffle: true}
+	first := preprocess.NewBatchIterator(&countingSource{20}, 5, [3]int{1, 2, 2}, rand.New(rand.NewSource(4)), options)
+	defer first.Close()
+	options.Prefetch = true
+	second := preprocess.NewBatchIterator(&countingSource{20}, 5, [3]int{1, 2, 2}, rand.New(rand.NewSource(4)), options)
+	defer second.Close()
+
+	firstEpoch := fmt.Sprint(epochIdxs(&first))
+	if firstEpoch != fmt.Sprint(epochIdxs(&second)) {
+		t.Fail()
+	}
+	nextEpoch := fmt.Sprint(epochIdxs(&first))
+	if nextEpoch == firstEpoch || nextEpoch != fmt.Sprint(epochIdxs(&second)) {
+		fmt.Println(firstEpoch)
+		fmt.Println(nextEpoch)
+		t.Fail()
+	}
+}
+
+func TestBatchIterator_Batch(t *testing.T) {
+	it := preprocess.NewBatchIterator(&countingSource{5}, 2, [3]int{1, 2, 2}, nil, preprocess.BatchOptions{})
+	defer it.Close()
+	it.Next()
+	batch, _ := it.Next()
+	if n, c, h, w := batch.Input.Dims(); n != 2 || c != 1 || h != 2 || w != 2 {
+		fmt.Println(n, c, h, w)
+		t.Fail()
+	}
+	targetInput := []float64{
+		2.0 / 255.0, 2.0 / 255.0, 2.0 / 255.0, 2.0 / 255.0,
+		3.0 / 255.0, 3.0 / 255.0, 3.0 / 255.0, 3.0 / 255.0,
+	}
+	targetTargets := []float64{
+		0, 0, 1,
+		1, 0, 0,
+	}
+	input := batch.Input.RawData()
+	targets := batch.Targets.RawData()
+	if !functools.IsEqual(&targetInput, &input, 1e-12) || !functools.IsEqual(&targetTargets, &targets, 0) ||
+		fmt.Sprint(batch.Labels) != "[2 0]" {
+		fmt.Println(input, targets, batch.Labels)
+		t.Fail()
+	}
+}
+
+func TestBatchIterator_Close(t *testing.T) {
+	options := preprocess.BatchOptions{Prefetch: true}
+	it := preprocess.NewBatchIterator(&countingSource{10}, 2, [3]int{1, 2, 2}, nil, options)
+	defer it.Close()
+	it.Next()
+	it.Close()
+	if len(epochIdxs(&it)) != 5 || it.Epoch() != 2 {
+		fmt.Println(it.Epoch())
+		t.Fail()
+	}
+}
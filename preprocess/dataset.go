@@ -0,0 +1,152 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"DoodleGan/functools"
+)
+
+type CategoryFile struct {
+	Name string
+	Path string
+}
+
+// QuickDraw npy files are named after their category, e.g. dir/cat.npy
+func CategoryFiles(dir string, names []string) []CategoryFile {
+	retVal := make([]CategoryFile, len(names))
+	for i, name := range names {
+		retVal[i] = CategoryFile{Name: name, Path: filepath.Join(dir, name+".npy")}
+	}
+	return retVal
+}
+
+// Labelled samples of many categories, class index is the position of the category
+// in the list it was loaded from. Samples are stored class after class.
+type Dataset struct {
+	sampleLen  int
+	classNames []string
+	classIndex map[string]int
+	samples    [][]uint8
+	labels     []int
+}
+
+// Every class keeps at most maxPerClass first samples, negative keeps all of them.
+// Balanced dataset cuts every class to the size of the smallest one.
+func LoadDataset(
+	categories []CategoryFile,
+	sampleLen, maxPerClass int,
+	balanced bool,
+) (Dataset, error) {
+	if len(categories) == 0 {
+		return Dataset{}, errors.New("Dataset needs at least one category")
+	}
+	if sampleLen < 1 {
+		return Dataset{}, fmt.Errorf("Sample length must be positive, have: %d", sampleLen)
+	}
+	dataset := Dataset{
+		sampleLen:  sampleLen,
+		classNames: make([]string, len(categories)),
+		classIndex: make(map[string]int, len(categories)),
+	}
+	classRows := make([]NpyRows, 0, len(categories))
+	defer func() {
+		for i := range classRows {
+			classRows[i].Close()
+		}
+	}()
+	for i, category := range categories {
+		if _, ok := dataset.classIndex[category.Name]; ok {
+			return Dataset{}, fmt.Errorf("Category %s is duplicated", category.Name)
+		}
+		dataset.classNames[i] = category.Name
+		dataset.classIndex[category.Name] = i
+
+		rows, err := OpenNpyRows(category.Path, sampleLen, false)
+		if err != nil {
+			return Dataset{}, fmt.Errorf("Category %s: %w", category.Name, err)
+		}
+		classRows = append(classRows, rows)
+	}
+
+	// Only rows that are kept are read from the files
+	classSize := maxPerClass
+	for i := range classRows {
+		if balanced && (classSize < 0 || classRows[i].Len() < classSize) {
+			classSize = classRows[i].Len()
+		}
+	}
+	for i := range classRows {
+		numSamples := classRows[i].Len()
+		if classSize >= 0 {
+			numSamples = min(classSize, numSamples)
+		}
+		rawData, err := classRows[i].Rows(0, numSamples)
+		if err != nil {
+			return Dataset{}, fmt.Errorf("Category %s: %w", categories[i].Name, err)
+		}
+		dataset.samples = append(dataset.samples, Reshape(rawData, sampleLen)...)
+		dataset.labels = append(dataset.labels, functools.RepeatSlice(i, numSamples)...)
+	}
+	return dataset, nil
+}
+
+func (dataset *Dataset) Len() int {
+	return len(dataset.samples)
+}
+
+func (dataset *Dataset) SampleLen() int {
+	return dataset.sampleLen
+}
+
+func (dataset *Dataset) NumClasses() int {
+	return len(dataset.classNames)
+}
+
+func (dataset *Dataset) ClassNames() []string {
+	return dataset.classNames
+}
+
+func (dataset *Dataset) ClassName(idx int) string {
+	return dataset.classNames[idx]
+}
+
+func (dataset *Dataset) ClassIndex(name string) (int, bool) {
+	idx, ok := dataset.classIndex[name]
+	return idx, ok
+}
+
+func (dataset *Dataset) ClassCounts() []int {
+	retVal := make([]int, len(dataset.classNames))
+	for _, label := range dataset.labels {
+		retVal[label]++
+	}
+	return retVal
+}
+
+func (dataset *Dataset) Sample(idx int) []uint8 {
+	return dataset.samples[idx]
+}
+
+func (dataset *Dataset) Label(idx int) int {
+	return dataset.labels[idx]
+}
+
+func (dataset *Dataset) OneHot(idx int) []float64 {
+	return functools.ArgToSliceLabel(len(dataset.classNames), dataset.labels[idx])
+}
+
+// Samples scaled from [0, 255] to [0, 1] and one-hot labels, both flattened
+// the way Sequential.Train takes them
+func (dataset *Dataset) Flatten() ([]float64, []float64) {
+	X := make([]float64, 0, len(dataset.samples)*dataset.sampleLen)
+	y := make([]float64, 0, len(dataset.samples)*len(dataset.classNames))
+	for i, sample := range dataset.samples {
+		for _, v := range sample {
+			X = append(X, float64(v)/255.0)
+		}
+		y = append(y, dataset.OneHot(i)...)
+	}
+	return X, y
+}
@@ -0,0 +1,109 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbinet/npyio"
+
+	"DoodleGan/functools"
+	"DoodleGan/preprocess"
+)
+
+// Every sample of class i is filled with value i, samples are 2 values long
+func writeCategories(t *testing.T, counts map[string]int) string {
+	dir := t.TempDir()
+	value := uint8(0)
+	for _, name := range []string{"cat", "tornado", "tree"} {
+		count, ok := counts[name]
+		if !ok {
+			continue
+		}
+		data := make([]uint8, 2*count)
+		for i := range data {
+			data[i] = value
+		}
+		value++
+		file, err := os.Create(filepath.Join(dir, name+".npy"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := npyio.Write(file, data); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	return dir
+}
+
+func TestLoadDataset(t *testing.T) {
+	dir := writeCategories(t, map[string]int{"cat": 3, "tornado": 5, "tree": 2})
+	categories := preprocess.CategoryFiles(dir, []string{"cat", "tornado", "tree"})
+	testCases := []struct {
+		maxPerClass int
+		balanced    bool
+		counts      []int
+	}{
+		{-1, false, []int{3, 5, 2}},
+		{4, false, []int{3, 4, 2}},
+		{-1, true, []int{2, 2, 2}},
+		{1, true, []int{1, 1, 1}},
+	}
+	for _, tc := range testCases {
+		dataset, err := preprocess.LoadDataset(categories, 2, tc.maxPerClass, tc.balanced)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := dataset.ClassCounts()
+		if fmt.Sprint(counts) != fmt.Sprint(tc.counts) {
+			fmt.Println(tc.maxPerClass, tc.balanced, counts)
+			t.Fail()
+		}
+		for i := range dataset.Len() {
+			if int(dataset.Sample(i)[0]) != dataset.Label(i) {
+				fmt.Println(i, dataset.Sample(i), dataset.Label(i))
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestDataset_Classes(t *testing.T) {
+	dir := writeCategories(t, map[string]int{"cat": 1, "tornado": 1})
+	dataset, err := preprocess.LoadDataset(preprocess.CategoryFiles(dir, []string{"tornado", "cat"}), 2, -1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, ok := dataset.ClassIndex("cat")
+	if !ok || idx != 1 || dataset.ClassName(0) != "tornado" || dataset.NumClasses() != 2 {
+		fmt.Println(idx, ok, dataset.ClassNames())
+		t.Fail()
+	}
+	if _, ok := dataset.ClassIndex("dog"); ok {
+		t.Fail()
+	}
+
+	// Values of tornado are 1, values of cat are 0
+	X, y := dataset.Flatten()
+	targetX := []float64{1.0 / 255.0, 1.0 / 255.0, 0, 0}
+	targetY := []float64{1, 0, 0, 1}
+	if !functools.IsEqual(&targetX, &X, 1e-12) || !functools.IsEqual(&targetY, &y, 0) {
+		fmt.Println(X, y)
+		t.Fail()
+	}
+}
+
+func TestLoadDataset_Errors(t *testing.T) {
+	dir := writeCategories(t, map[string]int{"cat": 3})
+	if _, err := preprocess.LoadDataset(preprocess.CategoryFiles(dir, []string{"cat", "cat"}), 2, -1, false); err == nil {
+		t.Fail()
+	}
+	if _, err := preprocess.LoadDataset(preprocess.CategoryFiles(dir, []string{"cat"}), 4, -1, false); err == nil {
+		t.Fail()
+	}
+	if _, err := preprocess.LoadDataset(preprocess.CategoryFiles(dir, []string{"dog"}), 2, -1, false); err == nil {
+		t.Fail()
+	}
+}
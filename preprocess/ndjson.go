@@ -0,0 +1,178 @@
+package preprocess
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+/*
+
+   QuickDraw ndjson format, one drawing per line:
+
+   https://github.com/googlecreativelab/quickdraw-dataset#the-raw-moderated-dataset
+
+   Simplified files hold x and y of every stroke, raw files also hold time of every point.
+
+*/
+
+const quickDrawTimeLayout = "2006-01-02 15:04:05.999999 MST"
+
+type Stroke struct {
+	X []float64
+	Y []float64
+	T []float64 // Milliseconds since first point, empty in simplified files
+}
+
+type Drawing struct {
+	KeyID       string
+	Word        string
+	CountryCode string
+	Timestamp   time.Time
+	Recognized  bool
+	Strokes     []Stroke
+}
+
+type rawDrawing struct {
+	KeyID       string        `json:"key_id"`
+	Word        string        `json:"word"`
+	CountryCode string        `json:"countrycode"`
+	Timestamp   string        `json:"timestamp"`
+	Recognized  bool          `json:"recognized"`
+	Drawing     [][][]float64 `json:"drawing"`
+}
+
+func (d *Drawing) UnmarshalJSON(data []byte) error {
+	var raw rawDrawing
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	timestamp, err := time.Parse(quickDrawTimeLayout, raw.Timestamp)
+	if err != nil {
+		return err
+	}
+	strokes := make([]Stroke, len(raw.Drawing))
+	for i, stroke := range raw.Drawing {
+		if len(stroke) < 2 || len(stroke) > 3 || len(stroke[0]) != len(stroke[1]) {
+			return fmt.Errorf("stroke %d must hold x and y (and t) of the same length", i)
+		}
+		strokes[i] = Stroke{X: stroke[0], Y: stroke[1]}
+		if len(stroke) == 3 {
+			if len(stroke[2]) != len(stroke[0]) {
+				return fmt.Errorf("stroke %d time length doesn't match number of points", i)
+			}
+			strokes[i].T = stroke[2]
+		}
+	}
+	*d = Drawing{
+		KeyID:       raw.KeyID,
+		Word:        raw.Word,
+		CountryCode: raw.CountryCode,
+		Timestamp:   timestamp,
+		Recognized:  raw.Recognized,
+		Strokes:     strokes,
+	}
+	return nil
+}
+
+type RecognizedFilter int
+
+const (
+	AnyRecognized RecognizedFilter = iota
+	OnlyRecognized
+	OnlyUnrecognized
+)
+
+// Countries are two letter codes, empty list accepts every country
+type DrawingFilter struct {
+	Recognized RecognizedFilter
+	Countries  []string
+}
+
+func (filter *DrawingFilter) accepts(drawing *Drawing) bool {
+	switch filter.Recognized {
+	case OnlyRecognized:
+		if !drawing.Recognized {
+			return false
+		}
+	case OnlyUnrecognized:
+		if drawing.Recognized {
+			return false
+		}
+	}
+	return len(filter.Countries) == 0 || slices.Contains(filter.Countries, drawing.CountryCode)
+}
+
+// Streams drawings line by line, so whole category files don't have to fit in memory
+type NdjsonReader struct {
+	scanner *bufio.Scanner
+	filter  DrawingFilter
+	line    int
+	drawing Drawing
+	err     error
+}
+
+// Raw drawings with timing can be long, lines up to maxLineSize bytes are accepted
+const maxLineSize = 16 * 1024 * 1024
+
+func NewNdjsonReader(source io.Reader, filter DrawingFilter) NdjsonReader {
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return NdjsonReader{
+		scanner: scanner,
+		filter:  filter,
+	}
+}
+
+// Advances to the next drawing accepted by the filter, returns false at the end
+// of input or on the first error
+func (reader *NdjsonReader) Next() bool {
+	if reader.err != nil {
+		return false
+	}
+	for reader.scanner.Scan() {
+		reader.line++
+		line := reader.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var drawing Drawing
+		if err := json.Unmarshal(line, &drawing); err != nil {
+			reader.err = fmt.Errorf("ndjson line %d: %w", reader.line, err)
+			return false
+		}
+		if reader.filter.accepts(&drawing) {
+			reader.drawing = drawing
+			return true
+		}
+	}
+	reader.err = reader.scanner.Err()
+	return false
+}
+
+func (reader *NdjsonReader) Drawing() Drawing {
+	return reader.drawing
+}
+
+func (reader *NdjsonReader) Err() error {
+	return reader.err
+}
+
+// Reads at most maxDrawings accepted drawings from a file, negative reads all of them
+func GetDrawings(fileName string, filter DrawingFilter, maxDrawings int) ([]Drawing, error) {
+	dataFile, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer dataFile.Close()
+	var drawings []Drawing
+	reader := NewNdjsonReader(dataFile, filter)
+	for (maxDrawings < 0 || len(drawings) < maxDrawings) && reader.Next() {
+		drawings = append(drawings, reader.Drawing())
+	}
+	return drawings, reader.Err()
+}
@@ -0,0 +1,122 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"DoodleGan/functools"
+	"DoodleGan/preprocess"
+)
+
+const ndjsonLines = `{"word":"cat","countrycode":"US","timestamp":"2017-03-09 00:28:55.63775 UTC","recognized":true,"key_id":"5201136883597312","drawing":[[[0,10,20],[5,15,25]],[[30,40],[0,255]]]}
+{"word":"cat","countrycode":"PL","timestamp":"2017-01-23 21:25:30.06067 UTC","recognized":false,"key_id":"4752318291722240","drawing":[[[1,2],[3,4]]]}
+
+{"word":"cat","countrycode":"DE","timestamp":"2017-03-02 23:10:11.1 UTC","recognized":true,"key_id":"6210329412632576","drawing":[[[1,2],[3,4],[0,16]]]}
+`
+
+func TestNdjsonReader_Record(t *testing.T) {
+	reader := preprocess.NewNdjsonReader(strings.NewReader(ndjsonLines), preprocess.DrawingFilter{})
+	if !reader.Next() {
+		fmt.Println(reader.Err())
+		t.FailNow()
+	}
+	drawing := reader.Drawing()
+	targetTime := time.Date(2017, 3, 9, 0, 28, 55, 637750000, time.UTC)
+	if drawing.Word != "cat" || drawing.CountryCode != "US" || !drawing.Recognized ||
+		drawing.KeyID != "5201136883597312" || !drawing.Timestamp.Equal(targetTime) {
+		fmt.Println(drawing)
+		t.Fail()
+	}
+	if len(drawing.Strokes) != 2 {
+		fmt.Println(drawing.Strokes)
+		t.FailNow()
+	}
+	targetX := []float64{30, 40}
+	targetY := []float64{0, 255}
+	if !functools.IsEqual(&targetX, &drawing.Strokes[1].X, 0) ||
+		!functools.IsEqual(&targetY, &drawing.Strokes[1].Y, 0) ||
+		len(drawing.Strokes[1].T) != 0 {
+		fmt.Println(drawing.Strokes[1])
+		t.Fail()
+	}
+
+	count := 1
+	for reader.Next() {
+		count++
+	}
+	if count != 3 || reader.Err() != nil {
+		fmt.Println(count, reader.Err())
+		t.Fail()
+	}
+}
+
+func TestNdjsonReader_Filter(t *testing.T) {
+	testCases := []struct {
+		filter preprocess.DrawingFilter
+		target []string
+	}{
+		{preprocess.DrawingFilter{Recognized: preprocess.OnlyRecognized}, []string{"US", "DE"}},
+		{preprocess.DrawingFilter{Recognized: preprocess.OnlyUnrecognized}, []string{"PL"}},
+		{preprocess.DrawingFilter{Countries: []string{"PL", "DE"}}, []string{"PL", "DE"}},
+		{preprocess.DrawingFilter{Recognized: preprocess.OnlyRecognized, Countries: []string{"PL"}}, nil},
+	}
+	for _, tc := range testCases {
+		reader := preprocess.NewNdjsonReader(strings.NewReader(ndjsonLines), tc.filter)
+		var result []string
+		for reader.Next() {
+			result = append(result, reader.Drawing().CountryCode)
+		}
+		if fmt.Sprint(result) != fmt.Sprint(tc.target) || reader.Err() != nil {
+			fmt.Println(result, reader.Err())
+			t.Fail()
+		}
+	}
+}
+
+func TestNdjsonReader_RawTime(t *testing.T) {
+	filter := preprocess.DrawingFilter{Countries: []string{"DE"}}
+	reader := preprocess.NewNdjsonReader(strings.NewReader(ndjsonLines), filter)
+	if !reader.Next() {
+		fmt.Println(reader.Err())
+		t.FailNow()
+	}
+	target := []float64{0, 16}
+	result := reader.Drawing().Strokes[0].T
+	if !functools.IsEqual(&target, &result, 0) {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestNdjsonReader_Error(t *testing.T) {
+	source := ndjsonLines + `{"word":"cat","drawing":[[[1,2],[3]]]}` + "\n"
+	reader := preprocess.NewNdjsonReader(strings.NewReader(source), preprocess.DrawingFilter{})
+	count := 0
+	for reader.Next() {
+		count++
+	}
+	if count != 3 || reader.Err() == nil || !strings.Contains(reader.Err().Error(), "line 5") {
+		fmt.Println(count, reader.Err())
+		t.Fail()
+	}
+}
+
+func TestGetDrawings(t *testing.T) {
+	filePath := t.TempDir() + "/cat.ndjson"
+	if err := os.WriteFile(filePath, []byte(ndjsonLines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	drawings, err := preprocess.GetDrawings(filePath, preprocess.DrawingFilter{}, 2)
+	if err != nil || len(drawings) != 2 {
+		fmt.Println(len(drawings), err)
+		t.Fail()
+	}
+	drawings, err = preprocess.GetDrawings(filePath, preprocess.DrawingFilter{}, -1)
+	if err != nil || len(drawings) != 3 {
+		fmt.Println(len(drawings), err)
+		t.Fail()
+	}
+}
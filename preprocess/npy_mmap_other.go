@@ -0,0 +1,16 @@
+//go:build !unix
+
+package preprocess
+
+import (
+	"errors"
+	"os"
+)
+
+func mmapFile(file *os.File) ([]byte, error) {
+	return nil, errors.New("Memory mapping isn't supported on this platform")
+}
+
+func munmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,118 @@
+package preprocess
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sbinet/npyio"
+)
+
+// Fixed length uint8 rows of an npy file read on demand, only the header is parsed
+// on open. Memory mapped files return rows without copying them.
+type NpyRows struct {
+	file    *os.File
+	mapped  []byte // whole file, nil when rows are read with ReadAt
+	offset  int64  // start of array data
+	numRows int
+	rowLen  int
+}
+
+// Array of any C ordered shape is split into rows of rowLen values,
+// e.g. QuickDraw bitmaps (N x 784) into rows of 784
+func OpenNpyRows(fileName string, rowLen int, useMmap bool) (NpyRows, error) {
+	if rowLen < 1 {
+		return NpyRows{}, fmt.Errorf("Row length must be positive, have: %d", rowLen)
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return NpyRows{}, err
+	}
+	rows, err := newNpyRows(file, rowLen)
+	if err == nil && useMmap {
+		rows.mapped, err = mmapFile(file)
+	}
+	if err != nil {
+		file.Close()
+		return NpyRows{}, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return rows, nil
+}
+
+func newNpyRows(file *os.File, rowLen int) (NpyRows, error) {
+	npyReader, err := npyio.NewReader(file)
+	if err != nil {
+		return NpyRows{}, err
+	}
+	header := npyReader.Header
+	switch header.Descr.Type {
+	case "|u1", "<u1", ">u1", "u1":
+	default:
+		return NpyRows{}, fmt.Errorf("Array type must be uint8, have: %s", header.Descr.Type)
+	}
+	if header.Descr.Fortran && len(header.Descr.Shape) > 1 {
+		return NpyRows{}, fmt.Errorf("Fortran ordered array %v can't be read by rows", header.Descr.Shape)
+	}
+	size := 1
+	for _, dim := range header.Descr.Shape {
+		size *= dim
+	}
+	if size%rowLen != 0 {
+		return NpyRows{}, fmt.Errorf(
+			"Array size (%d) isn't a multiple of row length (%d)",
+			size,
+			rowLen,
+		)
+	}
+	// Header reader doesn't buffer, so the file is positioned at the data
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return NpyRows{}, err
+	}
+	return NpyRows{
+		file:    file,
+		offset:  offset,
+		numRows: size / rowLen,
+		rowLen:  rowLen,
+	}, nil
+}
+
+func (rows *NpyRows) Len() int {
+	return rows.numRows
+}
+
+func (rows *NpyRows) SampleLen() int {
+	return rows.rowLen
+}
+
+// Mapped rows are views of the file, they stay valid until Close
+func (rows *NpyRows) Row(idx int) ([]uint8, error) {
+	return rows.Rows(idx, idx+1)
+}
+
+// Rows in range [start, end) as one contiguous slice
+func (rows *NpyRows) Rows(start, end int) ([]uint8, error) {
+	if start < 0 || end > rows.numRows || start > end {
+		return nil, fmt.Errorf("Rows [%d, %d) out of range of %d rows", start, end, rows.numRows)
+	}
+	from := rows.offset + int64(start*rows.rowLen)
+	to := rows.offset + int64(end*rows.rowLen)
+	if rows.mapped != nil {
+		return rows.mapped[from:to:to], nil
+	}
+	retVal := make([]uint8, to-from)
+	if _, err := rows.file.ReadAt(retVal, from); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (rows *NpyRows) Close() error {
+	if rows.mapped != nil {
+		if err := munmapFile(rows.mapped); err != nil {
+			return err
+		}
+		rows.mapped = nil
+	}
+	return rows.file.Close()
+}
@@ -0,0 +1,83 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sbinet/npyio"
+
+	"DoodleGan/preprocess"
+)
+
+func writeNpy(t *testing.T, data any) string {
+	filePath := t.TempDir() + "/data.npy"
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := npyio.Write(file, data); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestNpyRows(t *testing.T) {
+	filePath := writeNpy(t, [4][3]uint8{
+		{1, 2, 3},
+		{4, 5, 6},
+		{7, 8, 9},
+		{10, 11, 12},
+	})
+	for _, useMmap := range []bool{false, true} {
+		rows, err := preprocess.OpenNpyRows(filePath, 3, useMmap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows.Len() != 4 || rows.SampleLen() != 3 {
+			fmt.Println(rows.Len(), rows.SampleLen())
+			t.Fail()
+		}
+		row, err := rows.Row(2)
+		if err != nil || fmt.Sprint(row) != "[7 8 9]" {
+			fmt.Println(useMmap, row, err)
+			t.Fail()
+		}
+		block, err := rows.Rows(1, 4)
+		if err != nil || fmt.Sprint(block) != "[4 5 6 7 8 9 10 11 12]" {
+			fmt.Println(useMmap, block, err)
+			t.Fail()
+		}
+		if _, err := rows.Row(4); err == nil {
+			t.Fail()
+		}
+		if err := rows.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNpyRows_Errors(t *testing.T) {
+	if _, err := preprocess.OpenNpyRows(writeNpy(t, []float64{1, 2}), 1, false); err == nil {
+		t.Fail()
+	}
+	if _, err := preprocess.OpenNpyRows(writeNpy(t, []uint8{1, 2, 3}), 2, false); err == nil {
+		t.Fail()
+	}
+	if _, err := preprocess.OpenNpyRows(t.TempDir()+"/missing.npy", 2, false); err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetSplitData(t *testing.T) {
+	filePath := writeNpy(t, []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	train, test, err := preprocess.GetSplitData(filePath, 4, 2, 0.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(train) != "[[1 2] [3 4] [5 6]]" || fmt.Sprint(test) != "[[7 8]]" {
+		fmt.Println(train, test)
+		t.Fail()
+	}
+}
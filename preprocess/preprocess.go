@@ -15,12 +15,17 @@ func GetSplitData(
 	if numberOfSamples < 0 {
 		return nil, nil, errors.New("Dataset size can't be negative")
 	}
-	rawData, err := GetRawData(fileName)
+	rows, err := OpenNpyRows(fileName, stride, false)
 	if err != nil {
 		return nil, nil, err
 	}
-	numberOfSamples = min(numberOfSamples, len(rawData)/stride)
-	reshapedData := Reshape(rawData[:numberOfSamples*stride], stride)
+	defer rows.Close()
+	numberOfSamples = min(numberOfSamples, rows.Len())
+	rawData, err := rows.Rows(0, numberOfSamples)
+	if err != nil {
+		return nil, nil, err
+	}
+	reshapedData := Reshape(rawData, stride)
 	trainSet, testSet := Split(reshapedData, trainRatio)
 	return trainSet, testSet, nil
 }
@@ -46,9 +51,7 @@ func GetRawData(fileName string) ([]uint8, error) {
 func Reshape(source []uint8, stride int) [][]uint8 {
 	var result [][]uint8
 	for i := 0; i+stride <= len(source); i += stride {
-		start := i * stride
-		end := start + stride
-		result = append(result, source[start:end])
+		result = append(result, source[i:i+stride])
 	}
 	return result
 }
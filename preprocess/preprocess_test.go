@@ -0,0 +1,26 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/preprocess"
+)
+
+// Rows start every stride values, scaling the offset by stride again
+// went out of range from the second row on
+func TestReshape(t *testing.T) {
+	result := preprocess.Reshape([]uint8{1, 2, 3, 4, 5, 6, 7}, 3)
+	if fmt.Sprint(result) != "[[1 2 3] [4 5 6]]" {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
+
+func TestReshape_Many_Rows(t *testing.T) {
+	result := preprocess.Reshape([]uint8{1, 2, 3, 4, 5, 6, 7, 8}, 2)
+	if fmt.Sprint(result) != "[[1 2] [3 4] [5 6] [7 8]]" {
+		fmt.Println(result)
+		t.Fail()
+	}
+}
@@ -0,0 +1,160 @@
+package preprocess
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+/*
+
+   Renders strokes the same way QuickDraw npy bitmaps were made from simplified drawings.
+   Drawing is centered in a 256 x 256 canvas, padded by padding + lineDiameter / 2 on every side
+   and scaled to side x side. Strokes have round caps and joins.
+
+*/
+
+const canvasSide = 256.0
+
+type Rasterizer struct {
+	side         int
+	lineDiameter float64 // in canvas units, scaled with the drawing
+	padding      float64 // in canvas units
+	antiAlias    bool
+}
+
+func NewRasterizer(side int, lineDiameter, padding float64, antiAlias bool) Rasterizer {
+	if side < 1 {
+		panic(fmt.Sprintf("NewRasterizer fail:\n\tSide must be positive, have: %d", side))
+	}
+	if lineDiameter <= 0.0 || padding < 0.0 {
+		mess := fmt.Sprintf(
+			"NewRasterizer fail:\n\tLine diameter (%f) must be positive and padding (%f) non negative",
+			lineDiameter,
+			padding,
+		)
+		panic(mess)
+	}
+	return Rasterizer{
+		side:         side,
+		lineDiameter: lineDiameter,
+		padding:      padding,
+		antiAlias:    antiAlias,
+	}
+}
+
+// Settings used for QuickDraw 28 x 28 npy bitmaps
+func NewQuickDrawRasterizer() Rasterizer {
+	return NewRasterizer(28, 16.0, 16.0, true)
+}
+
+func (r *Rasterizer) Side() int {
+	return r.side
+}
+
+// Grayscale image, side x side values in row major order, 0 is background
+func (r *Rasterizer) Rasterize(strokes []Stroke) []uint8 {
+	intensity := r.render(strokes)
+	retVal := make([]uint8, len(intensity))
+	for i, v := range intensity {
+		retVal[i] = uint8(math.Round(v * 255.0))
+	}
+	return retVal
+}
+
+// Values in [0, 1], raw data of the matrix is an input of Conv2D.ArrayToConv2DInput
+func (r *Rasterizer) RasterizeMat(strokes []Stroke) mat.Dense {
+	return *mat.NewDense(r.side, r.side, r.render(strokes))
+}
+
+func (r *Rasterizer) render(strokes []Stroke) []float64 {
+	intensity := make([]float64, r.side*r.side)
+	totalPadding := 2.0*r.padding + r.lineDiameter
+	scale := float64(r.side) / (canvasSide + totalPadding)
+	radius := r.lineDiameter / 2.0 * scale
+	for _, stroke := range r.toPixels(strokes, totalPadding/2.0, scale) {
+		coverage := r.strokeCoverage(stroke, radius)
+		// Every stroke is painted over the image drawn so far
+		for i, c := range coverage {
+			intensity[i] += c * (1.0 - intensity[i])
+		}
+	}
+	return intensity
+}
+
+// Centers the drawing in the canvas and moves it to output pixel coordinates.
+// Drawings bigger than the canvas (raw format) are scaled down to fit it first.
+func (r *Rasterizer) toPixels(strokes []Stroke, shift, scale float64) [][][2]float64 {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, stroke := range strokes {
+		for i := range stroke.X {
+			minX, maxX = min(minX, stroke.X[i]), max(maxX, stroke.X[i])
+			minY, maxY = min(minY, stroke.Y[i]), max(maxY, stroke.Y[i])
+		}
+	}
+	fit := 1.0
+	if span := max(maxX-minX, maxY-minY); span > canvasSide-1.0 {
+		fit = (canvasSide - 1.0) / span
+	}
+	offsetX := (canvasSide - (maxX-minX)*fit) / 2.0
+	offsetY := (canvasSide - (maxY-minY)*fit) / 2.0
+
+	retVal := make([][][2]float64, len(strokes))
+	for s, stroke := range strokes {
+		retVal[s] = make([][2]float64, len(stroke.X))
+		for i := range stroke.X {
+			retVal[s][i] = [2]float64{
+				((stroke.X[i]-minX)*fit + offsetX + shift) * scale,
+				((stroke.Y[i]-minY)*fit + offsetY + shift) * scale,
+			}
+		}
+	}
+	return retVal
+}
+
+// Coverage of every pixel by one stroke, computed from distance of pixel center
+// to the closest segment, anti-aliased edge is one pixel wide
+func (r *Rasterizer) strokeCoverage(points [][2]float64, radius float64) []float64 {
+	coverage := make([]float64, r.side*r.side)
+	if len(points) == 0 {
+		return coverage
+	}
+	for i := range points {
+		a := points[i]
+		b := points[max(i-1, 0)]
+		minCol := max(int(math.Floor(min(a[0], b[0])-radius-1.0)), 0)
+		maxCol := min(int(math.Ceil(max(a[0], b[0])+radius+1.0)), r.side-1)
+		minRow := max(int(math.Floor(min(a[1], b[1])-radius-1.0)), 0)
+		maxRow := min(int(math.Ceil(max(a[1], b[1])+radius+1.0)), r.side-1)
+		for row := minRow; row <= maxRow; row++ {
+			for col := minCol; col <= maxCol; col++ {
+				dist := segmentDistance(float64(col)+0.5, float64(row)+0.5, a, b)
+				c := r.pixelCoverage(dist, radius)
+				idx := row*r.side + col
+				coverage[idx] = max(coverage[idx], c)
+			}
+		}
+	}
+	return coverage
+}
+
+func (r *Rasterizer) pixelCoverage(dist, radius float64) float64 {
+	if r.antiAlias {
+		return min(max(radius-dist+0.5, 0.0), 1.0)
+	}
+	if dist <= radius {
+		return 1.0
+	}
+	return 0.0
+}
+
+func segmentDistance(x, y float64, a, b [2]float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0.0 {
+		t = min(max(((x-a[0])*dx+(y-a[1])*dy)/lengthSquared, 0.0), 1.0)
+	}
+	return math.Hypot(x-a[0]-t*dx, y-a[1]-t*dy)
+}
@@ -0,0 +1,87 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/conv"
+	"DoodleGan/preprocess"
+)
+
+var horizontalLine = []preprocess.Stroke{
+	{X: []float64{0, 255}, Y: []float64{0, 0}},
+}
+
+// Line is centered vertically, so it lies on the border of two middle rows
+func TestRasterizer_Center(t *testing.T) {
+	rasterizer := preprocess.NewQuickDrawRasterizer()
+	image := rasterizer.Rasterize(horizontalLine)
+	if len(image) != 28*28 {
+		fmt.Println(len(image))
+		t.FailNow()
+	}
+	for col := range 28 {
+		if image[13*28+col] != image[14*28+col] || image[12*28+col] != 0 || image[15*28+col] != 0 {
+			fmt.Println(col, image[12*28+col], image[13*28+col], image[14*28+col], image[15*28+col])
+			t.Fail()
+		}
+	}
+	// Padding keeps the first and the last column empty
+	if image[13*28] != 0 || image[13*28+27] != 0 || image[13*28+14] == 0 {
+		fmt.Println(image[13*28 : 14*28])
+		t.Fail()
+	}
+}
+
+func TestRasterizer_AntiAlias(t *testing.T) {
+	stroke := []preprocess.Stroke{
+		{X: []float64{0, 100, 255}, Y: []float64{0, 255, 30}},
+	}
+	aliased := preprocess.NewRasterizer(64, 16, 16, false)
+	antiAliased := preprocess.NewRasterizer(64, 16, 16, true)
+	partial := 0
+	for _, v := range aliased.Rasterize(stroke) {
+		if v != 0 && v != 255 {
+			fmt.Println(v)
+			t.Fail()
+			break
+		}
+	}
+	for _, v := range antiAliased.Rasterize(stroke) {
+		if v != 0 && v != 255 {
+			partial++
+		}
+	}
+	if partial == 0 {
+		t.Fail()
+	}
+}
+
+func TestRasterizer_Empty(t *testing.T) {
+	rasterizer := preprocess.NewRasterizer(10, 4, 0, true)
+	for _, v := range rasterizer.Rasterize(nil) {
+		if v != 0 {
+			fmt.Println(v)
+			t.Fail()
+		}
+	}
+}
+
+func TestRasterizer_Conv2DInput(t *testing.T) {
+	rasterizer := preprocess.NewRasterizer(32, 8, 8, true)
+	image := rasterizer.RasterizeMat(horizontalLine)
+	layer := conv.NewConv2D([2]int{3, 3}, 2, [2]int{32, 32}, 1, [2]int{1, 1}, [4]int{1, 1, 1, 1})
+	layer.InitFilterRandom(-1, 1)
+	input := layer.ArrayToConv2DInput(image.RawMatrix().Data)
+	if n, c, h, w := layer.Forward(input).Dims(); n != 1 || c != 2 || h != 32 || w != 32 {
+		fmt.Println(n, c, h, w)
+		t.Fail()
+	}
+	for _, v := range image.RawMatrix().Data {
+		if v < 0.0 || v > 1.0 {
+			fmt.Println(v)
+			t.Fail()
+			break
+		}
+	}
+}
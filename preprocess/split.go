@@ -0,0 +1,193 @@
+package preprocess
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Sample indices of every part, test holds what is left after train and validation
+type SplitIdxs struct {
+	Train      []int
+	Validation []int
+	Test       []int
+}
+
+type Fold struct {
+	Train      []int
+	Validation []int
+}
+
+// Nil rng keeps the original order, otherwise indices are shuffled first
+func SplitIndices(n int, trainRatio, validationRatio float64, rng *rand.Rand) SplitIdxs {
+	checkValidRatios(trainRatio, validationRatio, "SplitIndices")
+	var retVal SplitIdxs
+	retVal.add(permutation(n, rng), trainRatio, validationRatio)
+	return retVal
+}
+
+// Every class is split with the same ratios, so all parts keep class proportions
+func StratifiedSplitIndices(
+	labels []int,
+	trainRatio, validationRatio float64,
+	rng *rand.Rand,
+) SplitIdxs {
+	checkValidRatios(trainRatio, validationRatio, "StratifiedSplitIndices")
+	var retVal SplitIdxs
+	for _, classIdxs := range classIndices(labels, rng) {
+		retVal.add(classIdxs, trainRatio, validationRatio)
+	}
+	return retVal
+}
+
+// Folds of n samples differ in size by at most one, every sample is validated once
+func KFold(n, k int, rng *rand.Rand) []Fold {
+	checkValidFolds(n, k, "KFold")
+	idxs := permutation(n, rng)
+	parts := make([][]int, k)
+	for f := range k {
+		parts[f] = idxs[f*n/k : (f+1)*n/k]
+	}
+	return foldsFromParts(parts)
+}
+
+// Samples of every class are dealt to folds in turn, so every fold keeps class proportions
+func StratifiedKFold(labels []int, k int, rng *rand.Rand) []Fold {
+	checkValidFolds(len(labels), k, "StratifiedKFold")
+	parts := make([][]int, k)
+	f := 0
+	for _, classIdxs := range classIndices(labels, rng) {
+		for _, idx := range classIdxs {
+			parts[f] = append(parts[f], idx)
+			f = (f + 1) % k
+		}
+	}
+	return foldsFromParts(parts)
+}
+
+// Labels of every sample of source, to stratify its splits
+func Labels(source LabelledSource) []int {
+	retVal := make([]int, source.Len())
+	for i := range retVal {
+		retVal[i] = source.Label(i)
+	}
+	return retVal
+}
+
+// Part of a source seen through sample indices, e.g. train set of a split
+type Subset struct {
+	source LabelledSource
+	idxs   []int
+}
+
+func NewSubset(source LabelledSource, idxs []int) Subset {
+	for _, idx := range idxs {
+		if idx < 0 || idx >= source.Len() {
+			mess := fmt.Sprintf(
+				"NewSubset fail:\n\tIndex %d out of range of %d samples",
+				idx,
+				source.Len(),
+			)
+			panic(mess)
+		}
+	}
+	return Subset{source: source, idxs: idxs}
+}
+
+func (subset *Subset) Len() int {
+	return len(subset.idxs)
+}
+
+func (subset *Subset) SampleLen() int {
+	return subset.source.SampleLen()
+}
+
+func (subset *Subset) NumClasses() int {
+	return subset.source.NumClasses()
+}
+
+func (subset *Subset) Sample(idx int) []uint8 {
+	return subset.source.Sample(subset.idxs[idx])
+}
+
+func (subset *Subset) Label(idx int) int {
+	return subset.source.Label(subset.idxs[idx])
+}
+
+func (split *SplitIdxs) add(idxs []int, trainRatio, validationRatio float64) {
+	trainSize := int(float64(len(idxs)) * trainRatio)
+	validationSize := int(float64(len(idxs)) * validationRatio)
+	split.Train = append(split.Train, idxs[:trainSize]...)
+	split.Validation = append(split.Validation, idxs[trainSize:trainSize+validationSize]...)
+	split.Test = append(split.Test, idxs[trainSize+validationSize:]...)
+}
+
+func permutation(n int, rng *rand.Rand) []int {
+	if rng != nil {
+		return rng.Perm(n)
+	}
+	retVal := make([]int, n)
+	for i := range n {
+		retVal[i] = i
+	}
+	return retVal
+}
+
+// Indices of every class in order of labels, shuffled within the class if rng isn't nil
+func classIndices(labels []int, rng *rand.Rand) [][]int {
+	var retVal [][]int
+	for i, label := range labels {
+		if label < 0 {
+			panic(fmt.Sprintf("Stratification fail:\n\tLabel can't be negative, have: %d", label))
+		}
+		for len(retVal) <= label {
+			retVal = append(retVal, nil)
+		}
+		retVal[label] = append(retVal[label], i)
+	}
+	if rng != nil {
+		for _, classIdxs := range retVal {
+			rng.Shuffle(len(classIdxs), func(i, j int) {
+				classIdxs[i], classIdxs[j] = classIdxs[j], classIdxs[i]
+			})
+		}
+	}
+	return retVal
+}
+
+func foldsFromParts(parts [][]int) []Fold {
+	retVal := make([]Fold, len(parts))
+	for f := range parts {
+		retVal[f].Validation = slices.Clone(parts[f])
+		for other := range parts {
+			if other != f {
+				retVal[f].Train = append(retVal[f].Train, parts[other]...)
+			}
+		}
+	}
+	return retVal
+}
+
+func checkValidRatios(trainRatio, validationRatio float64, funcName string) {
+	if trainRatio < 0.0 || validationRatio < 0.0 || trainRatio+validationRatio > 1.0 {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tRatios (%f, %f) must be non negative and sum up to at most 1",
+			funcName,
+			trainRatio,
+			validationRatio,
+		)
+		panic(mess)
+	}
+}
+
+func checkValidFolds(n, k int, funcName string) {
+	if k < 2 || k > n {
+		mess := fmt.Sprintf(
+			"%s fail:\n\tNumber of folds (%d) must be in range [2, %d]",
+			funcName,
+			k,
+			n,
+		)
+		panic(mess)
+	}
+}
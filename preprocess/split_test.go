@@ -0,0 +1,111 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"DoodleGan/preprocess"
+)
+
+func classCounts(labels, idxs []int) []int {
+	retVal := make([]int, 3)
+	for _, idx := range idxs {
+		retVal[labels[idx]]++
+	}
+	return retVal
+}
+
+func TestSplitIndices(t *testing.T) {
+	split := preprocess.SplitIndices(10, 0.6, 0.2, nil)
+	if fmt.Sprint(split.Train) != "[0 1 2 3 4 5]" ||
+		fmt.Sprint(split.Validation) != "[6 7]" ||
+		fmt.Sprint(split.Test) != "[8 9]" {
+		fmt.Println(split)
+		t.Fail()
+	}
+
+	first := preprocess.SplitIndices(10, 0.6, 0.2, rand.New(rand.NewSource(5)))
+	second := preprocess.SplitIndices(10, 0.6, 0.2, rand.New(rand.NewSource(5)))
+	if fmt.Sprint(first) != fmt.Sprint(second) || fmt.Sprint(first) == fmt.Sprint(split) {
+		fmt.Println(first)
+		t.Fail()
+	}
+	all := append(append(slices.Clone(first.Train), first.Validation...), first.Test...)
+	slices.Sort(all)
+	if fmt.Sprint(all) != "[0 1 2 3 4 5 6 7 8 9]" {
+		fmt.Println(all)
+		t.Fail()
+	}
+}
+
+func TestStratifiedSplitIndices(t *testing.T) {
+	labels := make([]int, 0, 30)
+	for i := range 30 {
+		labels = append(labels, i%3)
+	}
+	split := preprocess.StratifiedSplitIndices(labels, 0.6, 0.2, rand.New(rand.NewSource(1)))
+	if fmt.Sprint(classCounts(labels, split.Train)) != "[6 6 6]" ||
+		fmt.Sprint(classCounts(labels, split.Validation)) != "[2 2 2]" ||
+		fmt.Sprint(classCounts(labels, split.Test)) != "[2 2 2]" {
+		fmt.Println(split)
+		t.Fail()
+	}
+}
+
+func TestKFold(t *testing.T) {
+	folds := preprocess.KFold(10, 3, rand.New(rand.NewSource(2)))
+	validated := []int{}
+	for _, fold := range folds {
+		if len(fold.Train)+len(fold.Validation) != 10 || len(fold.Validation) < 3 || len(fold.Validation) > 4 {
+			fmt.Println(fold)
+			t.Fail()
+		}
+		for _, idx := range fold.Validation {
+			if slices.Contains(fold.Train, idx) {
+				fmt.Println(fold)
+				t.Fail()
+			}
+		}
+		validated = append(validated, fold.Validation...)
+	}
+	slices.Sort(validated)
+	if fmt.Sprint(validated) != "[0 1 2 3 4 5 6 7 8 9]" {
+		fmt.Println(validated)
+		t.Fail()
+	}
+}
+
+func TestStratifiedKFold(t *testing.T) {
+	labels := []int{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2}
+	folds := preprocess.StratifiedKFold(labels, 4, rand.New(rand.NewSource(3)))
+	for _, fold := range folds {
+		if fmt.Sprint(classCounts(labels, fold.Validation)) != "[1 1 1]" ||
+			fmt.Sprint(classCounts(labels, fold.Train)) != "[3 3 3]" {
+			fmt.Println(fold)
+			t.Fail()
+		}
+	}
+	again := preprocess.StratifiedKFold(labels, 4, rand.New(rand.NewSource(3)))
+	if fmt.Sprint(folds) != fmt.Sprint(again) {
+		t.Fail()
+	}
+}
+
+func TestSubset(t *testing.T) {
+	source := &countingSource{6}
+	split := preprocess.StratifiedSplitIndices(preprocess.Labels(source), 0.5, 0, nil)
+	train := preprocess.NewSubset(source, split.Train)
+	if train.Len() != 3 || train.Label(1) != 1 || train.Sample(2)[0] != 2 {
+		fmt.Println(split.Train)
+		t.Fail()
+	}
+	it := preprocess.NewBatchIterator(&train, 3, [3]int{1, 2, 2}, nil, preprocess.BatchOptions{})
+	defer it.Close()
+	batch, _ := it.Next()
+	if fmt.Sprint(batch.Labels) != "[0 1 2]" {
+		fmt.Println(batch.Labels)
+		t.Fail()
+	}
+}
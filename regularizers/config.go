@@ -0,0 +1,55 @@
+package regularizers
+
+import "fmt"
+
+// Serializable description of a regularizer
+type Config struct {
+	Type   string  `json:"type"`
+	Lambda float64 `json:"lambda"`
+}
+
+func GetConfig(regularizer Regularizer) (Config, error) {
+	switch r := regularizer.(type) {
+	case *L1:
+		return Config{Type: "L1", Lambda: r.lambda}, nil
+	case *L2:
+		return Config{Type: "L2", Lambda: r.lambda}, nil
+	}
+	return Config{}, fmt.Errorf("GetConfig fail: unsupported regularizer type %T", regularizer)
+}
+
+func NewFromConfig(config *Config) (Regularizer, error) {
+	switch config.Type {
+	case "L1":
+		regularizer := NewL1(config.Lambda)
+		return &regularizer, nil
+	case "L2":
+		regularizer := NewL2(config.Lambda)
+		return &regularizer, nil
+	}
+	return nil, fmt.Errorf("NewFromConfig fail: unknown regularizer type %q", config.Type)
+}
+
+func GetConfigs(regularizerList []Regularizer) ([]Config, error) {
+	configs := make([]Config, 0, len(regularizerList))
+	for _, regularizer := range regularizerList {
+		config, err := GetConfig(regularizer)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+func NewFromConfigs(configs []Config) ([]Regularizer, error) {
+	regularizerList := make([]Regularizer, 0, len(configs))
+	for i := range configs {
+		regularizer, err := NewFromConfig(&configs[i])
+		if err != nil {
+			return nil, err
+		}
+		regularizerList = append(regularizerList, regularizer)
+	}
+	return regularizerList, nil
+}
@@ -0,0 +1,72 @@
+package regularizers
+
+import (
+	"fmt"
+	"math"
+)
+
+// Penalty of layer weights added to the loss, biases aren't regularized
+type Regularizer interface {
+	Penalty(weights []float64) float64
+	// Adds gradient of the penalty to grads of the same length as weights
+	AddGrads(weights, grads []float64)
+}
+
+// lambda * sum(|w|)
+type L1 struct {
+	lambda float64
+}
+
+func NewL1(lambda float64) L1 {
+	checkValidLambda(&lambda, "NewL1")
+	return L1{lambda: lambda}
+}
+
+func (r *L1) Penalty(weights []float64) float64 {
+	sum := 0.0
+	for _, w := range weights {
+		sum += math.Abs(w)
+	}
+	return r.lambda * sum
+}
+
+// Subgradient at 0 is 0
+func (r *L1) AddGrads(weights, grads []float64) {
+	for i, w := range weights {
+		if w > 0.0 {
+			grads[i] += r.lambda
+		} else if w < 0.0 {
+			grads[i] -= r.lambda
+		}
+	}
+}
+
+// lambda * sum(w^2)
+type L2 struct {
+	lambda float64
+}
+
+func NewL2(lambda float64) L2 {
+	checkValidLambda(&lambda, "NewL2")
+	return L2{lambda: lambda}
+}
+
+func (r *L2) Penalty(weights []float64) float64 {
+	sum := 0.0
+	for _, w := range weights {
+		sum += w * w
+	}
+	return r.lambda * sum
+}
+
+func (r *L2) AddGrads(weights, grads []float64) {
+	for i, w := range weights {
+		grads[i] += 2.0 * r.lambda * w
+	}
+}
+
+func checkValidLambda(lambda *float64, funcName string) {
+	if *lambda < 0.0 {
+		panic(fmt.Sprintf("%s fail:\n\tlambda can't be less than 0", funcName))
+	}
+}
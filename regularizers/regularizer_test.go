@@ -0,0 +1,84 @@
+package regularizers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"DoodleGan/functools"
+	"DoodleGan/regularizers"
+)
+
+func TestL1(t *testing.T) {
+	regularizer := regularizers.NewL1(0.1)
+	weights := []float64{2, -3, 0, 0.5}
+	grads := []float64{1, 1, 1, 1}
+
+	targetPenalty := 0.55
+	resultPenalty := regularizer.Penalty(weights)
+	regularizer.AddGrads(weights, grads)
+	targetGrads := []float64{1.1, 0.9, 1, 1.1}
+	if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+		fmt.Println(resultPenalty)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetGrads, &grads, 1e-12) {
+		fmt.Println(grads)
+		t.Fail()
+	}
+}
+
+func TestL2(t *testing.T) {
+	regularizer := regularizers.NewL2(0.5)
+	weights := []float64{2, -3, 0, 0.5}
+	grads := []float64{1, 1, 1, 1}
+
+	targetPenalty := 6.625
+	resultPenalty := regularizer.Penalty(weights)
+	regularizer.AddGrads(weights, grads)
+	targetGrads := []float64{3, -2, 1, 1.5}
+	if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+		fmt.Println(resultPenalty)
+		t.Fail()
+	}
+	if !functools.IsEqual(&targetGrads, &grads, 1e-12) {
+		fmt.Println(grads)
+		t.Fail()
+	}
+}
+
+func TestL2_negative_lambda_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	regularizers.NewL2(-0.1)
+}
+
+func TestConfig_RoundTrip(t *testing.T) {
+	l1 := regularizers.NewL1(0.1)
+	l2 := regularizers.NewL2(0.5)
+	weights := []float64{2, -3, 0, 0.5}
+	for _, regularizer := range []regularizers.Regularizer{&l1, &l2} {
+		config, err := regularizers.GetConfig(regularizer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := regularizers.NewFromConfig(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		targetPenalty := regularizer.Penalty(weights)
+		resultPenalty := loaded.Penalty(weights)
+		if !functools.IsEqualVal(&targetPenalty, &resultPenalty, 1e-12) {
+			fmt.Println(config, targetPenalty, resultPenalty)
+			t.Fail()
+		}
+	}
+}
+
+func TestNewFromConfig_Unknown(t *testing.T) {
+	if _, err := regularizers.NewFromConfig(&regularizers.Config{Type: "L3", Lambda: 0.1}); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,9 @@
+package tensor
+
+// Trainable values of a layer with gradients of the last backward pass.
+// Value shares data with the layer, so updating it updates the layer.
+type Param struct {
+	Name  string
+	Value *Tensor
+	Grad  *Tensor
+}
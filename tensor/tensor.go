@@ -0,0 +1,194 @@
+package tensor
+
+import (
+	"fmt"
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Batch of N samples, each with C channels of H x W values.
+// Data is stored contiguously in N, C, H, W order, so a sample, a channel
+// and a row are all continuous slices of it.
+// Dense layers see every sample as a flat vector of C * H * W values.
+type Tensor struct {
+	n, c, h, w int
+	data       []float64
+}
+
+// Allocates zeroed data when data is nil, otherwise data is used directly
+func New(n, c, h, w int, data []float64) *Tensor {
+	if n < 1 || c < 1 || h < 1 || w < 1 {
+		mess := fmt.Sprintf(
+			"New tensor fail:\n\tDimensions (%d x %d x %d x %d) must be positive",
+			n, c, h, w,
+		)
+		panic(mess)
+	}
+	if data == nil {
+		data = make([]float64, n*c*h*w)
+	}
+	if len(data) != n*c*h*w {
+		mess := fmt.Sprintf(
+			"New tensor fail:\n\tData length (%d) doesn't match dimensions %d * %d * %d * %d",
+			len(data),
+			n, c, h, w,
+		)
+		panic(mess)
+	}
+	return &Tensor{n: n, c: c, h: h, w: w, data: data}
+}
+
+// Single sample 1 x 1 x 1 x L, data is shared with v
+func FromVec(v *mat.VecDense) *Tensor {
+	return New(1, 1, 1, v.Len(), v.RawVector().Data)
+}
+
+// Batch of flat samples N x 1 x 1 x L, data is copied
+func FromVecs(vs []mat.VecDense) *Tensor {
+	if len(vs) == 0 {
+		panic("FromVecs fail:\n\tNo vectors given")
+	}
+	sampleLen := vs[0].Len()
+	data := make([]float64, 0, len(vs)*sampleLen)
+	for i := range vs {
+		if vs[i].Len() != sampleLen {
+			mess := fmt.Sprintf(
+				"FromVecs fail:\n\tVector %d length (%d) doesn't match first vector length (%d)",
+				i,
+				vs[i].Len(),
+				sampleLen,
+			)
+			panic(mess)
+		}
+		for j := range sampleLen {
+			data = append(data, vs[i].AtVec(j))
+		}
+	}
+	return New(len(vs), 1, 1, sampleLen, data)
+}
+
+// Single sample 1 x C x H x W from channel matrices, data is copied
+func FromMats(ms []mat.Dense) *Tensor {
+	if len(ms) == 0 {
+		panic("FromMats fail:\n\tNo matrices given")
+	}
+	h, w := ms[0].Dims()
+	data := make([]float64, 0, len(ms)*h*w)
+	for c := range ms {
+		ch, cw := ms[c].Dims()
+		if ch != h || cw != w {
+			mess := fmt.Sprintf(
+				"FromMats fail:\n\tChannel %d size (%d x %d) doesn't match first channel size (%d x %d)",
+				c, ch, cw,
+				h, w,
+			)
+			panic(mess)
+		}
+		for i := range h {
+			for j := range w {
+				data = append(data, ms[c].At(i, j))
+			}
+		}
+	}
+	return New(1, len(ms), h, w, data)
+}
+
+func (t *Tensor) Dims() (int, int, int, int) {
+	return t.n, t.c, t.h, t.w
+}
+
+// Number of samples in the batch
+func (t *Tensor) Len() int {
+	return t.n
+}
+
+func (t *Tensor) SampleLen() int {
+	return t.c * t.h * t.w
+}
+
+func (t *Tensor) RawData() []float64 {
+	return t.data
+}
+
+func (t *Tensor) At(n, c, i, j int) float64 {
+	return t.data[t.offset(n, c)+i*t.w+j]
+}
+
+func (t *Tensor) Set(n, c, i, j int, v float64) {
+	t.data[t.offset(n, c)+i*t.w+j] = v
+}
+
+// Flat view of sample n
+func (t *Tensor) Sample(n int) []float64 {
+	start := n * t.SampleLen()
+	return t.data[start : start+t.SampleLen() : start+t.SampleLen()]
+}
+
+// Flat view of sample n
+func (t *Tensor) Vec(n int) *mat.VecDense {
+	return mat.NewVecDense(t.SampleLen(), t.Sample(n))
+}
+
+// View of the whole batch as N x (C * H * W) matrix, one sample per row
+func (t *Tensor) BatchMat() *mat.Dense {
+	return mat.NewDense(t.n, t.SampleLen(), t.data)
+}
+
+// Flat view of channel c of sample n
+func (t *Tensor) Channel(n, c int) []float64 {
+	start := t.offset(n, c)
+	return t.data[start : start+t.h*t.w : start+t.h*t.w]
+}
+
+// View of channel c of sample n
+func (t *Tensor) Mat(n, c int) *mat.Dense {
+	return mat.NewDense(t.h, t.w, t.Channel(n, c))
+}
+
+// Views of all channels of sample n
+func (t *Tensor) Mats(n int) []mat.Dense {
+	retVal := make([]mat.Dense, t.c)
+	for c := range t.c {
+		retVal[c] = *t.Mat(n, c)
+	}
+	return retVal
+}
+
+// Same data seen with different sample dimensions, number of samples is kept
+func (t *Tensor) Reshape(c, h, w int) *Tensor {
+	if c*h*w != t.SampleLen() {
+		mess := fmt.Sprintf(
+			"Reshape fail:\n\tSample length (%d) doesn't match %d channels of size %d x %d",
+			t.SampleLen(),
+			c, h, w,
+		)
+		panic(mess)
+	}
+	return New(t.n, c, h, w, t.data)
+}
+
+func (t *Tensor) Clone() *Tensor {
+	return New(t.n, t.c, t.h, t.w, slices.Clone(t.data))
+}
+
+func (t *Tensor) Zero() {
+	clear(t.data)
+}
+
+func (t *Tensor) SameShape(other *Tensor) bool {
+	return t.n == other.n && t.c == other.c && t.h == other.h && t.w == other.w
+}
+
+// Returns new tensor with fn applied to every value
+func (t *Tensor) Map(fn func(v float64) float64) *Tensor {
+	retVal := New(t.n, t.c, t.h, t.w, nil)
+	for i, v := range t.data {
+		retVal.data[i] = fn(v)
+	}
+	return retVal
+}
+
+func (t *Tensor) offset(n, c int) int {
+	return (n*t.c + c) * t.h * t.w
+}
@@ -0,0 +1,98 @@
+package tensor_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"DoodleGan/functools"
+	"DoodleGan/tensor"
+)
+
+func TestTensor_Views(t *testing.T) {
+	data := []float64{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+
+		9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	input := tensor.New(2, 2, 2, 2, data)
+	if input.Len() != 2 || input.SampleLen() != 8 {
+		fmt.Println(input.Dims())
+		t.Fatal()
+	}
+	if input.At(1, 0, 1, 0) != 11 {
+		fmt.Println(input.At(1, 0, 1, 0))
+		t.Fail()
+	}
+	if !reflect.DeepEqual(input.Sample(1), []float64{9, 10, 11, 12, 13, 14, 15, 16}) {
+		fmt.Println(input.Sample(1))
+		t.Fail()
+	}
+	if !reflect.DeepEqual(input.Channel(0, 1), []float64{5, 6, 7, 8}) {
+		fmt.Println(input.Channel(0, 1))
+		t.Fail()
+	}
+	target := mat.NewDense(2, 2, []float64{13, 14, 15, 16})
+	if !functools.IsEqualMat(target, input.Mat(1, 1), 1e-12) {
+		functools.PrintMat(input.Mat(1, 1), 1)
+		t.Fail()
+	}
+
+	input.Mat(0, 0).Set(0, 1, -2)
+	if data[1] != -2 {
+		fmt.Println(data)
+		t.Fail()
+	}
+}
+
+func TestTensor_BatchMat(t *testing.T) {
+	input := tensor.FromVecs([]mat.VecDense{
+		*mat.NewVecDense(3, []float64{1, 2, 3}),
+		*mat.NewVecDense(3, []float64{4, 5, 6}),
+	})
+	target := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	if !functools.IsEqualMat(target, input.BatchMat(), 1e-12) {
+		functools.PrintMat(input.BatchMat(), 1)
+		t.Fail()
+	}
+}
+
+func TestTensor_Reshape(t *testing.T) {
+	input := tensor.FromMats([]mat.Dense{
+		*mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}),
+		*mat.NewDense(2, 3, []float64{7, 8, 9, 10, 11, 12}),
+	})
+	flat := input.Reshape(1, 1, 12)
+	target := mat.NewVecDense(12, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	if !functools.IsEqualVec(target, flat.Vec(0), 1e-12) {
+		fmt.Println(flat.RawData())
+		t.Fail()
+	}
+	maps := flat.Reshape(3, 2, 2)
+	if maps.At(0, 2, 1, 1) != 12 {
+		fmt.Println(maps.Mats(0))
+		t.Fail()
+	}
+}
+
+func TestTensor_Reshape_Length_Mismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	tensor.New(2, 1, 2, 3, nil).Reshape(1, 2, 2)
+}
+
+func TestTensor_New_Length_Mismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	tensor.New(1, 2, 2, 2, make([]float64, 7))
+}